@@ -24,6 +24,7 @@ func cmdLineArguments() (server.ServerOpts, bool, bool) {
 	var stdlibPath = flag.String("stdlib-path", "", "Path to stdlib sources. Allows stdlib inspections.")
 
 	var diagnosticsDelay = flag.Int("diagnostics-delay", 2000, "Delay calculation of code diagnostics after modifications in source. In milliseconds, default 2000 ms.")
+	var diagnosticsMode = flag.String("diagnostics-mode", server.DiagnosticsModeAuto, "How c3c checks the code: 'project' builds the whole project, 'file' compiles the open files with their unsaved changes, 'auto' uses 'project' when there's a project.json.")
 
 	flag.Parse()
 
@@ -56,6 +57,16 @@ func cmdLineArguments() (server.ServerOpts, bool, bool) {
 		Diagnostics: server.DiagnosticsOpts{
 			Delay:   time.Duration(*diagnosticsDelay),
 			Enabled: true,
+			Mode:    *diagnosticsMode,
+		},
+		Cache: server.CacheOpts{
+			Enabled: true,
+			Path:    option.None[string](),
+		},
+		InlayHints: server.InlayHintsOpts{
+			ParameterNames: true,
+			InferredTypes:  true,
+			EnumValues:     true,
 		},
 		LogFilepath:      logFilePathOpt,
 		Debug:            *debug,
@@ -72,6 +83,9 @@ func printHelp(appName string, version string, commit string) {
 
 	fmt.Println("\nOptions")
 	flag.PrintDefaults()
+
+	fmt.Println("\nCommands")
+	fmt.Println("  format [options] [files]\n    \tFormats C3 files, or the standard input. Run 'format -help' for its options.")
 }
 
 func buildInfo() string {
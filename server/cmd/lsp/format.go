@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/formatter"
+	"github.com/pherrymason/c3-lsp/internal/lsp/server"
+)
+
+// runFormat formats the files given in args, or the standard input when there are none.
+// Options are read from ./c3lsp.json and can be overridden with flags.
+// Returns the exit code.
+func runFormat(args []string) int {
+	flags := flag.NewFlagSet("format", flag.ExitOnError)
+	write := flags.Bool("w", false, "Write the result to the files instead of the standard output")
+	indentWidth := flags.Int("indent-width", 0, "Columns of an indentation level")
+	useTabs := flags.Bool("use-tabs", true, "Indent with tabs instead of spaces")
+	maxLineWidth := flags.Int("max-line-width", 0, "Lines longer than this are wrapped. 0 disables wrapping")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s format [options] [files]\n\nOptions\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	options := formatterOptions()
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "indent-width":
+			options.IndentWidth = *indentWidth
+		case "use-tabs":
+			options.UseTabs = *useTabs
+		case "max-line-width":
+			options.MaxLineWidth = *maxLineWidth
+		}
+	})
+	options = options.Normalized()
+
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		formatted, err := formatter.Format(string(source), options)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "<stdin>: %v\n", err)
+			return 1
+		}
+		fmt.Print(formatted)
+
+		return 0
+	}
+
+	exitCode := 0
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+			continue
+		}
+
+		formatted, err := formatter.Format(string(source), options)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			exitCode = 1
+			continue
+		}
+
+		if !*write {
+			fmt.Print(formatted)
+		} else if formatted != string(source) {
+			if err := os.WriteFile(path, []byte(formatted), 0644); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				exitCode = 1
+			}
+		}
+	}
+
+	return exitCode
+}
+
+// formatterOptions reads the formatting options from c3lsp.json in the working directory, if any.
+func formatterOptions() formatter.Options {
+	options := formatter.DefaultOptions()
+
+	data, err := os.ReadFile("c3lsp.json")
+	if err != nil {
+		return options
+	}
+
+	var configuration server.ServerOptsJson
+	if err := json.Unmarshal(data, &configuration); err != nil {
+		fmt.Fprintf(os.Stderr, "c3lsp.json: %v\n", err)
+		return options
+	}
+
+	if configuration.Formatting.IndentWidth != nil {
+		options.IndentWidth = *configuration.Formatting.IndentWidth
+	}
+	if configuration.Formatting.UseTabs != nil {
+		options.UseTabs = *configuration.Formatting.UseTabs
+	}
+	if configuration.Formatting.MaxLineWidth != nil {
+		options.MaxLineWidth = *configuration.Formatting.MaxLineWidth
+	}
+
+	return options
+}
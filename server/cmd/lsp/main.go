@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 	"time"
 
 	"github.com/getsentry/sentry-go"
@@ -14,6 +15,10 @@ const prerelease = false
 const appName = "C3-LSP"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "format" {
+		os.Exit(runFormat(os.Args[2:]))
+	}
+
 	options, showHelp, showVersion := cmdLineArguments()
 	commitHash := buildInfo()
 	if showHelp {
@@ -2,6 +2,7 @@ package c3c
 
 import (
 	"bytes"
+	"context"
 	"log"
 	"os/exec"
 	"regexp"
@@ -45,16 +46,35 @@ func GetC3Version(c3Path option.Option[string]) option.Option[string] {
 	return option.None[string]()
 }
 
-func CheckC3ErrorsCommand(c3Options C3Opts, projectPath string) (bytes.Buffer, bytes.Buffer, error) {
-	binary := binaryPath(c3Options.Path)
-
+// CheckC3ErrorsCommand builds the project in projectPath, reporting errors in the format expected by the LSP.
+// The compiler is killed if ctx is cancelled.
+func CheckC3ErrorsCommand(ctx context.Context, c3Options C3Opts, projectPath string) (bytes.Buffer, bytes.Buffer, error) {
 	args := []string{"build", "--lsp"}
 	if len(c3Options.CompileArgs) > 0 {
 		args = append(args, c3Options.CompileArgs...)
 	}
 
-	command := exec.Command(binary, args...)
-	command.Dir = projectPath
+	return runCommand(ctx, c3Options, projectPath, args)
+}
+
+// CheckSnapshotErrorsCommand compiles the files of snapshot without linking them, so no
+// project.json is needed, reporting errors in the format expected by the LSP.
+// The compiler is killed if ctx is cancelled.
+func CheckSnapshotErrorsCommand(ctx context.Context, c3Options C3Opts, snapshot *Snapshot) (bytes.Buffer, bytes.Buffer, error) {
+	args := []string{"compile-only", "--lsp"}
+	if len(c3Options.CompileArgs) > 0 {
+		args = append(args, c3Options.CompileArgs...)
+	}
+	args = append(args, snapshot.Files()...)
+
+	return runCommand(ctx, c3Options, snapshot.Dir(), args)
+}
+
+func runCommand(ctx context.Context, c3Options C3Opts, dir string, args []string) (bytes.Buffer, bytes.Buffer, error) {
+	binary := binaryPath(c3Options.Path)
+
+	command := exec.CommandContext(ctx, binary, args...)
+	command.Dir = dir
 
 	// set var to get the output
 	var out bytes.Buffer
@@ -7,4 +7,5 @@ type C3Opts struct {
 	Path        option.Option[string] `json:"path"`
 	StdlibPath  option.Option[string] `json:"stdlib-path"`
 	CompileArgs []string              `json:"compile-args"`
+	Target      option.Option[string] `json:"target"` // As in `c3c --target`, used to compute the layout of types
 }
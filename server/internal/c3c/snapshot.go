@@ -0,0 +1,86 @@
+package c3c
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Snapshot is a copy of source files, with their unsaved contents, written to a temporary
+// directory so the compiler can check them. Files keep their layout relative to the project
+// folder, so paths reported by the compiler can be mapped back to the original files.
+type Snapshot struct {
+	dir       string
+	files     []string          // Relative paths of the files inside dir
+	originals map[string]string // Snapshot path -> original path
+}
+
+// NewSnapshot writes contents (original path -> source code) in a new temporary directory.
+// Files outside root are placed apart, as their location relative to the project does not matter.
+func NewSnapshot(root string, contents map[string]string) (*Snapshot, error) {
+	dir, err := os.MkdirTemp("", "c3-lsp-check-")
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{dir: dir, originals: map[string]string{}}
+
+	paths := []string{}
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for i, path := range paths {
+		relative, err := filepath.Rel(root, path)
+		if root == "" || err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			relative = filepath.Join("_external", strconv.Itoa(i)+"_"+filepath.Base(path))
+		}
+
+		snapshotPath := filepath.Join(dir, relative)
+		if err := os.MkdirAll(filepath.Dir(snapshotPath), 0o755); err != nil {
+			snapshot.Remove()
+			return nil, err
+		}
+		if err := os.WriteFile(snapshotPath, []byte(contents[path]), 0o644); err != nil {
+			snapshot.Remove()
+			return nil, err
+		}
+
+		snapshot.files = append(snapshot.files, relative)
+		snapshot.originals[snapshotPath] = path
+	}
+
+	return snapshot, nil
+}
+
+func (s *Snapshot) Dir() string {
+	return s.dir
+}
+
+// Files returns the paths of the files in the snapshot, relative to Dir.
+func (s *Snapshot) Files() []string {
+	return s.files
+}
+
+// OriginalPath maps a path reported by the compiler while checking the snapshot to the file
+// it was copied from. Paths of files not in the snapshot, like stdlib ones, are returned as is.
+func (s *Snapshot) OriginalPath(reported string) string {
+	path := reported
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.dir, path)
+	}
+
+	if original, found := s.originals[filepath.Clean(path)]; found {
+		return original
+	}
+
+	return reported
+}
+
+// Remove deletes the temporary directory of the snapshot.
+func (s *Snapshot) Remove() {
+	os.RemoveAll(s.dir)
+}
@@ -0,0 +1,83 @@
+package c3c
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSnapshot(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "project")
+	main := filepath.Join(root, "src", "main.c3")
+	external := filepath.Join(string(filepath.Separator), "libs", "io.c3")
+
+	snapshot, err := NewSnapshot(root, map[string]string{
+		main:     "module app;",
+		external: "module io;",
+	})
+	assert.NoError(t, err)
+	defer snapshot.Remove()
+
+	t.Run("Files inside root keep their relative path", func(t *testing.T) {
+		assert.Contains(t, snapshot.Files(), filepath.Join("src", "main.c3"))
+
+		content, err := os.ReadFile(filepath.Join(snapshot.Dir(), "src", "main.c3"))
+		assert.NoError(t, err)
+		assert.Equal(t, "module app;", string(content))
+	})
+
+	t.Run("Files outside root are placed apart", func(t *testing.T) {
+		assert.Contains(t, snapshot.Files(), filepath.Join("_external", "0_io.c3"))
+
+		content, err := os.ReadFile(filepath.Join(snapshot.Dir(), "_external", "0_io.c3"))
+		assert.NoError(t, err)
+		assert.Equal(t, "module io;", string(content))
+	})
+}
+
+func TestNewSnapshot_without_root_places_every_file_apart(t *testing.T) {
+	snapshot, err := NewSnapshot("", map[string]string{"/project/main.c3": "module app;"})
+	assert.NoError(t, err)
+	defer snapshot.Remove()
+
+	assert.Equal(t, []string{filepath.Join("_external", "0_main.c3")}, snapshot.Files())
+}
+
+func TestSnapshot_OriginalPath(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "project")
+	main := filepath.Join(root, "src", "main.c3")
+	external := filepath.Join(string(filepath.Separator), "libs", "io.c3")
+
+	snapshot, err := NewSnapshot(root, map[string]string{main: "", external: ""})
+	assert.NoError(t, err)
+	defer snapshot.Remove()
+
+	t.Run("Maps absolute paths of the snapshot", func(t *testing.T) {
+		assert.Equal(t, main, snapshot.OriginalPath(filepath.Join(snapshot.Dir(), "src", "main.c3")))
+		assert.Equal(t, external, snapshot.OriginalPath(filepath.Join(snapshot.Dir(), "_external", "0_io.c3")))
+	})
+
+	t.Run("Maps paths relative to the snapshot", func(t *testing.T) {
+		assert.Equal(t, main, snapshot.OriginalPath(filepath.Join("src", "main.c3")))
+		assert.Equal(t, main, snapshot.OriginalPath(filepath.Join("src", ".", "main.c3")))
+	})
+
+	t.Run("Keeps paths of files not in the snapshot", func(t *testing.T) {
+		stdlib := filepath.Join(string(filepath.Separator), "c3", "lib", "std", "io.c3")
+
+		assert.Equal(t, stdlib, snapshot.OriginalPath(stdlib))
+		assert.Equal(t, "unknown.c3", snapshot.OriginalPath("unknown.c3"))
+	})
+}
+
+func TestSnapshot_Remove(t *testing.T) {
+	snapshot, err := NewSnapshot("/project", map[string]string{"/project/main.c3": "module app;"})
+	assert.NoError(t, err)
+
+	snapshot.Remove()
+
+	_, err = os.Stat(snapshot.Dir())
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,194 @@
+package call_hierarchy
+
+import (
+	"sort"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/reference_index"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Call links a function or macro with another one it calls, or is called by.
+type Call struct {
+	Function *symbols.Function
+	// Identifiers of the calls, in the document of the calling function.
+	Ranges []symbols.Range
+}
+
+// FunctionDeclaredAt returns the function or macro whose name is declared at position of docId.
+func FunctionDeclaredAt(state *project_state.ProjectState, docId string, position symbols.Position) *symbols.Function {
+	unitModules := state.GetUnitModulesByDoc(docId)
+	if unitModules == nil {
+		return nil
+	}
+
+	for _, module := range unitModules.Modules() {
+		for _, function := range module.ChildrenFunctions {
+			if function.GetIdRange().Start == position {
+				return function
+			}
+		}
+	}
+
+	return nil
+}
+
+// OutgoingCalls returns the functions and macros called in the body of function, in order of
+// first call. Method calls are resolved through the type of their receiver.
+func OutgoingCalls(function *symbols.Function, state *project_state.ProjectState, search search.SearchInterface) []Call {
+	docId := function.GetDocumentURI()
+	doc := state.GetDocument(docId)
+	if doc == nil || doc.SyntaxTree() == nil {
+		return []Call{}
+	}
+
+	calls := calls{}
+	walkCalls(declarationNode(doc.SyntaxTree().RootNode(), function), func(identifier *sitter.Node) {
+		position := symbols.NewPositionFromTreeSitterPoint(identifier.StartPoint())
+		resolved := search.FindSymbolDeclarationInWorkspace(docId, position, state)
+		if resolved.IsNone() {
+			return
+		}
+		if callee, ok := resolved.Get().(*symbols.Function); ok {
+			calls.add(callee, symbols.NewRangeFromTreeSitterPositions(identifier.StartPoint(), identifier.EndPoint()))
+		}
+	})
+
+	return calls
+}
+
+// IncomingCalls returns the functions and macros calling function, in order of their documents
+// and positions. Calls made outside of functions, like in the initializers of globals, are left out.
+func IncomingCalls(function *symbols.Function, state *project_state.ProjectState, search search.SearchInterface) []Call {
+	calls := calls{}
+	for _, candidate := range sortedReferences(state.SearchReferences(function.GetMethodName())) {
+		doc := state.GetDocument(candidate.DocId)
+		if doc == nil || doc.SyntaxTree() == nil {
+			continue
+		}
+
+		point := sitter.Point{Row: uint32(candidate.Range.Start.Line), Column: uint32(candidate.Range.Start.Character)}
+		identifier := doc.SyntaxTree().RootNode().NamedDescendantForPointRange(point, point)
+		if identifier == nil || !isCallee(identifier) {
+			continue
+		}
+
+		caller := functionContaining(state, candidate.DocId, candidate.Range.Start)
+		if caller == nil {
+			continue
+		}
+
+		resolved := search.FindSymbolDeclarationInWorkspace(candidate.DocId, candidate.Range.Start, state)
+		if resolved.IsNone() || !isSameFunction(resolved.Get(), function) {
+			continue
+		}
+
+		calls.add(caller, candidate.Range)
+	}
+
+	return calls
+}
+
+type calls []Call
+
+// add registers a call to, or from, function, grouping the calls of each function.
+func (c *calls) add(function *symbols.Function, callRange symbols.Range) {
+	for i, call := range *c {
+		if isSameFunction(call.Function, function) {
+			(*c)[i].Ranges = append(call.Ranges, callRange)
+			return
+		}
+	}
+
+	*c = append(*c, Call{Function: function, Ranges: []symbols.Range{callRange}})
+}
+
+func isSameFunction(a symbols.Indexable, b *symbols.Function) bool {
+	return a.GetName() == b.GetName() &&
+		a.GetDocumentURI() == b.GetDocumentURI() &&
+		a.GetIdRange() == b.GetIdRange()
+}
+
+// declarationNode returns the node of the declaration of function in the tree of its document.
+func declarationNode(root *sitter.Node, function *symbols.Function) *sitter.Node {
+	idRange := function.GetIdRange()
+	start := sitter.Point{Row: uint32(idRange.Start.Line), Column: uint32(idRange.Start.Character)}
+	node := root.NamedDescendantForPointRange(start, start)
+	for node != nil && node.Type() != "func_definition" && node.Type() != "macro_declaration" {
+		node = node.Parent()
+	}
+
+	return node
+}
+
+// walkCalls calls found with the identifier naming the callee of each call found in node.
+func walkCalls(node *sitter.Node, found func(identifier *sitter.Node)) {
+	if node == nil {
+		return
+	}
+
+	if node.Type() == "call_expr" {
+		if callee := node.ChildByFieldName("function"); callee != nil {
+			if identifier := reference_index.LastIdentifier(callee); identifier != nil {
+				found(identifier)
+			}
+		}
+	}
+
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		walkCalls(node.NamedChild(i), found)
+	}
+}
+
+// isCallee tells if identifier names the function called by a call expression, as `bar` in
+// `bar()` or `foo.bar()`, and not one of its arguments.
+func isCallee(identifier *sitter.Node) bool {
+	node := identifier.Parent()
+	for node != nil && node.Type() != "call_expr" {
+		node = node.Parent()
+	}
+	if node == nil {
+		return false
+	}
+
+	callee := node.ChildByFieldName("function")
+	if callee == nil {
+		return false
+	}
+	last := reference_index.LastIdentifier(callee)
+
+	return last != nil && last.StartByte() == identifier.StartByte() && last.EndByte() == identifier.EndByte()
+}
+
+// functionContaining returns the function or macro whose declaration includes position.
+func functionContaining(state *project_state.ProjectState, docId string, position symbols.Position) *symbols.Function {
+	unitModules := state.GetUnitModulesByDoc(docId)
+	if unitModules == nil {
+		return nil
+	}
+
+	for _, module := range unitModules.Modules() {
+		for _, function := range module.ChildrenFunctions {
+			if function.GetDocumentRange().HasPosition(position) {
+				return function
+			}
+		}
+	}
+
+	return nil
+}
+
+func sortedReferences(references []reference_index.Reference) []reference_index.Reference {
+	sorted := append([]reference_index.Reference{}, references...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].DocId != sorted[j].DocId {
+			return sorted[i].DocId < sorted[j].DocId
+		}
+
+		return sorted[j].Range.IsBeforePosition(sorted[i].Range.Start)
+	})
+
+	return sorted
+}
@@ -0,0 +1,83 @@
+package call_hierarchy
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/stretchr/testify/assert"
+	"github.com/tliron/commonlog"
+)
+
+func function(t *testing.T, state *project_state.ProjectState, fqn string) *symbols.Function {
+	found := state.SearchByFQN(fqn)
+	assert.Equal(t, 1, len(found), "Function %s not found", fqn)
+
+	return found[0].(*symbols.Function)
+}
+
+// summary describes calls as the names of the functions with the lines of each call.
+func summary(calls []Call) map[string][]uint {
+	found := map[string][]uint{}
+	for _, call := range calls {
+		lines := []uint{}
+		for _, callRange := range call.Ranges {
+			lines = append(lines, callRange.Start.Line)
+		}
+		found[call.Function.GetName()] = lines
+	}
+
+	return found
+}
+
+const source = `module app;
+struct Canvas { int width; }
+fn void Canvas.clear(&self) {}
+macro @twice(#expr) { #expr; #expr; }
+fn void draw(Canvas* canvas) {
+	canvas.clear();
+	@twice(canvas.clear());
+}
+fn void main() {
+	Canvas canvas;
+	draw(&canvas);
+	draw(&canvas);
+}`
+
+func TestOutgoingCalls(t *testing.T) {
+	state := project_state.NewTestProjectState(map[string]string{"app.c3": source})
+	searcher := search.NewSearch(commonlog.MockLogger{}, false)
+
+	t.Run("Methods are resolved through the type of their receiver", func(t *testing.T) {
+		calls := OutgoingCalls(function(t, state, "app::draw"), state, &searcher)
+
+		assert.Equal(t, map[string][]uint{
+			"Canvas.clear": {5, 6},
+			"@twice":       {6},
+		}, summary(calls))
+	})
+
+	t.Run("Calls to the same function are grouped", func(t *testing.T) {
+		calls := OutgoingCalls(function(t, state, "app::main"), state, &searcher)
+
+		assert.Equal(t, map[string][]uint{"draw": {10, 11}}, summary(calls))
+	})
+}
+
+func TestIncomingCalls(t *testing.T) {
+	state := project_state.NewTestProjectState(map[string]string{"app.c3": source})
+	searcher := search.NewSearch(commonlog.MockLogger{}, false)
+
+	calls := IncomingCalls(function(t, state, "app::Canvas.clear"), state, &searcher)
+
+	assert.Equal(t, map[string][]uint{"draw": {5, 6}}, summary(calls))
+}
+
+func TestFunctionDeclaredAt(t *testing.T) {
+	state := project_state.NewTestProjectState(map[string]string{"app.c3": source})
+
+	found := FunctionDeclaredAt(state, "app.c3", symbols.NewPosition(4, 8))
+
+	assert.Equal(t, "draw", found.GetName())
+}
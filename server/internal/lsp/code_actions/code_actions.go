@@ -0,0 +1,82 @@
+package code_actions
+
+import (
+	"github.com/pherrymason/c3-lsp/internal/lsp/reference_index"
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/symbols_table"
+	sitter "github.com/smacker/go-tree-sitter"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Request describes where the client asks for code actions.
+type Request struct {
+	Doc *document.Document
+	// URI the client uses to refer to Doc. Edits must be sent with it.
+	URI      protocol.DocumentUri
+	Position protocol.Position
+	// Diagnostics reported where code actions are requested. They are linked to the actions fixing them.
+	Diagnostics []protocol.Diagnostic
+}
+
+func quickFix(title string, uri protocol.DocumentUri, diagnostics []protocol.Diagnostic, edits []protocol.TextEdit) protocol.CodeAction {
+	kind := protocol.CodeActionKindQuickFix
+
+	return protocol.CodeAction{
+		Title:       title,
+		Kind:        &kind,
+		Diagnostics: diagnostics,
+		Edit: &protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+				uri: edits,
+			},
+		},
+	}
+}
+
+// identifierAt returns the identifier node found at position, or right before it, so
+// the cursor can be at the end of the identifier.
+func identifierAt(doc *document.Document, position protocol.Position) *sitter.Node {
+	points := []sitter.Point{{Row: position.Line, Column: position.Character}}
+	if position.Character > 0 {
+		points = append(points, sitter.Point{Row: position.Line, Column: position.Character - 1})
+	}
+
+	for _, point := range points {
+		node := doc.SyntaxTree().RootNode().NamedDescendantForPointRange(point, point)
+		if node != nil && reference_index.IsIdentifierNode(node) {
+			return node
+		}
+	}
+
+	return nil
+}
+
+// moduleAt returns the module of the document position belongs to.
+func moduleAt(unitModules *symbols_table.UnitModules, position symbols.Position) *symbols.Module {
+	var found *symbols.Module
+	for _, module := range unitModules.Modules() {
+		if module.GetDocumentRange().HasPosition(position) {
+			found = module
+		}
+	}
+
+	return found
+}
+
+// diagnosticsAt returns the diagnostics with one of codes reported on nodeRange.
+func diagnosticsAt(diagnostics []protocol.Diagnostic, nodeRange protocol.Range, codes ...string) []protocol.Diagnostic {
+	found := []protocol.Diagnostic{}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Range != nodeRange || diagnostic.Code == nil {
+			continue
+		}
+		for _, code := range codes {
+			if diagnostic.Code.Value == code {
+				found = append(found, diagnostic)
+			}
+		}
+	}
+
+	return found
+}
@@ -0,0 +1,209 @@
+package code_actions
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/diagnostics"
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/pherrymason/c3-lsp/pkg/cast"
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	sitter "github.com/smacker/go-tree-sitter"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// ImportFixes offers to import the module declaring the identifier found at position, when it
+// can't be used from doc. For identifiers written with a module path (`io::printn`), only modules
+// matching that path are proposed. Plain identifiers that can't be resolved can also be qualified
+// with their module (`io::printn`): functions and variables of other modules can't be used otherwise.
+func ImportFixes(request Request, state *project_state.ProjectState, search search.SearchInterface) []protocol.CodeAction {
+	doc := request.Doc
+	unitModules := state.GetUnitModulesByDoc(doc.URI)
+	if doc.SyntaxTree() == nil || unitModules == nil {
+		return []protocol.CodeAction{}
+	}
+
+	node := identifierAt(doc, request.Position)
+	if node == nil {
+		return []protocol.CodeAction{}
+	}
+
+	sourceCode := []byte(doc.SourceCode.Text)
+	name := node.Content(sourceCode)
+	nodeRange := symbols.NewRangeFromTreeSitterPositions(node.StartPoint(), node.EndPoint())
+	module := moduleAt(unitModules, nodeRange.Start)
+	if module == nil {
+		return []protocol.CodeAction{}
+	}
+
+	path := modulePathBefore(sourceCode, int(node.StartByte()))
+	if path == "" {
+		resolved := search.FindSymbolDeclarationInWorkspace(doc.URI, nodeRange.Start, state)
+		if resolved.IsSome() {
+			return []protocol.CodeAction{}
+		}
+	}
+
+	candidates := []string{}
+	for _, symbol := range state.SearchByName(name) {
+		candidate := symbol.GetModuleString()
+		if candidate == module.GetName() || (path != "" && !isModulePath(candidate, path)) {
+			continue
+		}
+		if path != "" && isImported(module, candidate) {
+			// The module path already refers to an imported module.
+			return []protocol.CodeAction{}
+		}
+		candidates = append(candidates, candidate)
+	}
+	sort.Strings(candidates)
+
+	lspRange := _prot.Lsp_NewRangeFromRange(nodeRange)
+	fixed := diagnosticsAt(request.Diagnostics, lspRange, diagnostics.CodeUnresolvedIdentifier, diagnostics.CodeUnknownType)
+	isType := node.Type() == "type_ident"
+
+	actions := []protocol.CodeAction{}
+	for i, candidate := range candidates {
+		if i > 0 && candidates[i-1] == candidate {
+			continue
+		}
+
+		imported := isImported(module, candidate)
+		importEdits := []protocol.TextEdit{}
+		if !imported {
+			importEdits = append(importEdits, importEdit(doc, module, candidate))
+		}
+
+		if !imported && (path != "" || isType) {
+			actions = append(actions, quickFix("Add `import "+candidate+";`", request.URI, fixed, importEdits))
+		}
+
+		if path == "" && !(imported && isType) {
+			qualified := lastModuleName(candidate) + "::" + name
+			edits := append([]protocol.TextEdit{{Range: lspRange, NewText: qualified}}, importEdits...)
+			actions = append(actions, quickFix("Qualify as `"+qualified+"`", request.URI, fixed, edits))
+		}
+	}
+
+	if len(actions) == 1 {
+		actions[0].IsPreferred = cast.ToPtr(true)
+	}
+
+	return actions
+}
+
+// importEdit inserts the import of name in the imports section of module, keeping it sorted.
+// When module has no imports yet, it is placed after the module declaration.
+func importEdit(doc *document.Document, module *symbols.Module, name string) protocol.TextEdit {
+	sourceCode := []byte(doc.SourceCode.Text)
+	moduleRange := module.GetDocumentRange()
+	root := doc.SyntaxTree().RootNode()
+
+	var declaration *sitter.Node
+	var lastImport *sitter.Node
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		child := root.NamedChild(i)
+		if !moduleRange.HasPosition(symbols.NewPositionFromTreeSitterPoint(child.StartPoint())) {
+			continue
+		}
+
+		switch child.Type() {
+		case "module_declaration":
+			if declaration == nil {
+				declaration = child
+			}
+		case "import_declaration":
+			if importedName(child, sourceCode) > name {
+				start := protocol.Position{Line: child.StartPoint().Row, Character: 0}
+				return protocol.TextEdit{Range: protocol.Range{Start: start, End: start}, NewText: "import " + name + ";\n"}
+			}
+			lastImport = child
+		}
+	}
+
+	after := lastImport
+	if after == nil {
+		after = declaration
+	}
+	if after == nil {
+		start := protocol.Position{Line: 0, Character: 0}
+		return protocol.TextEdit{Range: protocol.Range{Start: start, End: start}, NewText: "import " + name + ";\n"}
+	}
+
+	end := protocol.Position{Line: after.EndPoint().Row, Character: after.EndPoint().Column}
+	return protocol.TextEdit{Range: protocol.Range{Start: end, End: end}, NewText: "\nimport " + name + ";"}
+}
+
+// importedName returns the first module imported by an import declaration.
+func importedName(declaration *sitter.Node, sourceCode []byte) string {
+	for i := 0; i < int(declaration.NamedChildCount()); i++ {
+		path := declaration.NamedChild(i)
+		if path.Type() != "path_ident" {
+			continue
+		}
+
+		name := ""
+		for j := 0; j < int(path.ChildCount()); j++ {
+			part := path.Child(j)
+			if part.Type() == "ident" || part.Type() == "module_resolution" {
+				name += part.Content(sourceCode)
+			}
+		}
+		return name
+	}
+
+	return ""
+}
+
+// modulePathBefore returns the module path written before the identifier starting at index,
+// like `std::io` in `std::io::printn`.
+func modulePathBefore(sourceCode []byte, index int) string {
+	start := index
+	for start >= 2 && sourceCode[start-1] == ':' && sourceCode[start-2] == ':' {
+		i := start - 2
+		for i > 0 && isIdentifierByte(sourceCode[i-1]) {
+			i--
+		}
+		if i == start-2 {
+			break
+		}
+		start = i
+	}
+
+	if start == index {
+		return ""
+	}
+
+	return string(sourceCode[start : index-2])
+}
+
+func isIdentifierByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+// isModulePath tells if path refers to module: C3 allows to shorten module paths down to their last names.
+func isModulePath(module string, path string) bool {
+	return module == path || strings.HasSuffix(module, "::"+path)
+}
+
+// isImported tells if symbols of name can be used from module. Importing a module gives access to its submodules.
+func isImported(module *symbols.Module, name string) bool {
+	for _, imported := range module.Imports {
+		if name == imported || strings.HasPrefix(name, imported+"::") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lastModuleName(module string) string {
+	if i := strings.LastIndex(module, "::"); i >= 0 {
+		return module[i+2:]
+	}
+
+	return module
+}
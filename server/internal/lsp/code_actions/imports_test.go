@@ -0,0 +1,94 @@
+package code_actions
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const ioSource = `module std::io;
+struct File { int fd; }
+fn void printn(String message) {}`
+
+func importFixes(sources map[string]string, docId string, position protocol.Position) []protocol.CodeAction {
+	state := project_state.NewTestProjectState(sources)
+	searcher := search.NewSearch(commonlog.MockLogger{}, false)
+
+	return ImportFixes(Request{Doc: state.GetDocument(docId), URI: docId, Position: position}, state, &searcher)
+}
+
+func titles(actions []protocol.CodeAction) []string {
+	found := []string{}
+	for _, action := range actions {
+		found = append(found, action.Title)
+	}
+
+	return found
+}
+
+func TestImportFixes_module_path(t *testing.T) {
+	t.Run("Imports the module of the path, keeping imports sorted", func(t *testing.T) {
+		actions := importFixes(map[string]string{
+			"app.c3": `module app;
+import std::math;
+fn void main() {
+	io::printn("hi");
+}`,
+			"io.c3":   ioSource,
+			"math.c3": "module std::math;\nfn int abs(int x) { return x; }",
+		}, "app.c3", protocol.Position{Line: 3, Character: 6})
+
+		assert.Equal(t, []string{"Add `import std::io;`"}, titles(actions))
+		assert.Equal(t, []protocol.TextEdit{
+			{Range: _prot.NewLSPRange(1, 0, 1, 0), NewText: "import std::io;\n"},
+		}, actions[0].Edit.Changes["app.c3"])
+	})
+
+	t.Run("Nothing is offered when the module is already imported", func(t *testing.T) {
+		actions := importFixes(map[string]string{
+			"app.c3": `module app;
+import std::io;
+fn void main() {
+	io::printn("hi");
+}`,
+			"io.c3": ioSource,
+		}, "app.c3", protocol.Position{Line: 3, Character: 6})
+
+		assert.Empty(t, actions)
+	})
+}
+
+func TestImportFixes_unresolved_identifier(t *testing.T) {
+	t.Run("Functions are qualified, importing their module", func(t *testing.T) {
+		actions := importFixes(map[string]string{
+			"app.c3": `module app;
+fn void main() {
+	printn("hi");
+}`,
+			"io.c3": ioSource,
+		}, "app.c3", protocol.Position{Line: 2, Character: 2})
+
+		assert.Equal(t, []string{"Qualify as `io::printn`"}, titles(actions))
+		assert.Equal(t, []protocol.TextEdit{
+			{Range: _prot.NewLSPRange(2, 1, 2, 7), NewText: "io::printn"},
+			{Range: _prot.NewLSPRange(0, 11, 0, 11), NewText: "\nimport std::io;"},
+		}, actions[0].Edit.Changes["app.c3"])
+	})
+
+	t.Run("Types can be imported or qualified", func(t *testing.T) {
+		actions := importFixes(map[string]string{
+			"app.c3": `module app;
+fn void main() {
+	File file;
+}`,
+			"io.c3": ioSource,
+		}, "app.c3", protocol.Position{Line: 2, Character: 1})
+
+		assert.Equal(t, []string{"Add `import std::io;`", "Qualify as `io::File`"}, titles(actions))
+	})
+}
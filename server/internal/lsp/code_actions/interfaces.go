@@ -0,0 +1,71 @@
+package code_actions
+
+import (
+	"strings"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/diagnostics"
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// InterfaceStubs offers to generate the methods missing in the struct declared at position
+// for each of the interfaces it implements. Stubs are added right after the struct.
+func InterfaceStubs(request Request, state *project_state.ProjectState) []protocol.CodeAction {
+	unitModules := state.GetUnitModulesByDoc(request.Doc.URI)
+	if unitModules == nil {
+		return []protocol.CodeAction{}
+	}
+
+	position := symbols.NewPositionFromLSPPosition(request.Position)
+	var strukt *symbols.Struct
+	for _, module := range unitModules.Modules() {
+		for _, candidate := range module.Structs {
+			if candidate.GetDocumentRange().HasPosition(position) {
+				strukt = candidate
+			}
+		}
+	}
+	if strukt == nil {
+		return []protocol.CodeAction{}
+	}
+
+	fixed := diagnosticsAt(request.Diagnostics, _prot.Lsp_NewRangeFromRange(strukt.GetIdRange()), diagnostics.CodeMissingInterfaceMethod)
+	end := strukt.GetDocumentRange().End.ToLSPPosition()
+
+	actions := []protocol.CodeAction{}
+	for _, missing := range diagnostics.MissingInterfaceMethods(strukt, state) {
+		stubs := []string{}
+		for _, method := range missing.Methods {
+			stubs = append(stubs, methodStub(method, strukt.GetName()))
+		}
+
+		actions = append(actions, quickFix("Implement missing methods of `"+missing.Interface.GetName()+"`", request.URI, fixed, []protocol.TextEdit{{
+			Range:   protocol.Range{Start: end, End: end},
+			NewText: "\n\n" + strings.Join(stubs, "\n\n"),
+		}}))
+	}
+
+	return actions
+}
+
+// methodStub writes the declaration of method for structName, with its interface signature
+// and a body that fails until it is implemented:
+//
+//	fn String Foo.name(&self, int arg) @dynamic
+func methodStub(method *symbols.Function, structName string) string {
+	signature := method.DisplaySignature(true)
+	name := method.GetMethodName()
+
+	start := strings.Index(signature, " "+name+"(")
+	arguments := signature[start+len(name)+2 : len(signature)-1]
+	if arguments != "" {
+		arguments = ", " + arguments
+	}
+
+	return signature[:start] + " " + structName + "." + name + "(&self" + arguments + ") @dynamic\n" +
+		"{\n" +
+		"\tunreachable(\"Not implemented\");\n" +
+		"}"
+}
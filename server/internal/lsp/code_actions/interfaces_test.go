@@ -0,0 +1,45 @@
+package code_actions
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestInterfaceStubs(t *testing.T) {
+	source := `module app;
+interface Shape
+{
+	fn float area();
+	fn void scale(float factor, bool round);
+}
+struct Square (Shape)
+{
+	float side;
+}
+fn float Square.area(&self) @dynamic
+{
+	return self.side * self.side;
+}`
+
+	state := project_state.NewTestProjectState(map[string]string{"app.c3": source})
+
+	t.Run("Generates the missing methods after the struct", func(t *testing.T) {
+		actions := InterfaceStubs(Request{Doc: state.GetDocument("app.c3"), URI: "app.c3", Position: protocol.Position{Line: 6, Character: 8}}, state)
+
+		assert.Equal(t, []string{"Implement missing methods of `Shape`"}, titles(actions))
+		assert.Equal(t, []protocol.TextEdit{{
+			Range:   _prot.NewLSPRange(9, 1, 9, 1),
+			NewText: "\n\nfn void Square.scale(&self, float factor, bool round) @dynamic\n{\n\tunreachable(\"Not implemented\");\n}",
+		}}, actions[0].Edit.Changes["app.c3"])
+	})
+
+	t.Run("Nothing is offered outside structs", func(t *testing.T) {
+		actions := InterfaceStubs(Request{Doc: state.GetDocument("app.c3"), URI: "app.c3", Position: protocol.Position{Line: 12, Character: 1}}, state)
+
+		assert.Empty(t, actions)
+	})
+}
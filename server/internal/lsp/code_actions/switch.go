@@ -0,0 +1,240 @@
+package code_actions
+
+import (
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/reference_index"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	sitter "github.com/smacker/go-tree-sitter"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// SwitchCases offers to add a case for each value not covered yet by the switch found at position,
+// when it switches over an enum or a fault. Cases are added before the default one, or at the end.
+func SwitchCases(request Request, state *project_state.ProjectState, search search.SearchInterface) []protocol.CodeAction {
+	doc := request.Doc
+	if doc.SyntaxTree() == nil {
+		return []protocol.CodeAction{}
+	}
+
+	point := sitter.Point{Row: request.Position.Line, Column: request.Position.Character}
+	node := doc.SyntaxTree().RootNode().NamedDescendantForPointRange(point, point)
+	for node != nil && node.Type() != "switch_stmt" {
+		node = node.Parent()
+	}
+	if node == nil {
+		return []protocol.CodeAction{}
+	}
+
+	sourceCode := []byte(doc.SourceCode.Text)
+	var body *sitter.Node
+	var condition *sitter.Node
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		if child.Type() == "switch_body" {
+			body = child
+		} else if condition == nil && child.Type() != "label" {
+			condition = child
+		}
+	}
+	if body == nil || condition == nil {
+		return []protocol.CodeAction{}
+	}
+
+	typeName, values := switchedValues(doc.URI, condition, state, search)
+	if len(values) == 0 {
+		return []protocol.CodeAction{}
+	}
+
+	covered := map[string]bool{}
+	var defaultCase *sitter.Node
+	caseIndentation := ""
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		child := body.NamedChild(i)
+		switch child.Type() {
+		case "case_stmt":
+			caseIndentation = lineIndentation(sourceCode, child)
+			for _, name := range caseValues(child, sourceCode, values) {
+				covered[name] = true
+			}
+		case "default_stmt":
+			caseIndentation = lineIndentation(sourceCode, child)
+			defaultCase = child
+		}
+	}
+
+	missing := []string{}
+	for _, value := range values {
+		if !covered[value] {
+			missing = append(missing, value)
+		}
+	}
+	if len(missing) == 0 {
+		return []protocol.CodeAction{}
+	}
+
+	// Closing brace of the body.
+	anchor := body.Child(int(body.ChildCount()) - 1)
+	if defaultCase != nil {
+		anchor = defaultCase
+	}
+	if caseIndentation == "" {
+		caseIndentation = lineIndentation(sourceCode, node) + "\t"
+	}
+
+	cases := ""
+	for _, value := range missing {
+		cases += caseIndentation + "case " + typeName + "." + value + ":\n" + caseIndentation + "\tbreak;\n"
+	}
+
+	var edit protocol.TextEdit
+	start := anchor.StartPoint()
+	if isFirstInLine(sourceCode, anchor) {
+		position := protocol.Position{Line: start.Row, Character: 0}
+		edit = protocol.TextEdit{Range: protocol.Range{Start: position, End: position}, NewText: cases}
+	} else {
+		position := protocol.Position{Line: start.Row, Character: start.Column}
+		edit = protocol.TextEdit{Range: protocol.Range{Start: position, End: position}, NewText: "\n" + cases + lineIndentation(sourceCode, anchor)}
+	}
+
+	return []protocol.CodeAction{
+		quickFix("Add missing cases of `"+typeName+"`", request.URI, []protocol.Diagnostic{}, []protocol.TextEdit{edit}),
+	}
+}
+
+// switchedValues resolves the type of the switch condition, returning its name and, when it is
+// an enum or a fault, the names of its values in declaration order.
+func switchedValues(docId string, condition *sitter.Node, state *project_state.ProjectState, search search.SearchInterface) (string, []string) {
+	identifier := reference_index.LastIdentifier(condition)
+	if identifier == nil {
+		return "", nil
+	}
+
+	position := symbols.NewPositionFromTreeSitterPoint(identifier.StartPoint())
+	resolved := search.FindSymbolDeclarationInWorkspace(docId, position, state)
+	if resolved.IsNone() {
+		return "", nil
+	}
+
+	var valueType *symbols.Type
+	switch symbol := resolved.Get().(type) {
+	case *symbols.Variable:
+		valueType = symbol.GetType()
+	case *symbols.StructMember:
+		valueType = symbol.GetType()
+	case *symbols.Function:
+		valueType = symbol.GetReturnType()
+	}
+	if valueType == nil {
+		return "", nil
+	}
+
+	candidates := state.SearchByFQN(valueType.GetFullQualifiedName())
+	if len(candidates) == 0 {
+		candidates = state.SearchByName(valueType.GetName())
+	}
+
+	for _, candidate := range candidates {
+		values := []string{}
+		switch typeSymbol := candidate.(type) {
+		case *symbols.Enum:
+			for _, enumerator := range typeSymbol.GetEnumerators() {
+				values = append(values, enumerator.GetName())
+			}
+		case *symbols.Fault:
+			if typeSymbol.GetName() == "" {
+				// Constants declared with faultdef don't belong to a type that can be enumerated.
+				continue
+			}
+			for _, constant := range typeSymbol.GetConstants() {
+				values = append(values, constant.GetName())
+			}
+		default:
+			continue
+		}
+
+		return candidate.GetName(), values
+	}
+
+	return "", nil
+}
+
+// caseValues returns the values a case covers. Ranges (`case RED..BLUE:`) cover every value
+// between both ends, in the order of values.
+func caseValues(caseNode *sitter.Node, sourceCode []byte, values []string) []string {
+	names := []string{}
+	rangeEnd := -1 // Index in names where the identifiers of the end of a range start
+	for i := 0; i < int(caseNode.ChildCount()); i++ {
+		child := caseNode.Child(i)
+		if child.Type() == ":" {
+			break
+		}
+		caseIdentifiers(child, sourceCode, &names, &rangeEnd)
+	}
+
+	if rangeEnd <= 0 || rangeEnd >= len(names) {
+		return names
+	}
+
+	// Ends can be qualified (`Color.RED..Color.BLUE`), the value is their last identifier.
+	first, last := names[rangeEnd-1], names[len(names)-1]
+	inRange := false
+	covered := []string{}
+	for _, value := range values {
+		if value == first {
+			inRange = true
+		}
+		if inRange {
+			covered = append(covered, value)
+		}
+		if value == last {
+			break
+		}
+	}
+
+	return covered
+}
+
+// caseIdentifiers appends the names of the identifiers of node to names, in the order they
+// are written. When a range operator is found, rangeEnd is set to the index of the next name.
+func caseIdentifiers(node *sitter.Node, sourceCode []byte, names *[]string, rangeEnd *int) {
+	if node.Type() == ".." {
+		*rangeEnd = len(*names)
+		return
+	}
+	if reference_index.IsIdentifierNode(node) {
+		*names = append(*names, node.Content(sourceCode))
+		return
+	}
+
+	for i := 0; i < int(node.ChildCount()); i++ {
+		caseIdentifiers(node.Child(i), sourceCode, names, rangeEnd)
+	}
+}
+
+// lineIndentation returns the whitespace the line of node starts with.
+func lineIndentation(sourceCode []byte, node *sitter.Node) string {
+	start := int(node.StartByte())
+	lineStart := start
+	for lineStart > 0 && sourceCode[lineStart-1] != '\n' {
+		lineStart--
+	}
+
+	end := lineStart
+	for end < start && (sourceCode[end] == ' ' || sourceCode[end] == '\t') {
+		end++
+	}
+
+	return string(sourceCode[lineStart:end])
+}
+
+func isFirstInLine(sourceCode []byte, node *sitter.Node) bool {
+	start := int(node.StartByte())
+	for i := start - 1; i >= 0 && sourceCode[i] != '\n'; i-- {
+		if sourceCode[i] != ' ' && sourceCode[i] != '\t' {
+			return false
+		}
+	}
+
+	return true
+}
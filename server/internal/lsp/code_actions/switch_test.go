@@ -0,0 +1,95 @@
+package code_actions
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func switchCases(source string, position protocol.Position) []protocol.CodeAction {
+	state := project_state.NewTestProjectState(map[string]string{"app.c3": source})
+	searcher := search.NewSearch(commonlog.MockLogger{}, false)
+
+	return SwitchCases(Request{Doc: state.GetDocument("app.c3"), URI: "app.c3", Position: position}, state, &searcher)
+}
+
+func TestSwitchCases(t *testing.T) {
+	t.Run("Adds the enumerators not covered before the default case", func(t *testing.T) {
+		actions := switchCases(`module app;
+enum Color { RED, GREEN, BLUE, BLACK }
+fn void paint(Color color) {
+	switch (color)
+	{
+		case Color.GREEN:
+			break;
+		default:
+			break;
+	}
+}`, protocol.Position{Line: 3, Character: 10})
+
+		assert.Equal(t, []string{"Add missing cases of `Color`"}, titles(actions))
+		assert.Equal(t, "\t\tcase Color.RED:\n\t\t\tbreak;\n\t\tcase Color.BLUE:\n\t\t\tbreak;\n\t\tcase Color.BLACK:\n\t\t\tbreak;\n",
+			actions[0].Edit.Changes["app.c3"][0].NewText)
+		assert.Equal(t, protocol.Position{Line: 7, Character: 0}, actions[0].Edit.Changes["app.c3"][0].Range.Start)
+	})
+
+	t.Run("Ranges cover every enumerator between their ends", func(t *testing.T) {
+		actions := switchCases(`module app;
+enum Color { RED, GREEN, BLUE, BLACK }
+fn void paint(Color color) {
+	switch (color)
+	{
+		case RED..BLUE:
+			break;
+	}
+}`, protocol.Position{Line: 3, Character: 10})
+
+		assert.Equal(t, "\t\tcase Color.BLACK:\n\t\t\tbreak;\n", actions[0].Edit.Changes["app.c3"][0].NewText)
+	})
+
+	t.Run("Ranges with qualified ends cover every enumerator between their ends", func(t *testing.T) {
+		actions := switchCases(`module app;
+enum Color { RED, GREEN, BLUE, BLACK }
+fn void paint(Color color) {
+	switch (color)
+	{
+		case Color.RED..Color.BLUE:
+			break;
+	}
+}`, protocol.Position{Line: 3, Character: 10})
+
+		assert.Equal(t, "\t\tcase Color.BLACK:\n\t\t\tbreak;\n", actions[0].Edit.Changes["app.c3"][0].NewText)
+	})
+
+	t.Run("Nothing is offered when every enumerator is covered", func(t *testing.T) {
+		actions := switchCases(`module app;
+enum Color { RED, GREEN }
+fn void paint(Color color) {
+	switch (color)
+	{
+		case Color.RED:
+		case Color.GREEN:
+			break;
+	}
+}`, protocol.Position{Line: 3, Character: 10})
+
+		assert.Empty(t, actions)
+	})
+
+	t.Run("Nothing is offered for other types", func(t *testing.T) {
+		actions := switchCases(`module app;
+fn void count(int value) {
+	switch (value)
+	{
+		case 1:
+			break;
+	}
+}`, protocol.Position{Line: 2, Character: 10})
+
+		assert.Empty(t, actions)
+	})
+}
@@ -39,9 +39,36 @@ func GetParsedTreeFromString(source string) *sitter.Tree {
 	return n
 }
 
+// ReparseTree parses source reusing the parts of oldTree that did not change.
+// oldTree must have been updated with Tree.Edit to match the edits done to source.
+func ReparseTree(oldTree *sitter.Tree, source string) *sitter.Tree {
+	parser := NewSitterParser()
+	n, err := parser.ParseCtx(context.Background(), oldTree, []byte(source))
+	if err != nil {
+		panic(fmt.Errorf("failed parsing tree: %v", err))
+	}
+
+	return n
+}
+
 func RunQuery(query *sitter.Query, node *sitter.Node) *sitter.QueryCursor {
 	qc := sitter.NewQueryCursor()
 	qc.Exec(query, node)
 
 	return qc
 }
+
+// RunQueryInRange runs query over the parts of node overlapping rangeNode.
+// Patterns are still matched against node, so they can refer to its structure.
+func RunQueryInRange(query *sitter.Query, node *sitter.Node, rangeNode *sitter.Node) *sitter.QueryCursor {
+	qc := sitter.NewQueryCursor()
+	qc.SetPointRange(rangeNode.StartPoint(), rangeNode.EndPoint())
+	qc.Exec(query, node)
+
+	return qc
+}
+
+// IsNodeInside reports whether node is placed inside container.
+func IsNodeInside(node *sitter.Node, container *sitter.Node) bool {
+	return node.StartByte() >= container.StartByte() && node.EndByte() <= container.EndByte()
+}
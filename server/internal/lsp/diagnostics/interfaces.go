@@ -0,0 +1,127 @@
+package diagnostics
+
+import (
+	"strings"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// MissingMethods are the methods of an interface a struct declares to implement, but does not.
+type MissingMethods struct {
+	Interface *symbols.Interface
+	Methods   []*symbols.Function // In the order the interface declares them
+}
+
+// MissingInterfaceMethods returns, for each interface in the implements list of strukt, the
+// methods strukt lacks. Only @dynamic methods implement interface methods. Interfaces that
+// can't be found are skipped.
+func MissingInterfaceMethods(strukt *symbols.Struct, state *project_state.ProjectState) []MissingMethods {
+	missing := []MissingMethods{}
+	if len(strukt.GetInterfaces()) == 0 {
+		return missing
+	}
+
+	implemented := dynamicMethodNames(strukt, state)
+	for _, name := range strukt.GetInterfaces() {
+		_interface := findInterface(name, strukt.GetModuleString(), state)
+		if _interface == nil {
+			continue
+		}
+
+		methods := []*symbols.Function{}
+		for _, child := range _interface.Children() {
+			if method, ok := child.(*symbols.Function); ok && !implemented[method.GetMethodName()] {
+				methods = append(methods, method)
+			}
+		}
+
+		if len(methods) > 0 {
+			missing = append(missing, MissingMethods{Interface: _interface, Methods: methods})
+		}
+	}
+
+	return missing
+}
+
+// dynamicMethodNames returns the names of the @dynamic methods of strukt. Methods can be
+// declared in any module, so they are searched in all of them.
+func dynamicMethodNames(strukt *symbols.Struct, state *project_state.ProjectState) map[string]bool {
+	names := map[string]bool{}
+	for _, unitModules := range state.GetAllUnitModules() {
+		for _, module := range unitModules.Modules() {
+			for _, function := range module.ChildrenFunctions {
+				if function.IsDynamic() && isMethodOfStruct(function, module, strukt, state) {
+					names[function.GetMethodName()] = true
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+// isMethodOfStruct tells if function, declared in module, is a method of strukt. A type
+// not qualified with its module path is resolved from module: it names strukt when
+// it is declared there or in a module it imports, unless module declares its own type.
+func isMethodOfStruct(function *symbols.Function, module *symbols.Module, strukt *symbols.Struct, state *project_state.ProjectState) bool {
+	if !function.IsMethodOf(strukt.GetName(), strukt.GetModuleString()) {
+		return false
+	}
+	if strings.Contains(function.GetTypeIdentifier(), "::") {
+		return true
+	}
+
+	structModule := strukt.GetModuleString()
+	if module.GetName() == structModule {
+		return true
+	}
+
+	local := state.ResolveName(strukt.GetName(), module.GetName(), func(symbol symbols.Indexable) bool {
+		return symbol.GetModuleString() == module.GetName()
+	})
+	if local != nil {
+		return false
+	}
+
+	structPath := symbols.NewModulePathFromString(structModule)
+	for _, imported := range module.Imports {
+		if structPath.IsSubModuleOf(symbols.NewModulePathFromString(imported)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findInterface resolves an interface named in an implements list, which can be qualified
+// with its module path (`io::Writer`). Interfaces of the module of the struct are preferred.
+func findInterface(name string, module string, state *project_state.ProjectState) *symbols.Interface {
+	found := state.ResolveName(name, module, func(symbol symbols.Indexable) bool {
+		_, ok := symbol.(*symbols.Interface)
+		return ok
+	})
+	if found == nil {
+		return nil
+	}
+
+	return found.(*symbols.Interface)
+}
+
+// checkInterfaces reports the structs of modules that lack methods of the interfaces they implement.
+func (l *linter) checkInterfaces(modules []*symbols.Module) {
+	for _, module := range modules {
+		for _, strukt := range module.Structs {
+			for _, missing := range MissingInterfaceMethods(strukt, l.state) {
+				names := []string{}
+				for _, method := range missing.Methods {
+					names = append(names, method.GetMethodName())
+				}
+
+				l.reportRange(CodeMissingInterfaceMethod, strukt.GetIdRange(), protocol.DiagnosticSeverityWarning,
+					"'"+strukt.GetName()+"' does not implement "+strings.Join(names, ", ")+" of interface '"+missing.Interface.GetName()+"'")
+			}
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package diagnostics
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/internal/lsp/reference_index"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/pherrymason/c3-lsp/pkg/cast"
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	sitter "github.com/smacker/go-tree-sitter"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Codes of the diagnostics reported by Lint. Each one can be disabled in the configuration.
+const (
+	CodeUnresolvedIdentifier   = "unresolved-identifier"
+	CodeUnknownType            = "unknown-type"
+	CodeUnknownModule          = "unknown-module"
+	CodeUnusedImport           = "unused-import"
+	CodeUnusedVariable         = "unused-variable"
+	CodeUnusedParameter        = "unused-parameter"
+	CodeMissingInterfaceMethod = "missing-interface-method"
+)
+
+// Codes lists every code Lint can report.
+var Codes = []string{
+	CodeUnresolvedIdentifier,
+	CodeUnknownType,
+	CodeUnknownModule,
+	CodeUnusedImport,
+	CodeUnusedVariable,
+	CodeUnusedParameter,
+	CodeMissingInterfaceMethod,
+}
+
+type LintOptions struct {
+	Disabled map[string]bool // Codes that must not be reported
+
+	// Resolve enables the checks that look for symbols in the whole project. They
+	// must be skipped while the project is being indexed, or they would report symbols
+	// declared in files not indexed yet.
+	Resolve bool
+}
+
+type linter struct {
+	doc        *document.Document
+	state      *project_state.ProjectState
+	search     search.SearchInterface
+	options    LintOptions
+	sourceCode []byte
+
+	diagnostics []protocol.Diagnostic
+}
+
+// Lint looks for semantic problems in doc, like identifiers that can't be resolved
+// or imports and variables that are never used. doc must be registered in state.
+func Lint(doc *document.Document, state *project_state.ProjectState, search search.SearchInterface, options LintOptions) []protocol.Diagnostic {
+	unitModules := state.GetUnitModulesByDoc(doc.URI)
+	if doc.SyntaxTree() == nil || unitModules == nil {
+		return []protocol.Diagnostic{}
+	}
+
+	l := linter{
+		doc:         doc,
+		state:       state,
+		search:      search,
+		options:     options,
+		sourceCode:  []byte(doc.SourceCode.Text),
+		diagnostics: []protocol.Diagnostic{},
+	}
+	modules := unitModules.Modules()
+
+	if options.Resolve {
+		usedModules, unresolved := l.checkIdentifiers(declaredRanges(modules))
+		// Unresolved symbols could belong to any import, so none is reported as unused.
+		l.checkImports(usedModules, unresolved == 0 && !doc.SyntaxTree().RootNode().HasError())
+		l.checkInterfaces(modules)
+	}
+	l.checkUnusedVariables(modules)
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i].Range.Start, l.diagnostics[j].Range.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Character < b.Character
+	})
+
+	return l.diagnostics
+}
+
+func (l *linter) report(code string, node *sitter.Node, severity protocol.DiagnosticSeverity, message string) {
+	nodeRange := symbols.NewRangeFromTreeSitterPositions(node.StartPoint(), node.EndPoint())
+	l.reportRange(code, nodeRange, severity, message)
+}
+
+func (l *linter) reportRange(code string, r symbols.Range, severity protocol.DiagnosticSeverity, message string) {
+	if l.options.Disabled[code] {
+		return
+	}
+
+	diagnostic := protocol.Diagnostic{
+		Range:    _prot.Lsp_NewRangeFromRange(r),
+		Severity: cast.ToPtr(severity),
+		Code:     &protocol.IntegerOrString{Value: code},
+		Source:   cast.ToPtr(Source),
+		Message:  message,
+	}
+	if severity == protocol.DiagnosticSeverityHint {
+		diagnostic.Tags = []protocol.DiagnosticTag{protocol.DiagnosticTagUnnecessary}
+	}
+
+	l.diagnostics = append(l.diagnostics, diagnostic)
+}
+
+// Nodes whose identifiers are not references to other symbols, or are already checked elsewhere.
+var skippedNodeTypes = map[string]bool{
+	"module_declaration": true,
+	"import_declaration": true,
+	"doc_comment":        true,
+	"attributes":         true,
+	"generic_param_list": true,
+}
+
+// checkIdentifiers resolves every identifier of the document, reporting the ones that
+// can't be resolved. It returns the modules of the symbols found, and the number of reported identifiers.
+func (l *linter) checkIdentifiers(declared map[symbols.Range]bool) (map[string]bool, int) {
+	usedModules := map[string]bool{}
+	unresolved := 0
+
+	pending := []*sitter.Node{l.doc.SyntaxTree().RootNode()}
+	for len(pending) > 0 {
+		node := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if node.IsError() || skippedNodeTypes[node.Type()] {
+			continue
+		}
+
+		if !reference_index.IsIdentifierNode(node) {
+			for i := int(node.NamedChildCount()) - 1; i >= 0; i-- {
+				pending = append(pending, node.NamedChild(i))
+			}
+			continue
+		}
+
+		nodeRange := symbols.NewRangeFromTreeSitterPositions(node.StartPoint(), node.EndPoint())
+		if declared[nodeRange] {
+			continue
+		}
+
+		resolved := l.search.FindSymbolDeclarationInWorkspace(l.doc.URI, nodeRange.Start, l.state)
+		if resolved.IsSome() {
+			usedModules[resolved.Get().GetModuleString()] = true
+			continue
+		}
+
+		name := node.Content(l.sourceCode)
+		switch {
+		case node.Type() == "type_ident" && l.isStandaloneIdentifier(node) && l.isSurelyUndeclared(node, name):
+			unresolved++
+			l.report(CodeUnknownType, node, protocol.DiagnosticSeverityWarning, "Unknown type '"+name+"'")
+
+		case (node.Type() == "ident" || node.Type() == "at_ident") && l.isStandaloneIdentifier(node) && l.isSurelyUndeclared(node, name):
+			unresolved++
+			l.report(CodeUnresolvedIdentifier, node, protocol.DiagnosticSeverityError, "'"+name+"' is not declared")
+		}
+	}
+
+	return usedModules, unresolved
+}
+
+// isStandaloneIdentifier tells if node is not part of a member access (`foo.bar`), a module
+// path (`io::printn`), a label or a named argument, which can't be checked on their own.
+func (l *linter) isStandaloneIdentifier(node *sitter.Node) bool {
+	return previousNonSpace(l.sourceCode, int(node.StartByte())) != '.' &&
+		nextNonSpace(l.sourceCode, int(node.EndByte())) != ':'
+}
+
+// isSurelyUndeclared filters out names the symbols table does not know about, like variables
+// declared by foreach, catch or lambdas, or generic parameters of modules: only names written
+// once are reported. Calls are no exception, as those variables can hold functions.
+func (l *linter) isSurelyUndeclared(node *sitter.Node, name string) bool {
+	occurrences := 0
+	for _, ref := range l.state.SearchReferences(name) {
+		if ref.DocId == l.doc.URI {
+			occurrences++
+		}
+	}
+
+	return occurrences == 1
+}
+
+func (l *linter) checkImports(usedModules map[string]bool, reportUnused bool) {
+	root := l.doc.SyntaxTree().RootNode()
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		declaration := root.NamedChild(i)
+		if declaration.Type() != "import_declaration" {
+			continue
+		}
+
+		for j := 0; j < int(declaration.NamedChildCount()); j++ {
+			path := declaration.NamedChild(j)
+			if path.Type() != "path_ident" {
+				continue
+			}
+
+			name := importName(path, l.sourceCode)
+			switch {
+			case !l.moduleExists(name):
+				l.report(CodeUnknownModule, path, protocol.DiagnosticSeverityError, "Module '"+name+"' not found")
+			case reportUnused && !isModuleUsed(name, usedModules):
+				l.report(CodeUnusedImport, path, protocol.DiagnosticSeverityHint, "Import '"+name+"' is never used")
+			}
+		}
+	}
+}
+
+func (l *linter) moduleExists(name string) bool {
+	for _, unitModules := range l.state.GetAllUnitModules() {
+		for _, module := range unitModules.Modules() {
+			if isSameOrSubModule(module.GetName(), name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkUnusedVariables reports variables and parameters of functions that are never referenced in their body.
+func (l *linter) checkUnusedVariables(modules []*symbols.Module) {
+	bodies := functionBodies(l.doc.SyntaxTree().RootNode())
+
+	for _, module := range modules {
+		for _, function := range module.ChildrenFunctions {
+			if !hasBody(function, bodies) {
+				continue
+			}
+
+			arguments := map[string]bool{}
+			for _, argumentId := range function.ArgumentIds() {
+				arguments[argumentId] = true
+			}
+
+			names := []string{}
+			for name := range function.Variables {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				variable := function.Variables[name]
+				if variable == nil || name == "" || strings.ContainsAny(name[:1], "$#@") || l.isReferenced(variable, function) {
+					continue
+				}
+
+				if !arguments[name] {
+					l.reportRange(CodeUnusedVariable, variable.GetIdRange(), protocol.DiagnosticSeverityHint, "Variable '"+name+"' is never used")
+				} else if name != "self" {
+					l.reportRange(CodeUnusedParameter, variable.GetIdRange(), protocol.DiagnosticSeverityHint, "Parameter '"+name+"' is never used")
+				}
+			}
+		}
+	}
+}
+
+func (l *linter) isReferenced(variable *symbols.Variable, function *symbols.Function) bool {
+	for _, ref := range l.state.SearchReferences(variable.GetName()) {
+		if ref.DocId == l.doc.URI &&
+			ref.Range != variable.GetIdRange() &&
+			function.GetDocumentRange().HasPosition(ref.Range.Start) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// declaredRanges collects the identifier range of every symbol declared in modules.
+func declaredRanges(modules []*symbols.Module) map[symbols.Range]bool {
+	declared := map[symbols.Range]bool{}
+
+	pending := []symbols.Indexable{}
+	for _, module := range modules {
+		pending = append(pending, module)
+	}
+	for len(pending) > 0 {
+		symbol := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		declared[symbol.GetIdRange()] = true
+		pending = append(pending, symbol.Children()...)
+	}
+
+	return declared
+}
+
+// functionBodies returns the ranges of the functions and macros that have a body.
+func functionBodies(root *sitter.Node) []symbols.Range {
+	bodies := []symbols.Range{}
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		node := root.NamedChild(i)
+		if node.Type() == "func_definition" || node.Type() == "macro_declaration" {
+			bodies = append(bodies, symbols.NewRangeFromTreeSitterPositions(node.StartPoint(), node.EndPoint()))
+		}
+	}
+
+	return bodies
+}
+
+func hasBody(function *symbols.Function, bodies []symbols.Range) bool {
+	for _, body := range bodies {
+		if body.HasPosition(function.GetIdRange().Start) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func importName(path *sitter.Node, sourceCode []byte) string {
+	name := ""
+	for i := 0; i < int(path.ChildCount()); i++ {
+		part := path.Child(i)
+		if part.Type() == "ident" || part.Type() == "module_resolution" {
+			name += part.Content(sourceCode)
+		}
+	}
+
+	return name
+}
+
+// isModuleUsed tells if a symbol of module name, or any of its submodules, is used.
+func isModuleUsed(name string, usedModules map[string]bool) bool {
+	for used := range usedModules {
+		if isSameOrSubModule(used, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSameOrSubModule(module string, parent string) bool {
+	return module == parent || strings.HasPrefix(module, parent+"::")
+}
+
+func previousNonSpace(sourceCode []byte, index int) byte {
+	for i := index - 1; i >= 0; i-- {
+		if !isSpace(sourceCode[i]) {
+			return sourceCode[i]
+		}
+	}
+
+	return 0
+}
+
+func nextNonSpace(sourceCode []byte, index int) byte {
+	for i := index; i < len(sourceCode); i++ {
+		if !isSpace(sourceCode[i]) {
+			return sourceCode[i]
+		}
+	}
+
+	return 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,263 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func lintSources(sources map[string]string, docId string, options LintOptions) []protocol.Diagnostic {
+	state := project_state.NewTestProjectState(sources)
+	searcher := search.NewSearch(commonlog.MockLogger{}, false)
+
+	return Lint(state.GetDocument(docId), state, &searcher, options)
+}
+
+func codes(diagnostics []protocol.Diagnostic) []string {
+	found := []string{}
+	for _, diagnostic := range diagnostics {
+		found = append(found, diagnostic.Code.Value.(string))
+	}
+
+	return found
+}
+
+func TestLint_unresolved_symbols(t *testing.T) {
+	t.Run("Reports calls to undeclared functions", func(t *testing.T) {
+		diagnostics := lintSources(map[string]string{"app.c3": `module app;
+fn void main() {
+	undeclared();
+}`}, "app.c3", LintOptions{Resolve: true})
+
+		assert.Equal(t, []string{CodeUnresolvedIdentifier}, codes(diagnostics))
+		assert.Equal(t, "'undeclared' is not declared", diagnostics[0].Message)
+	})
+
+	t.Run("Reports unknown types", func(t *testing.T) {
+		diagnostics := lintSources(map[string]string{"app.c3": `module app;
+fn void main() {
+	Unknown value;
+	value = value;
+}`}, "app.c3", LintOptions{Resolve: true})
+
+		assert.Equal(t, []string{CodeUnknownType}, codes(diagnostics))
+		assert.Equal(t, protocol.DiagnosticSeverityWarning, *diagnostics[0].Severity)
+	})
+
+	t.Run("Does not report generic parameters of modules", func(t *testing.T) {
+		diagnostics := lintSources(map[string]string{"app.c3": `module app {Type};
+fn Type identity(Type value) {
+	return value;
+}`}, "app.c3", LintOptions{Resolve: true})
+
+		assert.Empty(t, diagnostics)
+	})
+
+	t.Run("Does not report symbols of other documents", func(t *testing.T) {
+		diagnostics := lintSources(map[string]string{
+			"app.c3": `module app;
+import lib;
+fn void main() {
+	Point p = lib::make();
+	p = p;
+}`,
+			"lib.c3": `module lib;
+struct Point { int x; }
+fn Point make() { return {}; }`,
+		}, "app.c3", LintOptions{Resolve: true})
+
+		assert.Empty(t, diagnostics)
+	})
+
+	t.Run("Does not report calls to variables bound by foreach", func(t *testing.T) {
+		diagnostics := lintSources(map[string]string{"app.c3": `module app;
+alias Callback = fn void();
+fn void run_all(Callback[] callbacks) {
+	foreach (cb : callbacks) cb();
+}`}, "app.c3", LintOptions{Resolve: true})
+
+		assert.NotContains(t, codes(diagnostics), CodeUnresolvedIdentifier)
+	})
+
+	t.Run("Does not report calls using variables bound by catch", func(t *testing.T) {
+		diagnostics := lintSources(map[string]string{"app.c3": `module app;
+fn void? run() { return; }
+fn void report(fault excuse) {}
+fn void main() {
+	if (catch excuse = run()) report(excuse);
+}`}, "app.c3", LintOptions{Resolve: true})
+
+		assert.NotContains(t, codes(diagnostics), CodeUnresolvedIdentifier)
+	})
+
+	t.Run("Does not report calls to parameters of lambdas", func(t *testing.T) {
+		diagnostics := lintSources(map[string]string{"app.c3": `module app;
+alias Callback = fn void();
+alias Runner = fn void(Callback);
+fn void main() {
+	Runner runner = fn void(Callback inner) { inner(); };
+	runner(&main);
+}`}, "app.c3", LintOptions{Resolve: true})
+
+		assert.NotContains(t, codes(diagnostics), CodeUnresolvedIdentifier)
+	})
+
+	t.Run("Resolving checks are skipped when disabled", func(t *testing.T) {
+		diagnostics := lintSources(map[string]string{"app.c3": `module app;
+fn void main() {
+	undeclared();
+}`}, "app.c3", LintOptions{Resolve: false})
+
+		assert.Empty(t, diagnostics)
+	})
+}
+
+func TestLint_imports(t *testing.T) {
+	sources := map[string]string{
+		"app.c3": `module app;
+import lib;
+import missing;
+fn void main() {}`,
+		"lib.c3": `module lib;
+fn void run() {}`,
+	}
+
+	diagnostics := lintSources(sources, "app.c3", LintOptions{Resolve: true})
+
+	assert.Equal(t, []string{CodeUnusedImport, CodeUnknownModule}, codes(diagnostics))
+	assert.Equal(t, "Import 'lib' is never used", diagnostics[0].Message)
+	assert.Equal(t, []protocol.DiagnosticTag{protocol.DiagnosticTagUnnecessary}, diagnostics[0].Tags)
+	assert.Equal(t, "Module 'missing' not found", diagnostics[1].Message)
+}
+
+func TestLint_unused_variables(t *testing.T) {
+	source := `module app;
+fn int sum(int a, int b) {
+	int unused = 1;
+	int used = a;
+	return used;
+}`
+
+	t.Run("Reports unused variables and parameters", func(t *testing.T) {
+		diagnostics := lintSources(map[string]string{"app.c3": source}, "app.c3", LintOptions{})
+
+		assert.Equal(t, []string{CodeUnusedParameter, CodeUnusedVariable}, codes(diagnostics))
+		assert.Equal(t, "Parameter 'b' is never used", diagnostics[0].Message)
+		assert.Equal(t, "Variable 'unused' is never used", diagnostics[1].Message)
+	})
+
+	t.Run("Disabled codes are not reported", func(t *testing.T) {
+		diagnostics := lintSources(map[string]string{"app.c3": source}, "app.c3", LintOptions{
+			Disabled: map[string]bool{CodeUnusedParameter: true},
+		})
+
+		assert.Equal(t, []string{CodeUnusedVariable}, codes(diagnostics))
+	})
+}
+
+func TestLint_missing_interface_methods(t *testing.T) {
+	diagnostics := lintSources(map[string]string{"app.c3": `module app;
+interface Shape
+{
+	fn float area();
+	fn float perimeter();
+}
+struct Square (Shape)
+{
+	float side;
+}
+fn float Square.area(&self) @dynamic
+{
+	return self.side * self.side;
+}`}, "app.c3", LintOptions{Resolve: true})
+
+	assert.Equal(t, []string{CodeMissingInterfaceMethod}, codes(diagnostics))
+	assert.Equal(t, "'Square' does not implement perimeter of interface 'Shape'", diagnostics[0].Message)
+}
+
+func TestLint_missing_interface_methods_declared_in_other_modules(t *testing.T) {
+	sources := map[string]string{
+		"shapes.c3": `module shapes;
+interface Shape
+{
+	fn float area();
+}
+struct Square (Shape)
+{
+	float side;
+}`,
+		"extensions.c3": `module extensions;
+import shapes;
+fn float shapes::Square.area(&self) @dynamic
+{
+	return self.side * self.side;
+}`,
+	}
+
+	diagnostics := lintSources(sources, "shapes.c3", LintOptions{Resolve: true})
+
+	assert.Empty(t, codes(diagnostics))
+}
+
+func TestLint_missing_interface_methods_resolves_unqualified_receivers(t *testing.T) {
+	shapes := `module shapes;
+interface Shape
+{
+	fn float area();
+}
+struct Square (Shape)
+{
+	float side;
+}`
+	extension := func(header string) map[string]string {
+		return map[string]string{
+			"shapes.c3": shapes,
+			"extensions.c3": header + `
+fn float Square.area(&self) @dynamic
+{
+	return 0;
+}`,
+		}
+	}
+
+	t.Run("Methods of modules importing the struct implement it", func(t *testing.T) {
+		diagnostics := lintSources(extension("module extensions;\nimport shapes;"), "shapes.c3", LintOptions{Resolve: true})
+
+		assert.Empty(t, codes(diagnostics))
+	})
+
+	t.Run("Methods of modules not importing the struct don't implement it", func(t *testing.T) {
+		diagnostics := lintSources(extension("module extensions;"), "shapes.c3", LintOptions{Resolve: true})
+
+		assert.Equal(t, []string{CodeMissingInterfaceMethod}, codes(diagnostics))
+	})
+
+	t.Run("Methods of types named the same in the importing module don't implement it", func(t *testing.T) {
+		diagnostics := lintSources(extension("module extensions;\nimport shapes;\nstruct Square { int x; }"), "shapes.c3", LintOptions{Resolve: true})
+
+		assert.Equal(t, []string{CodeMissingInterfaceMethod}, codes(diagnostics))
+	})
+}
+
+func TestLint_missing_interface_methods_requires_dynamic_methods(t *testing.T) {
+	diagnostics := lintSources(map[string]string{"app.c3": `module app;
+interface Shape
+{
+	fn float area();
+}
+struct Square (Shape)
+{
+	float side;
+}
+fn float Square.area(&self)
+{
+	return self.side * self.side;
+}`}, "app.c3", LintOptions{Resolve: true})
+
+	assert.Equal(t, []string{CodeMissingInterfaceMethod}, codes(diagnostics))
+	assert.Equal(t, "'Square' does not implement area of interface 'Shape'", diagnostics[0].Message)
+}
@@ -0,0 +1,51 @@
+package diagnostics
+
+import (
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	sitter "github.com/smacker/go-tree-sitter"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// TokenRangeAt returns the range of the token of doc found at position. Compilers
+// usually report just where the offending token starts: this allows to highlight all of it.
+func TokenRangeAt(doc *document.Document, position protocol.Position) option.Option[protocol.Range] {
+	if doc.SyntaxTree() == nil {
+		return option.None[protocol.Range]()
+	}
+
+	point := sitter.Point{Row: position.Line, Column: position.Character}
+	node := doc.SyntaxTree().RootNode().NamedDescendantForPointRange(point, point)
+	// Keywords and punctuation are anonymous nodes: look for them among the children.
+	for node != nil && node.ChildCount() > 0 {
+		node = childAtPoint(node, point)
+	}
+	if node == nil {
+		// Not a token, but whitespace between them.
+		return option.None[protocol.Range]()
+	}
+
+	start, end := node.StartPoint(), node.EndPoint()
+	if start.Row != end.Row || start.Column == end.Column || start.Row != position.Line {
+		return option.None[protocol.Range]()
+	}
+
+	return option.Some(_prot.Lsp_NewRangeFromRange(symbols.NewRangeFromTreeSitterPositions(start, end)))
+}
+
+func childAtPoint(node *sitter.Node, point sitter.Point) *sitter.Node {
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		if !isPointBefore(point, child.StartPoint()) && isPointBefore(point, child.EndPoint()) {
+			return child
+		}
+	}
+
+	return nil
+}
+
+func isPointBefore(a sitter.Point, b sitter.Point) bool {
+	return a.Row < b.Row || (a.Row == b.Row && a.Column < b.Column)
+}
@@ -0,0 +1,42 @@
+package diagnostics
+
+import (
+	"testing"
+
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestTokenRangeAt(t *testing.T) {
+	doc := document.NewDocument("app.c3", `module app;
+fn void main() {
+	int value = missing;
+}`)
+
+	cases := []struct {
+		name     string
+		position protocol.Position
+		expected protocol.Range
+	}{
+		{"identifier", protocol.Position{Line: 2, Character: 13}, _prot.NewLSPRange(2, 13, 2, 20)},
+		{"keyword", protocol.Position{Line: 1, Character: 0}, _prot.NewLSPRange(1, 0, 1, 2)},
+		{"punctuation", protocol.Position{Line: 2, Character: 20}, _prot.NewLSPRange(2, 20, 2, 21)},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenRange := TokenRangeAt(&doc, tt.position)
+
+			assert.True(t, tokenRange.IsSome())
+			assert.Equal(t, tt.expected, tokenRange.Get())
+		})
+	}
+
+	t.Run("whitespace", func(t *testing.T) {
+		tokenRange := TokenRangeAt(&doc, protocol.Position{Line: 2, Character: 0})
+
+		assert.True(t, tokenRange.IsNone())
+	})
+}
@@ -0,0 +1,87 @@
+package diagnostics
+
+import (
+	"strings"
+
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/pkg/cast"
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	sitter "github.com/smacker/go-tree-sitter"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Source of the diagnostics computed by the server itself, without invoking c3c.
+const Source = "c3-lsp"
+
+// Maximum length of the source code quoted in a syntax error message.
+const maxQuotedLength = 30
+
+// SyntaxErrors reports the ERROR and MISSING nodes tree-sitter inserted
+// while parsing doc, that is, the places where the source is not valid C3.
+func SyntaxErrors(doc *document.Document) []protocol.Diagnostic {
+	diagnostics := []protocol.Diagnostic{}
+	if doc.SyntaxTree() == nil {
+		return diagnostics
+	}
+
+	sourceCode := []byte(doc.SourceCode.Text)
+	pending := []*sitter.Node{doc.SyntaxTree().RootNode()}
+	for len(pending) > 0 {
+		node := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		switch {
+		case node.IsMissing():
+			diagnostics = append(diagnostics, syntaxError(node, "missing '"+node.Type()+"'"))
+			continue
+
+		case node.IsError():
+			// Nested errors would point to the same problem: report only the outermost one.
+			diagnostics = append(diagnostics, syntaxError(node, unexpectedMessage(node.Content(sourceCode))))
+			continue
+
+		case !node.HasError():
+			continue
+		}
+
+		for i := int(node.ChildCount()) - 1; i >= 0; i-- {
+			pending = append(pending, node.Child(i))
+		}
+	}
+
+	return diagnostics
+}
+
+func syntaxError(node *sitter.Node, message string) protocol.Diagnostic {
+	nodeRange := symbols.NewRangeFromTreeSitterPositions(node.StartPoint(), node.EndPoint())
+
+	return protocol.Diagnostic{
+		Range:    _prot.Lsp_NewRangeFromRange(nodeRange),
+		Severity: cast.ToPtr(protocol.DiagnosticSeverityError),
+		Source:   cast.ToPtr(Source),
+		Message:  "Syntax error: " + message,
+	}
+}
+
+func unexpectedMessage(content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "unexpected input"
+	}
+
+	truncated := false
+	if i := strings.IndexByte(content, '\n'); i >= 0 {
+		content = strings.TrimSpace(content[:i])
+		truncated = true
+	}
+	if len(content) > maxQuotedLength {
+		content = content[:maxQuotedLength]
+		truncated = true
+	}
+	if truncated {
+		content += "..."
+	}
+
+	return "unexpected '" + content + "'"
+}
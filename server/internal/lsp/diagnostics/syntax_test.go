@@ -0,0 +1,53 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyntaxErrors(t *testing.T) {
+	t.Run("Valid source has no errors", func(t *testing.T) {
+		doc := document.NewDocument("app.c3", `module app;
+fn void main() {
+	int a = 1;
+}`)
+
+		assert.Empty(t, SyntaxErrors(&doc))
+	})
+
+	t.Run("Reports missing tokens", func(t *testing.T) {
+		doc := document.NewDocument("app.c3", `module app;
+fn void main() {
+	int a = 1
+}`)
+
+		diagnostics := SyntaxErrors(&doc)
+
+		assert.Len(t, diagnostics, 1)
+		assert.Equal(t, "Syntax error: missing ';'", diagnostics[0].Message)
+		assert.Equal(t, uint32(2), diagnostics[0].Range.Start.Line)
+		assert.Equal(t, Source, *diagnostics[0].Source)
+	})
+
+	t.Run("Reports unexpected input", func(t *testing.T) {
+		doc := document.NewDocument("app.c3", `module app;
+fn void main() {
+	int a = 1 +* ;
+}`)
+
+		diagnostics := SyntaxErrors(&doc)
+
+		assert.NotEmpty(t, diagnostics)
+		assert.Contains(t, diagnostics[0].Message, "Syntax error:")
+		assert.Equal(t, uint32(2), diagnostics[0].Range.Start.Line)
+	})
+}
+
+func TestUnexpectedMessage(t *testing.T) {
+	assert.Equal(t, "unexpected input", unexpectedMessage("  "))
+	assert.Equal(t, "unexpected '+*'", unexpectedMessage("+*"))
+	assert.Equal(t, "unexpected 'foo(...'", unexpectedMessage("foo(\n  bar)"))
+	assert.Equal(t, "unexpected 'aaaaaaaaaaaaaaaaaaaaaaaaaaaaaa...'", unexpectedMessage("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"))
+}
@@ -0,0 +1,263 @@
+package formatter
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/cst"
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	sitter "github.com/smacker/go-tree-sitter"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Options configures how code is formatted.
+type Options struct {
+	IndentWidth  int  // Columns of an indentation level. When indenting with tabs, it is used to measure lines.
+	UseTabs      bool // Indent with tabs instead of spaces
+	MaxLineWidth int  // Longer lines are wrapped after the commas of their lists. 0 disables wrapping.
+}
+
+func DefaultOptions() Options {
+	return Options{
+		IndentWidth:  4,
+		UseTabs:      true,
+		MaxLineWidth: 120,
+	}
+}
+
+// Normalized returns o with the values that can't be used replaced by the default ones.
+func (o Options) Normalized() Options {
+	if o.IndentWidth <= 0 {
+		o.IndentWidth = DefaultOptions().IndentWidth
+	}
+
+	return o
+}
+
+var ErrSyntax = errors.New("code with syntax errors can't be formatted")
+
+// ErrUnsafe is returned when the formatted code would not parse into the same tokens as the
+// original one. It means there's a bug in the formatter: the code is left as it is.
+var ErrUnsafe = errors.New("formatting would change the meaning of the code")
+
+// Format returns source formatted. Top level declarations are formatted one by one, separated
+// by a blank line when any of them spans several lines. Consecutive imports are sorted.
+func Format(source string, options Options) (string, error) {
+	sourceCode := []byte(source)
+	root := cst.GetParsedTreeFromString(source).RootNode()
+	if root.HasError() {
+		return "", ErrSyntax
+	}
+
+	items := []item{}
+	previousEnd := uint32(0)
+	for i := 0; i < int(root.ChildCount()); i++ {
+		node := root.Child(i)
+		if node.StartByte() == node.EndByte() {
+			continue
+		}
+
+		gap := string(sourceCode[previousEnd:node.StartByte()])
+		if strings.TrimSpace(gap) != "" {
+			return "", ErrUnsafe
+		}
+
+		item, err := newItem(node, sourceCode, options)
+		if err != nil {
+			return "", err
+		}
+		item.newlines = strings.Count(gap, "\n")
+		item.trailing = len(items) > 0 && item.isComment() && item.newlines == 0
+		items = append(items, item)
+		previousEnd = node.EndByte()
+	}
+	sortImports(items)
+
+	var builder strings.Builder
+	expected := []string{}
+	for i, item := range items {
+		if i > 0 {
+			builder.WriteString(separator(items[i-1], item))
+		}
+		builder.WriteString(item.text)
+		expected = append(expected, item.tokens...)
+	}
+	if len(items) > 0 {
+		builder.WriteString("\n")
+	}
+
+	formatted := builder.String()
+	if err := verify(formatted, expected); err != nil {
+		return "", err
+	}
+
+	return formatted, nil
+}
+
+// FormatRange returns the edits formatting the top level declarations of source found
+// between lines startLine and endLine. Declarations with syntax errors are left as they are.
+func FormatRange(source string, startLine uint32, endLine uint32, options Options) []protocol.TextEdit {
+	sourceCode := []byte(source)
+	root := cst.GetParsedTreeFromString(source).RootNode()
+
+	edits := []protocol.TextEdit{}
+	for i := 0; i < int(root.ChildCount()); i++ {
+		node := root.Child(i)
+		if node.EndPoint().Row < startLine || node.StartPoint().Row > endLine || node.HasError() || node.StartByte() == node.EndByte() {
+			continue
+		}
+
+		item, err := newItem(node, sourceCode, options)
+		if err != nil || item.text == node.Content(sourceCode) || verify(item.text, item.tokens) != nil {
+			continue
+		}
+
+		start, end := node.StartPoint(), node.EndPoint()
+		edits = append(edits, protocol.TextEdit{
+			Range:   _prot.NewLSPRange(start.Row, start.Column, end.Row, end.Column),
+			NewText: item.text,
+		})
+	}
+
+	return edits
+}
+
+// Edits returns the edits turning source into formatted: the lines that differ are replaced at once.
+func Edits(source string, formatted string) []protocol.TextEdit {
+	if source == formatted {
+		return []protocol.TextEdit{}
+	}
+
+	sourceLines := strings.SplitAfter(source, "\n")
+	formattedLines := strings.SplitAfter(formatted, "\n")
+
+	prefix := 0
+	for prefix < len(sourceLines) && prefix < len(formattedLines) && sourceLines[prefix] == formattedLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(sourceLines)-prefix && suffix < len(formattedLines)-prefix &&
+		sourceLines[len(sourceLines)-1-suffix] == formattedLines[len(formattedLines)-1-suffix] {
+		suffix++
+	}
+
+	end := protocol.Position{Line: uint32(len(sourceLines) - suffix)}
+	if suffix == 0 {
+		// The last line has no line break: the edit ends where the document does.
+		// Positions count UTF-16 code units.
+		lastLine := sourceLines[len(sourceLines)-1]
+		end = protocol.Position{Line: uint32(len(sourceLines) - 1), Character: uint32(len(utf16.Encode([]rune(lastLine))))}
+	}
+
+	return []protocol.TextEdit{{
+		Range:   protocol.Range{Start: protocol.Position{Line: uint32(prefix)}, End: end},
+		NewText: strings.Join(formattedLines[prefix:len(formattedLines)-suffix], ""),
+	}}
+}
+
+// item is a top level declaration or comment, formatted on its own.
+type item struct {
+	node     *sitter.Node
+	text     string   // Formatted text
+	tokens   []string // Text of the tokens written in text
+	newlines int      // Line breaks before the item in the source
+	trailing bool     // Comment written at the end of the line of the previous item
+}
+
+func newItem(node *sitter.Node, sourceCode []byte, options Options) (item, error) {
+	tokens, err := collectTokens(node, sourceCode)
+	if err != nil {
+		return item{}, err
+	}
+
+	texts := []string{}
+	for _, token := range tokens {
+		texts = append(texts, token.text)
+	}
+
+	return item{node: node, text: formatTokens(tokens, options), tokens: texts}, nil
+}
+
+func (i item) isComment() bool {
+	return strings.Contains(i.node.Type(), "comment")
+}
+
+func (i item) isImport() bool {
+	return i.node.Type() == "import_declaration"
+}
+
+func (i item) isMultiline() bool {
+	return strings.Contains(i.text, "\n")
+}
+
+// separator returns the whitespace written between two top level items.
+func separator(previous item, next item) string {
+	if next.trailing {
+		return " "
+	}
+
+	blank := next.newlines > 1
+	switch {
+	case previous.isComment() && !previous.trailing && next.newlines == 1:
+		// Comments stay attached to the declaration below them.
+		blank = false
+	case previous.isImport() && next.isImport():
+		// Blank lines separate groups of imports.
+	case previous.node.Type() == "module_declaration", previous.isMultiline(), next.isMultiline():
+		blank = true
+	}
+
+	if blank {
+		return "\n\n"
+	}
+
+	return "\n"
+}
+
+// sortImports sorts each group of consecutive imports: the ones not separated by blank lines or comments.
+func sortImports(items []item) {
+	for start := 0; start < len(items); start++ {
+		if !items[start].isImport() {
+			continue
+		}
+
+		end := start + 1
+		for end < len(items) && items[end].isImport() && items[end].newlines == 1 {
+			end++
+		}
+
+		newlines := items[start].newlines
+		sort.SliceStable(items[start:end], func(a, b int) bool {
+			return items[start+a].text < items[start+b].text
+		})
+		for i := start; i < end; i++ {
+			items[i].newlines = 1
+		}
+		items[start].newlines = newlines
+
+		start = end - 1
+	}
+}
+
+// verify checks that formatted parses without errors into the expected tokens, so formatting
+// only changes whitespace.
+func verify(formatted string, expected []string) error {
+	root := cst.GetParsedTreeFromString(formatted).RootNode()
+	if root.HasError() {
+		return ErrUnsafe
+	}
+
+	tokens, err := collectTokens(root, []byte(formatted))
+	if err != nil || len(tokens) != len(expected) {
+		return ErrUnsafe
+	}
+	for i, token := range tokens {
+		if token.text != expected[i] {
+			return ErrUnsafe
+		}
+	}
+
+	return nil
+}
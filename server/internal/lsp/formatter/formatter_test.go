@@ -0,0 +1,116 @@
+package formatter
+
+import (
+	"testing"
+
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			"Indents blocks and places braces on their own lines",
+			`module app;
+fn void main() {
+if (x>1) {
+foo(a,b);
+}
+}`,
+			"module app;\n\nfn void main()\n{\n\tif (x > 1)\n\t{\n\t\tfoo(a, b);\n\t}\n}\n",
+		},
+		{
+			"Sorts imports",
+			"module app;\nimport std::io;\nimport std::collections;\n\nfn void main() {}",
+			"module app;\n\nimport std::collections;\nimport std::io;\n\nfn void main()\n{\n}\n",
+		},
+		{
+			"Keeps comments",
+			"// The entry point\nfn void main() { foo(); // call\n}",
+			"// The entry point\nfn void main()\n{\n\tfoo(); // call\n}\n",
+		},
+		{
+			"Indents the statements of cases",
+			"fn void f(int x) {\nswitch (x) {\ncase 1:\nfoo();\ndefault:\nbreak;\n}\n}",
+			"fn void f(int x)\n{\n\tswitch (x)\n\t{\n\t\tcase 1:\n\t\t\tfoo();\n\t\tdefault:\n\t\t\tbreak;\n\t}\n}\n",
+		},
+		{
+			"Attaches unary operators to their operand",
+			"fn void f() {\nint y = -x*  *p;\n}",
+			"fn void f()\n{\n\tint y = -x * *p;\n}\n",
+		},
+		{
+			"Keeps at most one blank line",
+			"int a;\n\n\n\nint b;",
+			"int a;\n\nint b;\n",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted, err := Format(tt.source, DefaultOptions())
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, formatted)
+		})
+	}
+}
+
+func TestFormat_options(t *testing.T) {
+	t.Run("Indents with spaces", func(t *testing.T) {
+		formatted, err := Format("fn void main() { foo(); }", Options{IndentWidth: 2, MaxLineWidth: 120})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "fn void main()\n{\n  foo();\n}\n", formatted)
+	})
+
+	t.Run("Wraps long lines after the commas of lists", func(t *testing.T) {
+		formatted, err := Format("fn void main() { call(first_argument, second_argument, third); }", Options{IndentWidth: 4, UseTabs: true, MaxLineWidth: 30})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "fn void main()\n{\n\tcall(first_argument,\n\t\tsecond_argument,\n\t\tthird);\n}\n", formatted)
+	})
+}
+
+func TestFormat_does_not_format_code_with_syntax_errors(t *testing.T) {
+	_, err := Format("fn void main( {", DefaultOptions())
+
+	assert.ErrorIs(t, err, ErrSyntax)
+}
+
+func TestFormatRange(t *testing.T) {
+	source := "fn void a() { foo(); }\nfn void b() { bar(); }\n"
+
+	edits := FormatRange(source, 1, 1, DefaultOptions())
+
+	assert.Equal(t, []protocol.TextEdit{
+		{Range: _prot.NewLSPRange(1, 0, 1, 22), NewText: "fn void b()\n{\n\tbar();\n}"},
+	}, edits)
+}
+
+func TestEdits(t *testing.T) {
+	edits := Edits("int a;\nint  b;\nint c;\n", "int a;\nint b;\nint c;\n")
+
+	assert.Equal(t, []protocol.TextEdit{
+		{Range: _prot.NewLSPRange(1, 0, 2, 0), NewText: "int b;\n"},
+	}, edits)
+}
+
+func TestEdits_end_of_document_in_utf16_units(t *testing.T) {
+	edits := Edits("int a;\nString s = \"ñ😀\";", "int a;\nString s = \"ñ😀\";\n")
+
+	assert.Equal(t, []protocol.TextEdit{
+		{Range: _prot.NewLSPRange(1, 0, 1, 17), NewText: "String s = \"ñ😀\";\n"},
+	}, edits)
+}
+
+func TestOptions_Normalized(t *testing.T) {
+	assert.Equal(t, DefaultOptions().IndentWidth, Options{IndentWidth: 0}.Normalized().IndentWidth)
+	assert.Equal(t, DefaultOptions().IndentWidth, Options{IndentWidth: -2}.Normalized().IndentWidth)
+	assert.Equal(t, 2, Options{IndentWidth: 2}.Normalized().IndentWidth)
+}
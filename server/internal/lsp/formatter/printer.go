@@ -0,0 +1,520 @@
+package formatter
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+type token struct {
+	node     *sitter.Node
+	text     string
+	newlines int  // Line breaks between the previous token and this one in the source
+	spaced   bool // Whether there is whitespace between the previous token and this one in the source
+}
+
+// collectTokens returns the tokens of node in source order. Comments and literals are single
+// tokens, written as they are.
+func collectTokens(node *sitter.Node, sourceCode []byte) ([]token, error) {
+	tokens := []token{}
+	previousEnd := node.StartByte()
+
+	var walk func(n *sitter.Node) error
+	walk = func(n *sitter.Node) error {
+		if n.StartByte() == n.EndByte() {
+			return nil
+		}
+		if !isAtomic(n) {
+			for i := 0; i < int(n.ChildCount()); i++ {
+				if err := walk(n.Child(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if n.StartByte() < previousEnd {
+			return ErrUnsafe
+		}
+		gap := string(sourceCode[previousEnd:n.StartByte()])
+		if strings.TrimSpace(gap) != "" {
+			// Some text does not belong to any token: it would be lost.
+			return ErrUnsafe
+		}
+
+		tokens = append(tokens, token{
+			node:     n,
+			text:     n.Content(sourceCode),
+			newlines: strings.Count(gap, "\n"),
+			spaced:   gap != "",
+		})
+		previousEnd = n.EndByte()
+
+		return nil
+	}
+
+	err := walk(node)
+
+	return tokens, err
+}
+
+func isAtomic(node *sitter.Node) bool {
+	nodeType := node.Type()
+
+	return node.ChildCount() == 0 ||
+		strings.Contains(nodeType, "comment") ||
+		strings.Contains(nodeType, "string") ||
+		nodeType == "char_literal"
+}
+
+type kind int
+
+const (
+	kindOther   kind = iota
+	kindBinary       // Spaced on both sides
+	kindPrefix       // Attached to what follows
+	kindPostfix      // Attached to what precedes
+)
+
+// line of the formatted code. Lines without parts are blank.
+type line struct {
+	indent int
+	parts  []part
+}
+
+type part struct {
+	text  string
+	space bool // Separated from the previous part of the line by a space
+}
+
+// frame is a bracket or compile time block still open while writing tokens. Lines inside
+// are indented one level more than the line that opened it.
+type frame struct {
+	indent    int
+	closer    string
+	block     bool // Block braces are written on lines of their own
+	caseLabel bool // A case label is being written, until its colon
+	inCase    bool // The statements of a case are being written: they are indented one more level
+	ternaries int  // Ternary operators waiting for their colon
+}
+
+type printer struct {
+	options Options
+	tokens  []token
+	kinds   []kind
+	lines   []*line
+	frames  []*frame
+}
+
+var openers = map[string]string{
+	"(":        ")",
+	"[":        "]",
+	"{":        "}",
+	"$if":      "$endif",
+	"$for":     "$endfor",
+	"$foreach": "$endforeach",
+	"$switch":  "$endswitch",
+}
+
+var closers = map[string]bool{")": true, "]": true, "}": true, "$endif": true, "$endfor": true, "$endforeach": true, "$endswitch": true}
+
+// Node types whose braces enclose a block.
+var blockTypes = map[string]bool{
+	"compound_stmt":  true,
+	"switch_body":    true,
+	"struct_body":    true,
+	"bitstruct_body": true,
+	"interface_body": true,
+	"enum_body":      true,
+	"fault_body":     true,
+}
+
+var binaryOperators = map[string]bool{
+	"=": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true, "&=": true, "|=": true, "^=": true, "<<=": true, ">>=": true,
+	"==": true, "!=": true, "<": true, ">": true, "<=": true, ">=": true,
+	"||": true, "|": true, "^": true, "/": true, "%": true, "<<": true, ">>": true,
+	"=>": true, "?:": true, "??": true, "+++": true, "&&&": true, "|||": true,
+}
+
+// Operators that are unary or binary depending on what precedes them.
+var ambiguousOperators = map[string]bool{
+	"-": true, "+": true, "*": true, "&": true, "&&": true, "!": true, "!!": true, "~": true, "++": true, "--": true, "?": true,
+}
+
+var controlKeywords = map[string]bool{
+	"if": true, "while": true, "for": true, "foreach": true, "foreach_r": true, "switch": true, "catch": true,
+	"$if": true, "$for": true, "$foreach": true, "$switch": true,
+}
+
+var valueKeywords = map[string]bool{"true": true, "false": true, "null": true}
+
+// Tokens written without spaces around them.
+var attachedTokens = map[string]bool{".": true, "::": true, "..": true}
+
+// formatTokens writes tokens one after the other:
+//   - Indentation follows the brackets and compile time blocks the tokens are in.
+//   - Block braces go on lines of their own. Statements, cases and declarations too.
+//   - Other line breaks are kept, with at most one blank line.
+//   - Operators get spaces around them, or are attached to their operand when unary.
+//   - Elsewhere, tokens are spaced as they were.
+func formatTokens(tokens []token, options Options) string {
+	p := printer{
+		options: options,
+		tokens:  tokens,
+		kinds:   make([]kind, len(tokens)),
+		// Top level frame, so declarations are not indented.
+		frames: []*frame{{indent: -1}},
+	}
+	for i := range tokens {
+		p.write(i)
+	}
+
+	lines := []*line{}
+	for _, l := range p.lines {
+		lines = append(lines, p.wrap(l)...)
+	}
+
+	rendered := []string{}
+	for _, l := range lines {
+		rendered = append(rendered, p.render(l))
+	}
+
+	return strings.Join(rendered, "\n")
+}
+
+func (p *printer) write(i int) {
+	t := &p.tokens[i]
+
+	var closed *frame
+	if closers[t.text] {
+		closed = p.pop(t.text)
+	}
+	top := p.top()
+	if isCaseKeyword(t.text) {
+		top.inCase = false
+		top.caseLabel = true
+	}
+	p.kinds[i] = p.classify(i)
+
+	if len(p.lines) == 0 {
+		p.lines = append(p.lines, &line{indent: 0})
+	} else if p.breaksBefore(i, closed) {
+		previous := &p.tokens[i-1]
+		if t.newlines > 1 && !isBlockBrace(previous, "{") && (closed == nil || !closed.block) {
+			p.lines = append(p.lines, &line{})
+		}
+		p.lines = append(p.lines, &line{indent: p.indentFor(i, closed)})
+	}
+
+	current := p.lines[len(p.lines)-1]
+	current.parts = append(current.parts, part{
+		text:  t.text,
+		space: len(current.parts) > 0 && p.spaced(i),
+	})
+
+	if closer, ok := openers[t.text]; ok {
+		p.frames = append(p.frames, &frame{indent: current.indent, closer: closer, block: isBlockBrace(t, "{")})
+	}
+}
+
+func (p *printer) top() *frame {
+	return p.frames[len(p.frames)-1]
+}
+
+// pop closes the innermost frame closed by closer.
+func (p *printer) pop(closer string) *frame {
+	for i := len(p.frames) - 1; i > 0; i-- {
+		if p.frames[i].closer == closer {
+			closed := p.frames[i]
+			p.frames = p.frames[:i]
+			return closed
+		}
+	}
+
+	return nil
+}
+
+// classify tells how token i is spaced, based on the tokens around it.
+func (p *printer) classify(i int) kind {
+	t := &p.tokens[i]
+	top := p.top()
+
+	if t.text == ":" {
+		if top.ternaries > 0 {
+			top.ternaries--
+			return kindBinary
+		}
+		if top.caseLabel {
+			top.caseLabel = false
+			top.inCase = true
+			return kindPostfix
+		}
+		return kindOther
+	}
+
+	if (t.text == "*" || t.text == "?") && isTypeSuffix(t.node) {
+		return kindPostfix
+	}
+
+	var previous, next *token
+	if i > 0 {
+		previous = &p.tokens[i-1]
+	}
+	if i+1 < len(p.tokens) {
+		next = &p.tokens[i+1]
+	}
+
+	if binaryOperators[t.text] {
+		// Generic arguments: Foo(<int>)
+		if (t.text == "<" && previous != nil && previous.text == "(") || (t.text == ">" && next != nil && next.text == ")") {
+			return kindOther
+		}
+		return kindBinary
+	}
+
+	if !ambiguousOperators[t.text] {
+		return kindOther
+	}
+
+	if previous == nil || !p.endsOperand(i-1) {
+		if t.text == "?" || t.text == "!!" {
+			return kindOther
+		}
+		return kindPrefix
+	}
+
+	switch t.text {
+	case "++", "--", "!", "!!":
+		return kindPostfix
+	case "?":
+		if next != nil && startsOperand(next.text) {
+			top.ternaries++
+			return kindBinary
+		}
+		return kindPostfix
+	case "~":
+		return kindOther
+	}
+
+	return kindBinary
+}
+
+// endsOperand tells whether token i can be the end of an operand, so an operator after it is binary.
+func (p *printer) endsOperand(i int) bool {
+	t := &p.tokens[i]
+	switch {
+	case p.kinds[i] == kindPostfix:
+		return true
+	case t.text == ")":
+		// Casts are followed by their operand: (int)-x
+		parent := t.node.Parent()
+		return parent == nil || !strings.Contains(parent.Type(), "cast")
+	case t.text == "]":
+		return true
+	case t.text == "}":
+		return !isBlockBrace(t, "}")
+	case valueKeywords[t.text]:
+		return true
+	}
+
+	return t.node.IsNamed() && isWord(t.text)
+}
+
+// breaksBefore tells whether token i starts a new line.
+func (p *printer) breaksBefore(i int, closed *frame) bool {
+	previous, t := &p.tokens[i-1], &p.tokens[i]
+
+	switch {
+	case isLineComment(previous):
+		return true
+	case isComment(t) && t.newlines == 0:
+		return false
+	case isBlockBrace(t, "{"), closed != nil && closed.block:
+		return true
+	case isBlockBrace(previous, "{"):
+		return true
+	case isBlockBrace(previous, "}"):
+		return t.text != ";" && t.text != "," && t.text != ")"
+	case previous.text == ";" && p.top().closer != ")":
+		return true
+	case isCaseKeyword(t.text), t.text == "$else", closed != nil && strings.HasPrefix(closed.closer, "$"):
+		return true
+	}
+
+	return t.newlines > 0
+}
+
+// indentFor returns the indentation of the line started by token i.
+func (p *printer) indentFor(i int, closed *frame) int {
+	if closed != nil {
+		return closed.indent
+	}
+
+	top := p.top()
+	if p.tokens[i].text == "$else" {
+		return top.indent
+	}
+
+	indent := top.indent + 1
+	if top.inCase {
+		indent++
+	}
+
+	// Expressions continued in the next line.
+	if !isBlockBrace(&p.tokens[i], "{") &&
+		(p.kinds[i-1] == kindBinary || p.kinds[i] == kindBinary || p.tokens[i].text == ".") {
+		indent++
+	}
+
+	return indent
+}
+
+// spaced tells whether token i is separated by a space from the previous one, in the same line.
+func (p *printer) spaced(i int) bool {
+	previous, t := &p.tokens[i-1], &p.tokens[i]
+	previousKind, kind := p.kinds[i-1], p.kinds[i]
+
+	switch {
+	case isComment(t):
+		return true
+	case isComment(previous):
+		return t.spaced
+	case t.text == "," || t.text == ";":
+		return false
+	case previousKind == kindPrefix, kind == kindPostfix:
+		return false
+	case previousKind == kindBinary, kind == kindBinary:
+		return true
+	case previous.text == "(" || previous.text == "[":
+		return false
+	case t.text == ")" || t.text == "]":
+		return false
+	case previous.text == "," || previous.text == ";":
+		return true
+	case attachedTokens[t.text], attachedTokens[previous.text]:
+		return false
+	case t.text == "...":
+		return !isWord(previous.text)
+	case previous.text == "...":
+		return isWord(t.text)
+	case isGenericBrace(previous) || isGenericBrace(t):
+		return false
+	case t.text == "(":
+		if controlKeywords[previous.text] {
+			return true
+		}
+		if p.endsOperand(i - 1) {
+			return false
+		}
+	case t.text == "[":
+		if p.endsOperand(i-1) || previousKind == kindPostfix {
+			return false
+		}
+	case previousKind == kindPostfix && isWord(t.text):
+		return true
+	}
+
+	return t.spaced
+}
+
+func (p *printer) render(l *line) string {
+	if len(l.parts) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	if p.options.UseTabs {
+		builder.WriteString(strings.Repeat("\t", l.indent))
+	} else {
+		builder.WriteString(strings.Repeat(" ", l.indent*p.options.IndentWidth))
+	}
+	for _, part := range l.parts {
+		if part.space {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(part.text)
+	}
+
+	return builder.String()
+}
+
+func (p *printer) width(l *line) int {
+	return l.indent*p.options.IndentWidth + partsWidth(l.parts)
+}
+
+func partsWidth(parts []part) int {
+	width := 0
+	for _, part := range parts {
+		if part.space {
+			width++
+		}
+		width += utf8.RuneCountInString(part.text)
+	}
+
+	return width
+}
+
+func isWord(text string) bool {
+	if text == "" {
+		return false
+	}
+	c := text[0]
+
+	return c == '_' || c == '$' || c == '@' || c == '#' || c == '\'' || c == '"' || c == '`' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= utf8.RuneSelf
+}
+
+func startsOperand(text string) bool {
+	if isWord(text) {
+		return true
+	}
+
+	switch text {
+	case "(", "[", "{", "-", "+", "!", "~", "&", "&&", "*", "++", "--":
+		return true
+	}
+
+	return false
+}
+
+func isCaseKeyword(text string) bool {
+	return text == "case" || text == "default" || text == "$case" || text == "$default"
+}
+
+func isComment(t *token) bool {
+	return strings.Contains(t.node.Type(), "comment")
+}
+
+func isLineComment(t *token) bool {
+	return isComment(t) && strings.HasPrefix(t.text, "//")
+}
+
+func isBlockBrace(t *token, brace string) bool {
+	if t.text != brace {
+		return false
+	}
+	parent := t.node.Parent()
+
+	return parent != nil && blockTypes[parent.Type()]
+}
+
+func isGenericBrace(t *token) bool {
+	if t.text != "{" && t.text != "}" {
+		return false
+	}
+	parent := t.node.Parent()
+
+	return parent != nil && parent.Type() == "generic_arguments"
+}
+
+// isTypeSuffix tells whether node is part of a type, like the pointer in int*.
+func isTypeSuffix(node *sitter.Node) bool {
+	for parent, depth := node.Parent(), 0; parent != nil && depth < 3; parent, depth = parent.Parent(), depth+1 {
+		if parent.Type() == "type_suffix" || parent.Type() == "type" {
+			return true
+		}
+	}
+
+	return false
+}
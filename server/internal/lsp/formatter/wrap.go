@@ -0,0 +1,91 @@
+package formatter
+
+import "strings"
+
+// wrap splits l when it is longer than the maximum width. Elements of the first list of the
+// line are moved to continuation lines, indented one more level, filling each one as much as
+// possible:
+//
+//	result = some_function(first_argument, second_argument,
+//		third_argument);
+func (p *printer) wrap(l *line) []*line {
+	if p.options.MaxLineWidth <= 0 || p.width(l) <= p.options.MaxLineWidth {
+		return []*line{l}
+	}
+
+	open, close := listToWrap(l.parts)
+	if open < 0 {
+		return []*line{l}
+	}
+
+	// Each element keeps its comma. The last one takes the rest of the line.
+	elements := [][]part{}
+	element := []part{}
+	depth := 0
+	for _, piece := range l.parts[open+1 : close] {
+		element = append(element, piece)
+		if _, ok := openers[piece.text]; ok {
+			depth++
+		} else if closers[piece.text] {
+			depth--
+		} else if piece.text == "," && depth == 0 {
+			elements = append(elements, element)
+			element = []part{}
+		}
+	}
+	elements = append(elements, append(element, l.parts[close:]...))
+
+	current := &line{indent: l.indent, parts: append([]part{}, l.parts[:open+1]...)}
+	lines := []*line{current}
+	width := p.width(current)
+	for i, element := range elements {
+		if i > 0 && width+partsWidth(element) > p.options.MaxLineWidth {
+			element[0].space = false
+			current = &line{indent: l.indent + 1}
+			lines = append(lines, current)
+			width = p.width(current)
+		}
+		current.parts = append(current.parts, element...)
+		width += partsWidth(element)
+	}
+
+	return lines
+}
+
+// listToWrap returns the positions of the brackets of the first list in parts that is closed
+// in the same line and has several elements, or -1.
+func listToWrap(parts []part) (int, int) {
+	for _, part := range parts {
+		if strings.Contains(part.text, "\n") || strings.HasPrefix(part.text, "//") {
+			// Comments can't be moved around.
+			return -1, -1
+		}
+	}
+
+	for open, part := range parts {
+		if part.text != "(" && part.text != "[" && part.text != "{" {
+			continue
+		}
+
+		depth := 0
+		commas := 0
+		for i := open + 1; i < len(parts); i++ {
+			text := parts[i].text
+			if _, ok := openers[text]; ok {
+				depth++
+			} else if closers[text] {
+				if depth == 0 {
+					if commas > 0 {
+						return open, i
+					}
+					break
+				}
+				depth--
+			} else if text == "," && depth == 0 {
+				commas++
+			}
+		}
+	}
+
+	return -1, -1
+}
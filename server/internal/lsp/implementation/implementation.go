@@ -0,0 +1,141 @@
+package implementation
+
+import (
+	"sort"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/pherrymason/c3-lsp/internal/lsp/type_hierarchy"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+)
+
+// ImplementationsAt returns the implementations of the symbol at position of docId. Methods
+// called on `any` values can't be resolved to a declaration, so every @dynamic method with
+// their name implements them.
+func ImplementationsAt(docId string, position symbols.Position, state *project_state.ProjectState, search search.SearchInterface) []symbols.Indexable {
+	resolved := search.FindSymbolDeclarationInWorkspace(docId, position, state)
+	if resolved.IsSome() {
+		return Implementations(resolved.Get(), state)
+	}
+
+	doc := state.GetDocument(docId)
+	unitModules := state.GetUnitModulesByDoc(docId)
+	if doc == nil || unitModules == nil {
+		return []symbols.Indexable{}
+	}
+
+	word := doc.SourceCode.SymbolInPosition(position, unitModules)
+	if !word.HasAccessPath() {
+		return []symbols.Indexable{}
+	}
+
+	receiver := search.FindSymbolDeclarationInWorkspace(docId, word.PrevAccessPath().TextRange().Start, state)
+	if receiver.IsNone() || !isAny(receiver.Get()) {
+		return []symbols.Indexable{}
+	}
+
+	return dynamicMethods(word.Text(), nil, state)
+}
+
+// Implementations returns the structs implementing an interface, or the @dynamic methods
+// of those structs implementing a method of the interface. Other symbols have no implementations.
+func Implementations(symbol symbols.Indexable, state *project_state.ProjectState) []symbols.Indexable {
+	found := []symbols.Indexable{}
+
+	switch s := symbol.(type) {
+	case *symbols.Interface:
+		for _, implementer := range implementers(s, state) {
+			found = append(found, implementer)
+		}
+
+	case *symbols.Function:
+		if _interface := owningInterface(s, state); _interface != nil {
+			found = dynamicMethods(s.GetName(), implementers(_interface, state), state)
+		}
+	}
+
+	return found
+}
+
+// implementers returns the structs implementing _interface.
+func implementers(_interface *symbols.Interface, state *project_state.ProjectState) []*symbols.Struct {
+	structs := []*symbols.Struct{}
+	for _, subtype := range type_hierarchy.Subtypes(_interface, state) {
+		if strukt, ok := subtype.(*symbols.Struct); ok {
+			structs = append(structs, strukt)
+		}
+	}
+
+	return structs
+}
+
+// dynamicMethods returns the @dynamic methods named name of the given structs, or of any
+// type when structs is nil, ordered by document and position.
+func dynamicMethods(name string, structs []*symbols.Struct, state *project_state.ProjectState) []symbols.Indexable {
+	methods := []symbols.Indexable{}
+	for _, unitModules := range state.GetAllUnitModules() {
+		for _, module := range unitModules.Modules() {
+			for _, function := range module.ChildrenFunctions {
+				if function.GetTypeIdentifier() != "" && function.GetMethodName() == name && function.IsDynamic() && isMethodOfAny(function, structs) {
+					methods = append(methods, function)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(methods, func(i, j int) bool {
+		if methods[i].GetDocumentURI() != methods[j].GetDocumentURI() {
+			return methods[i].GetDocumentURI() < methods[j].GetDocumentURI()
+		}
+
+		return methods[j].GetIdRange().IsBeforePosition(methods[i].GetIdRange().Start)
+	})
+
+	return methods
+}
+
+// isMethodOfAny tells if function is a method of one of structs. Any method matches when structs is nil.
+func isMethodOfAny(function *symbols.Function, structs []*symbols.Struct) bool {
+	if structs == nil {
+		return true
+	}
+
+	for _, strukt := range structs {
+		if function.IsMethodOf(strukt.GetName(), strukt.GetModuleString()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// owningInterface returns the interface declaring function, if any.
+func owningInterface(function *symbols.Function, state *project_state.ProjectState) *symbols.Interface {
+	unitModules := state.GetUnitModulesByDoc(function.GetDocumentURI())
+	if unitModules == nil {
+		return nil
+	}
+
+	for _, module := range unitModules.Modules() {
+		for _, _interface := range module.Interfaces {
+			if _interface.GetMethod(function.GetName()) == function {
+				return _interface
+			}
+		}
+	}
+
+	return nil
+}
+
+// isAny tells if symbol is a variable or member typed `any`, whose methods are dispatched
+// at runtime.
+func isAny(symbol symbols.Indexable) bool {
+	switch s := symbol.(type) {
+	case *symbols.Variable:
+		return s.GetType().GetName() == "any"
+	case *symbols.StructMember:
+		return s.GetType().GetName() == "any"
+	}
+
+	return false
+}
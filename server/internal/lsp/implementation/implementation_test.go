@@ -0,0 +1,75 @@
+package implementation
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/stretchr/testify/assert"
+	"github.com/tliron/commonlog"
+)
+
+func names(found []symbols.Indexable) []string {
+	names := []string{}
+	for _, symbol := range found {
+		names = append(names, symbol.GetName())
+	}
+
+	return names
+}
+
+const shapes = `module shapes;
+interface Shape {
+	fn float area();
+}
+struct Circle (Shape) { float radius; }
+fn float Circle.area(&self) @dynamic { return 3.14f * self.radius * self.radius; }
+struct Square (Shape) { float side; }
+fn float Square.area(&self) @dynamic { return self.side * self.side; }
+fn float Square.perimeter(&self) { return 4 * self.side; }
+fn void print(Shape shape, any value) {
+	shape.area();
+	value.area();
+}`
+
+func TestImplementationsAt(t *testing.T) {
+	state := project_state.NewTestProjectState(map[string]string{
+		"shapes.c3": shapes,
+		"extra.c3": `module extra;
+struct Triangle { float base; float height; }
+fn float Triangle.area(&self) @dynamic { return self.base * self.height / 2; }
+fn float Triangle.half(&self) { return self.area() / 2; }`,
+	})
+	searcher := search.NewSearch(commonlog.MockLogger{}, false)
+
+	t.Run("Structs implementing an interface", func(t *testing.T) {
+		found := ImplementationsAt("shapes.c3", symbols.NewPosition(1, 11), state, &searcher)
+
+		assert.Equal(t, []string{"Circle", "Square"}, names(found))
+	})
+
+	t.Run("Dynamic methods of structs implementing the interface of a method", func(t *testing.T) {
+		found := ImplementationsAt("shapes.c3", symbols.NewPosition(2, 10), state, &searcher)
+
+		assert.Equal(t, []string{"Circle.area", "Square.area"}, names(found), "Triangle does not implement Shape")
+	})
+
+	t.Run("Methods called through an interface value", func(t *testing.T) {
+		found := ImplementationsAt("shapes.c3", symbols.NewPosition(10, 8), state, &searcher)
+
+		assert.Equal(t, []string{"Circle.area", "Square.area"}, names(found))
+	})
+
+	t.Run("Methods called through an any value", func(t *testing.T) {
+		found := ImplementationsAt("shapes.c3", symbols.NewPosition(11, 8), state, &searcher)
+
+		assert.Equal(t, []string{"Triangle.area", "Circle.area", "Square.area"}, names(found))
+	})
+
+	t.Run("Methods of structs have no implementations", func(t *testing.T) {
+		found := ImplementationsAt("shapes.c3", symbols.NewPosition(7, 17), state, &searcher)
+
+		assert.Empty(t, found)
+	})
+}
@@ -0,0 +1,121 @@
+package index_cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/reference_index"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/symbols_table"
+)
+
+// FormatVersion identifies the layout of cached entries. It must be increased
+// every time the JSON serialization of symbols changes, so entries written by
+// an older layout are discarded instead of loaded with missing data.
+const FormatVersion = 2
+
+// Entry is the cached index of a single source file.
+// It is only valid while the file content, the server version and the cache
+// format are the same ones used to build it.
+type Entry struct {
+	FormatVersion int                         `json:"format_version"`
+	ServerVersion string                      `json:"server_version"`
+	Path          string                      `json:"path"`
+	Hash          string                      `json:"hash"`
+	Modules       []*symbols.Module           `json:"modules"`
+	References    []reference_index.Reference `json:"references"`
+}
+
+// Cache stores the parsed symbols of source files on disk, so they don't
+// need to be extracted again on next startup if files did not change.
+type Cache struct {
+	dir           string
+	serverVersion string
+}
+
+func NewCache(dir string, serverVersion string) *Cache {
+	return &Cache{
+		dir:           dir,
+		serverVersion: serverVersion,
+	}
+}
+
+// GetDefaultCachePath returns the directory used when no cache path is configured.
+func GetDefaultCachePath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cacheDir, "c3-lsp", "index"), nil
+}
+
+// Load returns the cached entry of path, if content, server version and cache format still match it.
+func (c *Cache) Load(path string, content string) (Entry, error) {
+	data, err := os.ReadFile(c.entryFile(path))
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, fmt.Errorf("failed to parse cache file: %w", err)
+	}
+
+	if entry.FormatVersion != FormatVersion {
+		return Entry{}, fmt.Errorf("cache format mismatch: expected %d, got %d", FormatVersion, entry.FormatVersion)
+	}
+	if entry.ServerVersion != c.serverVersion {
+		return Entry{}, fmt.Errorf("cache server version mismatch: expected %s, got %s", c.serverVersion, entry.ServerVersion)
+	}
+	if entry.Path != path || entry.Hash != hashContent(content) {
+		return Entry{}, fmt.Errorf("cache is outdated for %s", path)
+	}
+
+	return entry, nil
+}
+
+// Save stores the symbols and references of path.
+// It must be called before symbols are registered in the symbols table, as
+// registering them resolves types against other documents.
+func (c *Cache) Save(path string, content string, modules symbols_table.UnitModules, references []reference_index.Reference) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(Entry{
+		FormatVersion: FormatVersion,
+		ServerVersion: c.serverVersion,
+		Path:          path,
+		Hash:          hashContent(content),
+		Modules:       modules.Modules(),
+		References:    references,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache: %w", err)
+	}
+
+	if err := os.WriteFile(c.entryFile(path), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	return nil
+}
+
+// Forget removes the cached entry of path.
+func (c *Cache) Forget(path string) {
+	_ = os.Remove(c.entryFile(path))
+}
+
+func (c *Cache) entryFile(path string) string {
+	return filepath.Join(c.dir, hashContent(path)+".json")
+}
+
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
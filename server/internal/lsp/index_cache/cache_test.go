@@ -0,0 +1,64 @@
+package index_cache
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/reference_index"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/symbols_table"
+	"github.com/stretchr/testify/assert"
+)
+
+func buildUnitModules(docId string) symbols_table.UnitModules {
+	unitModules := symbols_table.NewParsedModules(&docId)
+	module := symbols.NewModuleBuilder("app", docId).Build()
+	module.AddFunction(
+		symbols.NewFunctionBuilder("main", symbols.NewTypeFromString("void", "app"), "app", docId).Build(),
+	)
+	unitModules.RegisterModule(module)
+
+	return unitModules
+}
+
+func TestCache_loads_saved_entry(t *testing.T) {
+	cache := NewCache(t.TempDir(), "1.0.0")
+	references := []reference_index.Reference{
+		{Name: "main", DocId: "app.c3", Range: symbols.NewRange(0, 3, 0, 7)},
+	}
+
+	err := cache.Save("app.c3", "fn void main() {}", buildUnitModules("app.c3"), references)
+	assert.NoError(t, err)
+
+	entry, err := cache.Load("app.c3", "fn void main() {}")
+	assert.NoError(t, err)
+	assert.Equal(t, "app", entry.Modules[0].GetName())
+	assert.Equal(t, "main", entry.Modules[0].ChildrenFunctions[0].GetName())
+	assert.Equal(t, references, entry.References)
+}
+
+func TestCache_rejects_outdated_entries(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(dir, "1.0.0")
+	err := cache.Save("app.c3", "fn void main() {}", buildUnitModules("app.c3"), nil)
+	assert.NoError(t, err)
+
+	_, err = cache.Load("app.c3", "fn void main() { return; }")
+	assert.Error(t, err, "Content changed")
+
+	_, err = NewCache(dir, "1.0.1").Load("app.c3", "fn void main() {}")
+	assert.Error(t, err, "Server version changed")
+
+	data, err := os.ReadFile(cache.entryFile("app.c3"))
+	assert.NoError(t, err)
+	outdatedFormat := strings.Replace(string(data), fmt.Sprintf(`"format_version":%d`, FormatVersion), `"format_version":1`, 1)
+	assert.NoError(t, os.WriteFile(cache.entryFile("app.c3"), []byte(outdatedFormat), 0644))
+	_, err = cache.Load("app.c3", "fn void main() {}")
+	assert.Error(t, err, "Cache format changed")
+
+	cache.Forget("app.c3")
+	_, err = cache.Load("app.c3", "fn void main() {}")
+	assert.Error(t, err, "Entry was removed")
+}
@@ -0,0 +1,478 @@
+package inlay_hints
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/reference_index"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Kind of a hint. Values are the ones of the LSP InlayHintKind, 0 meaning no kind.
+type Kind int
+
+const (
+	KindType      Kind = 1
+	KindParameter Kind = 2
+)
+
+// Options selects the kinds of hints computed.
+type Options struct {
+	ParameterNames bool // Names of the parameters at call sites
+	InferredTypes  bool // Types of `var` declarations and of the arguments of untyped macro parameters
+	EnumValues     bool // Ordinals of enumerators
+}
+
+// Hint is a label shown inline in the document.
+type Hint struct {
+	Position     symbols.Position
+	Label        string
+	Kind         Kind
+	PaddingLeft  bool
+	PaddingRight bool
+	Data         Data
+}
+
+// Data identifies the symbol documenting a hint, so its tooltip is only computed when the
+// client resolves the hint.
+type Data struct {
+	URI string `json:"uri"`
+	// Position of the identifier of the called function, for parameter hints.
+	Callee    *symbols.Position `json:"callee,omitempty"`
+	Parameter string            `json:"parameter,omitempty"`
+	// Fully qualified name of the type, for type hints.
+	Type string `json:"type,omitempty"`
+}
+
+// Hints returns the hints of doc found in visible.
+func Hints(doc *document.Document, visible symbols.Range, state *project_state.ProjectState, search search.SearchInterface, options Options) []Hint {
+	if doc.SyntaxTree() == nil {
+		return []Hint{}
+	}
+
+	c := collector{
+		doc:        doc,
+		sourceCode: []byte(doc.SourceCode.Text),
+		visible:    visible,
+		state:      state,
+		search:     search,
+		options:    options,
+		hints:      []Hint{},
+	}
+	c.walk(doc.SyntaxTree().RootNode())
+
+	return c.hints
+}
+
+type collector struct {
+	doc        *document.Document
+	sourceCode []byte
+	visible    symbols.Range
+	state      *project_state.ProjectState
+	search     search.SearchInterface
+	options    Options
+	hints      []Hint
+}
+
+func (c *collector) walk(node *sitter.Node) {
+	nodeRange := symbols.NewRangeFromTreeSitterPositions(node.StartPoint(), node.EndPoint())
+	if isBefore(nodeRange.End, c.visible.Start) || isBefore(c.visible.End, nodeRange.Start) {
+		return
+	}
+
+	switch node.Type() {
+	case "call_expr":
+		c.callHints(node)
+	case "declaration":
+		if c.options.InferredTypes {
+			c.declarationHints(node)
+		}
+	case "enum_declaration":
+		if c.options.EnumValues {
+			c.enumHints(node)
+		}
+	}
+
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		c.walk(node.NamedChild(i))
+	}
+}
+
+func (c *collector) add(hint Hint) {
+	if c.visible.HasPosition(hint.Position) {
+		hint.Data.URI = c.doc.URI
+		c.hints = append(c.hints, hint)
+	}
+}
+
+// callHints adds the names of the parameters before the arguments of call, and the types of
+// the arguments of untyped macro parameters after them.
+func (c *collector) callHints(call *sitter.Node) {
+	if !c.options.ParameterNames && !c.options.InferredTypes {
+		return
+	}
+
+	invocation := call.ChildByFieldName("arguments")
+	calleeNode := call.ChildByFieldName("function")
+	if invocation == nil || calleeNode == nil {
+		return
+	}
+	function, calleePosition := c.callee(calleeNode)
+	if function == nil {
+		return
+	}
+
+	parameters := []*symbols.Variable{}
+	for _, parameter := range function.GetArguments() {
+		// Trailing blocks, like @body, are not passed between the parentheses.
+		if parameter != nil && !strings.HasPrefix(parameter.GetName(), "@") {
+			parameters = append(parameters, parameter)
+		}
+	}
+	if len(parameters) > 0 && parameters[0].GetName() == "self" && isCalledOnValue(calleeNode.Content(c.sourceCode)) {
+		parameters = parameters[1:]
+	}
+
+	for i, argument := range arguments(invocation) {
+		if i >= len(parameters) || isNamedArgument(argument) || isSplat(argument) {
+			return
+		}
+		parameter := parameters[i]
+		expression := argumentExpression(argument)
+		name := parameter.GetName()
+
+		if c.options.ParameterNames && !strings.HasPrefix(name, "$arg#") && !matchesParameter(expression.Content(c.sourceCode), name) {
+			label := name + ":"
+			if parameter.Arg.VarArg {
+				label = name + "...:"
+			}
+			c.add(Hint{
+				Position:     symbols.NewPositionFromTreeSitterPoint(argument.StartPoint()),
+				Label:        label,
+				Kind:         KindParameter,
+				PaddingRight: true,
+				Data:         Data{Callee: &calleePosition, Parameter: name},
+			})
+		}
+
+		if c.options.InferredTypes && function.FunctionType() == symbols.Macro && parameter.GetType().GetName() == "" {
+			c.addTypeHint(symbols.NewPositionFromTreeSitterPoint(argument.EndPoint()), expression)
+		}
+
+		if parameter.Arg.VarArg {
+			return
+		}
+	}
+}
+
+// callee resolves the function or macro called through node.
+func (c *collector) callee(node *sitter.Node) (*symbols.Function, symbols.Position) {
+	identifier := reference_index.LastIdentifier(node)
+	if identifier == nil {
+		return nil, symbols.Position{}
+	}
+
+	position := symbols.NewPositionFromTreeSitterPoint(identifier.StartPoint())
+	resolved := c.search.FindSymbolDeclarationInWorkspace(c.doc.URI, position, c.state)
+	if resolved.IsNone() {
+		return nil, position
+	}
+
+	function, _ := resolved.Get().(*symbols.Function)
+
+	return function, position
+}
+
+// declarationHints adds the type of the value assigned to `var` declarations.
+func (c *collector) declarationHints(declaration *sitter.Node) {
+	var name *sitter.Node
+	var value *sitter.Node
+	isVar := false
+	assigned := false
+	for i := 0; i < int(declaration.ChildCount()); i++ {
+		child := declaration.Child(i)
+		switch {
+		case child.Type() == "var":
+			isVar = true
+		case child.Type() == "=":
+			assigned = true
+		case !child.IsNamed():
+		case assigned && value == nil:
+			value = child
+		case !assigned && (child.Type() == "ident" || child.Type() == "ct_ident"):
+			name = child
+		}
+	}
+	if !isVar || name == nil || value == nil {
+		return
+	}
+
+	c.addTypeHint(symbols.NewPositionFromTreeSitterPoint(name.EndPoint()), value)
+}
+
+func (c *collector) addTypeHint(position symbols.Position, expression *sitter.Node) {
+	valueType := c.expressionType(expression)
+	if valueType == nil || valueType.GetName() == "" {
+		return
+	}
+
+	data := Data{}
+	if !valueType.IsBaseTypeLanguage() {
+		data.Type = valueType.GetFullQualifiedName()
+	}
+	c.add(Hint{
+		Position: position,
+		Label:    ": " + valueType.String(),
+		Kind:     KindType,
+		Data:     data,
+	})
+}
+
+// expressionType infers the type of the value of node. Only literals, casts, calls and
+// references to variables and members are understood.
+func (c *collector) expressionType(node *sitter.Node) *symbols.Type {
+	content := node.Content(c.sourceCode)
+
+	switch node.Type() {
+	case "paren_expr":
+		if node.NamedChildCount() == 1 {
+			return c.expressionType(node.NamedChild(0))
+		}
+	case "integer_literal":
+		return baseType(integerLiteralType(content))
+	case "real_literal":
+		return baseType(realLiteralType(content))
+	case "char_literal":
+		return baseType("char")
+	case "true", "false":
+		return baseType("bool")
+	case "string_literal", "raw_string_literal":
+		found := symbols.NewTypeFromString("String", "std::core::string")
+		return &found
+	case "cast_expr":
+		if typeNode := node.ChildByFieldName("type"); typeNode != nil {
+			found := symbols.NewTypeFromString(typeNode.Content(c.sourceCode), c.moduleAt(node))
+			return &found
+		}
+	case "call_expr":
+		calleeNode := node.ChildByFieldName("function")
+		if calleeNode == nil {
+			return nil
+		}
+		if function, _ := c.callee(calleeNode); function != nil {
+			return function.GetReturnType()
+		}
+	default:
+		identifier := reference_index.LastIdentifier(node)
+		if identifier == nil || identifier.EndByte() != node.EndByte() {
+			return nil
+		}
+
+		position := symbols.NewPositionFromTreeSitterPoint(identifier.StartPoint())
+		resolved := c.search.FindSymbolDeclarationInWorkspace(c.doc.URI, position, c.state)
+		if resolved.IsNone() {
+			return nil
+		}
+
+		switch symbol := resolved.Get().(type) {
+		case *symbols.Variable:
+			return symbol.GetType()
+		case *symbols.StructMember:
+			return symbol.GetType()
+		case *symbols.Enumerator:
+			found := symbols.NewTypeFromString(symbol.GetEnumName(), symbol.GetModuleString())
+			return &found
+		}
+	}
+
+	return nil
+}
+
+// moduleAt returns the name of the module node is written in.
+func (c *collector) moduleAt(node *sitter.Node) string {
+	position := symbols.NewPositionFromTreeSitterPoint(node.StartPoint())
+	for _, module := range c.state.GetUnitModulesByDoc(c.doc.URI).Modules() {
+		if module.GetDocumentRange().HasPosition(position) {
+			return module.GetName()
+		}
+	}
+
+	return ""
+}
+
+// enumHints adds the ordinal of each enumerator. Const enums, whose values are written, are skipped.
+func (c *collector) enumHints(declaration *sitter.Node) {
+	var body *sitter.Node
+	for i := 0; i < int(declaration.NamedChildCount()); i++ {
+		child := declaration.NamedChild(i)
+		switch child.Type() {
+		case "enum_spec":
+			for j := 0; j < int(child.ChildCount()); j++ {
+				if child.Child(j).Type() == "const" {
+					return
+				}
+			}
+		case "enum_body":
+			body = child
+		}
+	}
+	if body == nil {
+		return
+	}
+
+	ordinal := 0
+	for i := 0; i < int(body.NamedChildCount()); i++ {
+		constant := body.NamedChild(i)
+		if constant.Type() != "enum_constant" {
+			continue
+		}
+		name := constant.ChildByFieldName("name")
+		if name == nil {
+			continue
+		}
+
+		c.add(Hint{
+			Position:    symbols.NewPositionFromTreeSitterPoint(name.EndPoint()),
+			Label:       "= " + strconv.Itoa(ordinal),
+			PaddingLeft: true,
+		})
+		ordinal++
+	}
+}
+
+// arguments returns the arguments written between the parentheses of invocation.
+func arguments(invocation *sitter.Node) []*sitter.Node {
+	found := []*sitter.Node{}
+	for i := 0; i < int(invocation.NamedChildCount()); i++ {
+		child := invocation.NamedChild(i)
+		switch child.Type() {
+		case "attributes", "line_comment", "block_comment", "doc_comment":
+		default:
+			found = append(found, child)
+		}
+	}
+
+	return found
+}
+
+// isNamedArgument tells if argument is written as `name: value`.
+func isNamedArgument(argument *sitter.Node) bool {
+	if argument.ChildByFieldName("name") != nil {
+		return true
+	}
+	for i := 0; i < int(argument.ChildCount()); i++ {
+		if argument.Child(i).Type() == ":" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSplat tells if argument expands into several arguments: `...values`.
+func isSplat(argument *sitter.Node) bool {
+	return argument.ChildCount() > 0 && argument.Child(0).Type() == "..."
+}
+
+func argumentExpression(argument *sitter.Node) *sitter.Node {
+	if argument.Type() == "call_arg" && argument.NamedChildCount() > 0 {
+		return argument.NamedChild(int(argument.NamedChildCount()) - 1)
+	}
+
+	return argument
+}
+
+// matchesParameter tells if the argument already reads as the parameter name, like `count`
+// or `&self.count` for a parameter named count.
+func matchesParameter(argument string, name string) bool {
+	argument = strings.TrimLeft(argument, "&*")
+	if i := strings.LastIndex(argument, "."); i >= 0 {
+		argument = argument[i+1:]
+	}
+
+	return strings.TrimLeft(name, "$#") == strings.TrimLeft(argument, "$#")
+}
+
+// isCalledOnValue tells if a method is called on a value, as in `list.push(x)`, which is
+// passed as `self`, and not through its type, as in `List.push(&list, x)`.
+func isCalledOnValue(callee string) bool {
+	i := strings.LastIndex(callee, ".")
+	if i < 0 {
+		return false
+	}
+
+	receiver := callee[:i]
+	if j := strings.LastIndexAny(receiver, ".:"); j >= 0 {
+		receiver = receiver[j+1:]
+	}
+	first, _ := utf8.DecodeRuneInString(receiver)
+
+	// Type names start with an uppercase letter and are not all uppercase, like constants.
+	return !unicode.IsUpper(first) || strings.ToUpper(receiver) == receiver
+}
+
+func baseType(name string) *symbols.Type {
+	if name == "" {
+		return nil
+	}
+
+	found := symbols.NewBaseTypeBuilder(name, "").Build()
+	return &found
+}
+
+// Suffixes of integer literals, longest first.
+var integerSuffixes = []struct {
+	suffix   string
+	typeName string
+}{
+	{"i128", "int128"}, {"u128", "uint128"},
+	{"i64", "long"}, {"u64", "ulong"},
+	{"i32", "int"}, {"u32", "uint"},
+	{"i16", "short"}, {"u16", "ushort"},
+	{"i8", "ichar"}, {"u8", "char"},
+	{"ul", "ulong"}, {"u", "uint"}, {"l", "long"},
+}
+
+// integerLiteralType returns the type of an integer literal, int unless it has a suffix.
+func integerLiteralType(literal string) string {
+	literal = strings.ToLower(literal)
+	for _, suffix := range integerSuffixes {
+		if strings.HasSuffix(literal, suffix.suffix) {
+			return suffix.typeName
+		}
+	}
+
+	return "int"
+}
+
+// realLiteralType returns the type of a floating point literal, double unless it has a suffix.
+func realLiteralType(literal string) string {
+	literal = strings.ToLower(literal)
+	if strings.HasPrefix(literal, "0x") {
+		// Hexadecimal digits can't be told apart from suffixes.
+		return "double"
+	}
+
+	switch {
+	case strings.HasSuffix(literal, "f128"):
+		return "float128"
+	case strings.HasSuffix(literal, "f64"), strings.HasSuffix(literal, "d"):
+		return "double"
+	case strings.HasSuffix(literal, "f16"):
+		return "float16"
+	case strings.HasSuffix(literal, "f32"), strings.HasSuffix(literal, "f"):
+		return "float"
+	}
+
+	return "double"
+}
+
+func isBefore(a symbols.Position, b symbols.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
@@ -0,0 +1,136 @@
+package inlay_hints
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/stretchr/testify/assert"
+	"github.com/tliron/commonlog"
+)
+
+var allHints = Options{ParameterNames: true, InferredTypes: true, EnumValues: true}
+
+func documentState(source string) (*project_state.ProjectState, *document.Document) {
+	state := project_state.NewTestProjectState(map[string]string{"app.c3": source})
+
+	return state, state.GetDocument("app.c3")
+}
+
+func hints(source string, options Options) []Hint {
+	state, doc := documentState(source)
+	searcher := search.NewSearch(commonlog.MockLogger{}, false)
+
+	return Hints(doc, symbols.NewRange(0, 0, 1000, 0), state, &searcher, options)
+}
+
+func labels(hints []Hint) []string {
+	found := []string{}
+	for _, hint := range hints {
+		found = append(found, hint.Label)
+	}
+
+	return found
+}
+
+func TestHints_parameter_names(t *testing.T) {
+	t.Run("Names the arguments of calls", func(t *testing.T) {
+		found := hints(`module app;
+fn void draw(int x, int y) {}
+fn void main() {
+	int y = 2;
+	draw(1, y);
+}`, allHints)
+
+		assert.Equal(t, []string{"x:"}, labels(found))
+		assert.Equal(t, symbols.NewPosition(4, 6), found[0].Position)
+		assert.Equal(t, KindParameter, found[0].Kind)
+	})
+
+	t.Run("Self is not named when calling a method on a value", func(t *testing.T) {
+		found := hints(`module app;
+struct Canvas { int width; }
+fn void Canvas.draw(&self, int color) {}
+fn void main() {
+	Canvas canvas;
+	canvas.draw(3);
+}`, allHints)
+
+		assert.Equal(t, []string{"color:"}, labels(found))
+	})
+
+	t.Run("Nothing is added when disabled", func(t *testing.T) {
+		found := hints(`module app;
+fn void draw(int x) {}
+fn void main() {
+	draw(1);
+}`, Options{})
+
+		assert.Empty(t, found)
+	})
+}
+
+func TestHints_inferred_types(t *testing.T) {
+	t.Run("Types of var declarations", func(t *testing.T) {
+		found := hints(`module app;
+fn double area() { return 1.0; }
+macro void main() {
+	var count = 10;
+	var ratio = area();
+}`, allHints)
+
+		assert.Equal(t, []string{": int", ": double"}, labels(found))
+		assert.Equal(t, symbols.NewPosition(3, 10), found[0].Position)
+	})
+
+	t.Run("Types of the arguments of untyped macro parameters", func(t *testing.T) {
+		found := hints(`module app;
+macro twice(value) { return value * 2; }
+fn void main() {
+	twice(1.5f);
+}`, Options{InferredTypes: true})
+
+		assert.Equal(t, []string{": float"}, labels(found))
+	})
+}
+
+func TestHints_enum_values(t *testing.T) {
+	found := hints(`module app;
+enum Color { RED, GREEN }`, allHints)
+
+	assert.Equal(t, []string{"= 0", "= 1"}, labels(found))
+	assert.Equal(t, symbols.NewPosition(1, 16), found[0].Position)
+}
+
+func TestTooltip(t *testing.T) {
+	state, _ := documentState(`module app;
+<*
+ Draws a point.
+ @param x : "Horizontal position"
+*>
+fn void draw(int x) {}`)
+	searcher := search.NewSearch(commonlog.MockLogger{}, false)
+	callee := symbols.NewPosition(5, 8)
+
+	tooltip := Tooltip(Data{URI: "app.c3", Callee: &callee, Parameter: "x"}, state, &searcher)
+
+	assert.Equal(t, "```c3\nfn void draw(int x)\n```\n\n**@param** x : \"Horizontal position\"", tooltip)
+}
+
+func TestLiteralTypes(t *testing.T) {
+	assert.Equal(t, "int", integerLiteralType("42"))
+	assert.Equal(t, "char", integerLiteralType("0xFFu8"))
+	assert.Equal(t, "ulong", integerLiteralType("10UL"))
+	assert.Equal(t, "double", realLiteralType("1.5"))
+	assert.Equal(t, "float", realLiteralType("1.5f"))
+	assert.Equal(t, "double", realLiteralType("0x1.8p1"))
+}
+
+func TestIsCalledOnValue(t *testing.T) {
+	assert.True(t, isCalledOnValue("list.push"))
+	assert.True(t, isCalledOnValue("self.items.push"))
+	assert.False(t, isCalledOnValue("List.push"))
+	assert.False(t, isCalledOnValue("push"))
+}
@@ -0,0 +1,75 @@
+package inlay_hints
+
+import (
+	"strings"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+)
+
+// Tooltip returns the markdown documentation of the hint data belongs to, or "" when there is
+// none: the signature of the called function with the @param contract of the parameter, or the
+// doc comment of the inferred type.
+func Tooltip(data Data, state *project_state.ProjectState, search search.SearchInterface) string {
+	if data.Callee != nil {
+		resolved := search.FindSymbolDeclarationInWorkspace(data.URI, *data.Callee, state)
+		if resolved.IsNone() {
+			return ""
+		}
+		function, ok := resolved.Get().(*symbols.Function)
+		if !ok {
+			return ""
+		}
+
+		tooltip := "```c3\n" + function.DisplaySignature(true) + "\n```"
+		if description := parameterDescription(function.GetDocComment(), data.Parameter); description != "" {
+			tooltip += "\n\n" + description
+		}
+
+		return tooltip
+	}
+
+	if data.Type != "" {
+		name := data.Type
+		if i := strings.LastIndex(name, "::"); i >= 0 {
+			name = name[i+2:]
+		}
+
+		candidates := state.SearchByFQN(data.Type)
+		if len(candidates) == 0 {
+			candidates = state.SearchByName(name)
+		}
+		for _, candidate := range candidates {
+			if docComment := candidate.GetDocComment(); docComment != nil {
+				return docComment.DisplayBodyWithContracts()
+			}
+		}
+	}
+
+	return ""
+}
+
+// parameterDescription returns the body of the @param contract of docComment describing
+// parameter, which is written as `[in] name : "description"`.
+func parameterDescription(docComment *symbols.DocComment, parameter string) string {
+	if docComment == nil {
+		return ""
+	}
+
+	for _, contract := range docComment.GetContracts() {
+		if contract.GetName() != "@param" {
+			continue
+		}
+
+		words := strings.Fields(contract.GetBody())
+		if len(words) > 0 && strings.HasPrefix(words[0], "[") {
+			words = words[1:]
+		}
+		if len(words) > 0 && strings.TrimSuffix(words[0], ":") == parameter {
+			return "**@param** " + contract.GetBody()
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,341 @@
+package layout
+
+import (
+	"strings"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+)
+
+// Layout is how values of a type are stored in memory.
+type Layout struct {
+	Size    uint
+	Align   uint
+	Members []Member // Of structs, unions and bitstructs, in memory order
+}
+
+// Member is a member of a struct, union or bitstruct, or padding between them.
+type Member struct {
+	Name     string // Members of inner structs are prefixed with the name of the struct: inner.x
+	Type     string
+	Offset   uint // Bytes from the start of the type
+	Size     uint
+	BitRange option.Option[[2]uint] // Bits of a bitstruct member, in the storage found at Offset
+	Padding  bool
+}
+
+// Calculator computes the layout of types for a target, resolving the types they use in state.
+// Attributes changing the layout, like @packed or @align, are not taken into account.
+type Calculator struct {
+	target   Target
+	state    *project_state.ProjectState
+	visiting map[string]bool // Types being calculated, to stop on recursive declarations
+}
+
+func NewCalculator(target Target, state *project_state.ProjectState) *Calculator {
+	return &Calculator{
+		target:   target,
+		state:    state,
+		visiting: map[string]bool{},
+	}
+}
+
+// SymbolLayout returns the layout of the type declared by symbol or, for variables and members,
+// the layout of their type.
+func (c *Calculator) SymbolLayout(symbol symbols.Indexable) option.Option[Layout] {
+	var found Layout
+	var ok bool
+
+	switch s := symbol.(type) {
+	case *symbols.Variable:
+		found, ok = c.typeLayout(s.GetType())
+	case *symbols.StructMember:
+		substruct := s.Substruct()
+		if s.IsStruct() && substruct.IsSome() {
+			found, ok = c.structLayout(entriesOf(substruct.Get().GetMembers()), substruct.Get().IsUnion())
+		} else if !s.HasBitRange() {
+			found, ok = c.typeLayout(s.GetType())
+		}
+	default:
+		found, ok = c.declarationLayout(symbol)
+	}
+
+	if !ok {
+		return option.None[Layout]()
+	}
+
+	return option.Some(found)
+}
+
+func (c *Calculator) declarationLayout(symbol symbols.Indexable) (Layout, bool) {
+	key := symbol.GetModuleString() + "::" + symbol.GetName()
+	if c.visiting[key] {
+		return Layout{}, false
+	}
+	c.visiting[key] = true
+	defer delete(c.visiting, key)
+
+	switch s := symbol.(type) {
+	case *symbols.Struct:
+		return c.structLayout(declaredMembers(s), s.IsUnion())
+
+	case *symbols.Bitstruct:
+		backingType := s.Type()
+		found, ok := c.typeLayout(&backingType)
+		entries := entriesOf(s.Members())
+		found.Members = bitMembers(entries)
+
+		return found, ok
+
+	case *symbols.Enum:
+		backingType := s.GetType()
+		if backingType == "" {
+			backingType = "int"
+		}
+		enumType := symbols.NewTypeFromString(backingType, s.GetModuleString())
+		found, ok := c.typeLayout(&enumType)
+		found.Members = nil
+
+		return found, ok
+
+	case *symbols.Fault:
+		return c.pointerLayout(), true
+
+	case *symbols.Interface:
+		// Values of interface types are `any`: a pointer and a typeid.
+		return Layout{Size: 2 * c.target.PointerSize, Align: c.target.PointerSize}, true
+
+	case *symbols.Distinct:
+		return c.typeLayout(s.GetBaseType())
+
+	case *symbols.Def:
+		if s.ResolvesToType() {
+			return c.typeLayout(s.ResolvedType())
+		}
+	}
+
+	return Layout{}, false
+}
+
+func (c *Calculator) typeLayout(t *symbols.Type) (Layout, bool) {
+	var element Layout
+	ok := true
+
+	switch {
+	case t.IsPointer():
+		element = c.pointerLayout()
+	case t.HasGenericArguments():
+		return Layout{}, false
+	default:
+		element, ok = c.builtinLayout(t.GetName())
+		if !ok && !t.IsBaseTypeLanguage() {
+			if symbol := c.resolve(t); symbol != nil {
+				element, ok = c.declarationLayout(symbol)
+			}
+		}
+	}
+
+	if !ok || !t.IsCollection() {
+		return element, ok
+	}
+
+	length := t.GetCollectionSize()
+	if length.IsNone() {
+		// Slices are a pointer and a length.
+		return Layout{Size: 2 * c.target.PointerSize, Align: c.target.PointerSize}, true
+	}
+
+	return Layout{Size: element.Size * uint(length.Get()), Align: element.Align}, true
+}
+
+// resolve finds the declaration of the type t refers to. Types of the module t was written in
+// are preferred.
+func (c *Calculator) resolve(t *symbols.Type) symbols.Indexable {
+	name := t.GetName()
+	if i := strings.LastIndex(name, "::"); i >= 0 {
+		name = name[i+2:]
+	}
+
+	candidates := c.state.SearchByFQN(t.GetModule() + "::" + name)
+	candidates = append(candidates, c.state.SearchByName(name)...)
+	for _, candidate := range candidates {
+		switch candidate.(type) {
+		case *symbols.Struct, *symbols.Bitstruct, *symbols.Enum, *symbols.Fault, *symbols.Interface, *symbols.Distinct, *symbols.Def:
+			return candidate
+		}
+	}
+
+	return nil
+}
+
+func (c *Calculator) pointerLayout() Layout {
+	return Layout{Size: c.target.PointerSize, Align: c.target.PointerSize}
+}
+
+func (c *Calculator) builtinLayout(name string) (Layout, bool) {
+	size := uint(0)
+	switch name {
+	case "bool", "char", "ichar":
+		size = 1
+	case "short", "ushort", "float16", "bfloat":
+		size = 2
+	case "int", "uint", "float":
+		size = 4
+	case "long", "ulong", "double":
+		return Layout{Size: 8, Align: c.target.Int64Align}, true
+	case "int128", "uint128", "float128":
+		size = 16
+	case "iptr", "uptr", "isz", "usz", "typeid", "anyfault", "fault", "void*":
+		return c.pointerLayout(), true
+	case "any":
+		return Layout{Size: 2 * c.target.PointerSize, Align: c.target.PointerSize}, true
+	default:
+		return Layout{}, false
+	}
+
+	return Layout{Size: size, Align: size}, true
+}
+
+// entry is a member of a struct with the anonymous blocks that start with it.
+type entry struct {
+	member *symbols.StructMember
+	blocks []symbols.MemberBlock
+}
+
+func entriesOf(members []*symbols.StructMember) []entry {
+	entries := []entry{}
+	for _, member := range members {
+		entries = append(entries, entry{member: member, blocks: member.GetBlocks()})
+	}
+
+	return entries
+}
+
+// declaredMembers returns the members written in the declaration of strukt. The members of
+// inlined structs, which are appended after them, are left out.
+func declaredMembers(strukt *symbols.Struct) []entry {
+	members := []*symbols.StructMember{}
+	for _, member := range strukt.GetMembers() {
+		if member.GetDocumentURI() != strukt.GetDocumentURI() || !strukt.GetDocumentRange().HasPosition(member.GetIdRange().Start) {
+			break
+		}
+		members = append(members, member)
+	}
+
+	return entriesOf(members)
+}
+
+func (c *Calculator) structLayout(entries []entry, isUnion bool) (Layout, bool) {
+	result := Layout{Align: 1}
+	end := uint(0)
+
+	for i := 0; i < len(entries); {
+		item, count, ok := c.itemLayout(entries, i)
+		if !ok {
+			return Layout{}, false
+		}
+		i += count
+
+		start := uint(0)
+		if !isUnion {
+			start = alignTo(end, item.Align)
+			if start > end {
+				result.Members = append(result.Members, Member{Offset: end, Size: start - end, Padding: true})
+			}
+		}
+
+		for _, member := range item.Members {
+			member.Offset += start
+			result.Members = append(result.Members, member)
+		}
+		end = max(end, start+item.Size)
+		result.Align = max(result.Align, item.Align)
+	}
+
+	result.Size = alignTo(end, result.Align)
+	if result.Size > end {
+		result.Members = append(result.Members, Member{Offset: end, Size: result.Size - end, Padding: true})
+	}
+
+	return result, true
+}
+
+// itemLayout returns the layout of the member at entries[i], or of the anonymous block starting
+// there, with the number of entries it takes.
+func (c *Calculator) itemLayout(entries []entry, i int) (Layout, int, bool) {
+	current := entries[i]
+
+	if len(current.blocks) > 0 {
+		block := current.blocks[0]
+		count := min(max(block.Members, 1), len(entries)-i)
+		inner := append([]entry{}, entries[i:i+count]...)
+		inner[0].blocks = current.blocks[1:]
+
+		if block.Kind == symbols.MemberBlockBitstruct {
+			found, ok := c.typeLayout(&block.BackingType)
+			found.Members = bitMembers(inner)
+			return found, count, ok
+		}
+
+		found, ok := c.structLayout(inner, block.Kind == symbols.MemberBlockUnion)
+		return found, count, ok
+	}
+
+	member := current.member
+	if substruct := member.Substruct(); member.IsStruct() && substruct.IsSome() {
+		found, ok := c.structLayout(entriesOf(substruct.Get().GetMembers()), substruct.Get().IsUnion())
+		kind := "struct"
+		if substruct.Get().IsUnion() {
+			kind = "union"
+		}
+
+		members := []Member{{Name: member.GetName(), Type: kind, Size: found.Size}}
+		for _, inner := range found.Members {
+			if !inner.Padding {
+				inner.Name = member.GetName() + "." + inner.Name
+			}
+			members = append(members, inner)
+		}
+		found.Members = members
+
+		return found, 1, ok
+	}
+
+	if member.HasBitRange() {
+		// Bits of a bitstruct whose storage is unknown.
+		return Layout{}, 1, false
+	}
+
+	found, ok := c.typeLayout(member.GetType())
+
+	return Layout{
+		Size:    found.Size,
+		Align:   found.Align,
+		Members: []Member{{Name: member.GetName(), Type: member.GetType().String(), Size: found.Size}},
+	}, 1, ok
+}
+
+// bitMembers returns the members of a bitstruct, which share the storage of the bitstruct.
+func bitMembers(entries []entry) []Member {
+	members := []Member{}
+	for _, current := range entries {
+		if current.member.HasBitRange() {
+			members = append(members, Member{
+				Name:     current.member.GetName(),
+				Type:     current.member.GetType().String(),
+				BitRange: option.Some(current.member.GetBitRange()),
+			})
+		}
+	}
+
+	return members
+}
+
+func alignTo(offset uint, align uint) uint {
+	if align <= 1 {
+		return offset
+	}
+
+	return (offset + align - 1) / align * align
+}
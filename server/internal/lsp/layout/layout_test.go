@@ -0,0 +1,129 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	"github.com/stretchr/testify/assert"
+)
+
+func symbolLayout(t *testing.T, source string, name string, target Target) Layout {
+	state := project_state.NewTestProjectState(map[string]string{"app.c3": source})
+
+	symbols := state.SearchByFQN("app::" + name)
+	assert.Equal(t, 1, len(symbols), "Symbol %s not found", name)
+
+	found := NewCalculator(target, state).SymbolLayout(symbols[0])
+	assert.True(t, found.IsSome(), "Layout of %s could not be computed", name)
+
+	return found.Get()
+}
+
+func member(name string, typeName string, offset uint, size uint) Member {
+	return Member{Name: name, Type: typeName, Offset: offset, Size: size}
+}
+
+func padding(offset uint, size uint) Member {
+	return Member{Offset: offset, Size: size, Padding: true}
+}
+
+func TestSymbolLayout_structs(t *testing.T) {
+	t.Run("Members are aligned, with padding between them and at the end", func(t *testing.T) {
+		found := symbolLayout(t, `module app;
+struct Foo {
+	char a;
+	long b;
+	short c;
+}`, "Foo", Targets["linux-x64"])
+
+		assert.Equal(t, uint(24), found.Size)
+		assert.Equal(t, uint(8), found.Align)
+		assert.Equal(t, []Member{
+			member("a", "char", 0, 1),
+			padding(1, 7),
+			member("b", "long", 8, 8),
+			member("c", "short", 16, 2),
+			padding(18, 6),
+		}, found.Members)
+	})
+
+	t.Run("Alignment of 64 bit integers depends on the target", func(t *testing.T) {
+		found := symbolLayout(t, `module app;
+struct Foo {
+	char a;
+	long b;
+	void* c;
+}`, "Foo", Targets["linux-x86"])
+
+		assert.Equal(t, uint(16), found.Size)
+		assert.Equal(t, uint(4), found.Align)
+		assert.Equal(t, uint(4), found.Members[2].Offset)
+	})
+
+	t.Run("Members of unions share their offset", func(t *testing.T) {
+		found := symbolLayout(t, `module app;
+union Value {
+	char c;
+	int[3] numbers;
+	double d;
+}`, "Value", Targets["linux-x64"])
+
+		assert.Equal(t, uint(16), found.Size)
+		assert.Equal(t, []Member{
+			member("c", "char", 0, 1),
+			member("numbers", "int[3]", 0, 12),
+			member("d", "double", 0, 8),
+			padding(12, 4),
+		}, found.Members)
+	})
+
+	t.Run("Anonymous structs and unions are laid out in place", func(t *testing.T) {
+		found := symbolLayout(t, `module app;
+struct Foo {
+	int kind;
+	union {
+		char c;
+		long l;
+	}
+	char[] name;
+}`, "Foo", Targets["linux-x64"])
+
+		assert.Equal(t, uint(32), found.Size)
+		assert.Equal(t, []Member{
+			member("kind", "int", 0, 4),
+			padding(4, 4),
+			member("c", "char", 8, 1),
+			member("l", "long", 8, 8),
+			member("name", "char[]", 16, 16),
+		}, found.Members)
+	})
+
+	t.Run("Members use the layout of the types they refer to", func(t *testing.T) {
+		found := symbolLayout(t, `module app;
+enum Color : char { RED, GREEN }
+typedef Meters = double;
+alias Distance = Meters;
+struct Foo {
+	Color color;
+	Distance distance;
+}`, "Foo", Targets["linux-x64"])
+
+		assert.Equal(t, uint(16), found.Size)
+		assert.Equal(t, uint(8), found.Members[2].Offset)
+	})
+}
+
+func TestSymbolLayout_bitstructs(t *testing.T) {
+	found := symbolLayout(t, `module app;
+bitstruct Flags : ushort {
+	bool enabled : 0;
+	char level : 4..7;
+}`, "Flags", Targets["linux-x64"])
+
+	assert.Equal(t, uint(2), found.Size)
+	assert.Equal(t, []Member{
+		{Name: "enabled", Type: "bool", BitRange: option.Some([2]uint{0, 0})},
+		{Name: "level", Type: "char", BitRange: option.Some([2]uint{4, 7})},
+	}, found.Members)
+}
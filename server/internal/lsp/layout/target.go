@@ -0,0 +1,53 @@
+package layout
+
+import (
+	"strings"
+
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+)
+
+// Target describes the ABI details of a platform that affect the layout of types.
+type Target struct {
+	Name        string
+	PointerSize uint
+	Int64Align  uint // Alignment of long, ulong and double
+}
+
+// Targets supported, named as in `c3c --target`.
+var Targets = map[string]Target{
+	"linux-x64":       {Name: "linux-x64", PointerSize: 8, Int64Align: 8},
+	"macos-x64":       {Name: "macos-x64", PointerSize: 8, Int64Align: 8},
+	"windows-x64":     {Name: "windows-x64", PointerSize: 8, Int64Align: 8},
+	"freebsd-x64":     {Name: "freebsd-x64", PointerSize: 8, Int64Align: 8},
+	"netbsd-x64":      {Name: "netbsd-x64", PointerSize: 8, Int64Align: 8},
+	"openbsd-x64":     {Name: "openbsd-x64", PointerSize: 8, Int64Align: 8},
+	"linux-aarch64":   {Name: "linux-aarch64", PointerSize: 8, Int64Align: 8},
+	"macos-aarch64":   {Name: "macos-aarch64", PointerSize: 8, Int64Align: 8},
+	"windows-aarch64": {Name: "windows-aarch64", PointerSize: 8, Int64Align: 8},
+	"linux-riscv64":   {Name: "linux-riscv64", PointerSize: 8, Int64Align: 8},
+	"wasm64":          {Name: "wasm64", PointerSize: 8, Int64Align: 8},
+	"linux-x86":       {Name: "linux-x86", PointerSize: 4, Int64Align: 4},
+	"windows-x86":     {Name: "windows-x86", PointerSize: 4, Int64Align: 8},
+	"linux-riscv32":   {Name: "linux-riscv32", PointerSize: 4, Int64Align: 8},
+	"wasm32":          {Name: "wasm32", PointerSize: 4, Int64Align: 8},
+}
+
+// TargetByName returns the target named name, or the one of the machine running the server
+// when name is empty or unknown.
+func TargetByName(name string) (Target, bool) {
+	if target, found := Targets[strings.ToLower(name)]; found {
+		return target, true
+	}
+
+	return HostTarget(), name == ""
+}
+
+// HostTarget returns the target of the machine running the server.
+func HostTarget() Target {
+	switch utils.PointerSize() {
+	case 4:
+		return Targets["linux-x86"]
+	default:
+		return Targets["linux-x64"]
+	}
+}
@@ -0,0 +1,76 @@
+package project_state
+
+import (
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+)
+
+// nameIndex indexes module level symbols by their unqualified name, so symbols
+// can be found without knowing the module declaring them.
+type nameIndex struct {
+	symbols   map[string][]symbols.Indexable // Symbols by name
+	documents map[string][]string            // Names declared by each document
+}
+
+func newNameIndex() *nameIndex {
+	return &nameIndex{
+		symbols:   make(map[string][]symbols.Indexable),
+		documents: make(map[string][]string),
+	}
+}
+
+// Insert registers symbol under its name. Methods and other symbols not declared
+// at module level are ignored. As in the fqn index, a symbol replaces a previous
+// one with the same fqn.
+func (i *nameIndex) Insert(symbol symbols.Indexable) {
+	name := symbol.GetName()
+	if symbol.GetFQN() != symbol.GetModuleString()+"::"+name {
+		return
+	}
+
+	candidates := i.symbols[name]
+	for n, candidate := range candidates {
+		if candidate.GetFQN() == symbol.GetFQN() {
+			candidates[n] = symbol
+			i.documents[symbol.GetDocumentURI()] = append(i.documents[symbol.GetDocumentURI()], name)
+			return
+		}
+	}
+
+	i.symbols[name] = append(candidates, symbol)
+	i.documents[symbol.GetDocumentURI()] = append(i.documents[symbol.GetDocumentURI()], name)
+}
+
+// Search returns the symbols named name, whatever their module.
+func (i *nameIndex) Search(name string) []symbols.Indexable {
+	return i.symbols[name]
+}
+
+func (i *nameIndex) ClearByTag(docId string) {
+	i.ClearByTags([]string{docId})
+}
+
+// ClearByTags removes the symbols declared in any of the given documents.
+func (i *nameIndex) ClearByTags(docIds []string) {
+	cleared := make(map[string]bool, len(docIds))
+	for _, docId := range docIds {
+		cleared[docId] = true
+	}
+
+	for _, docId := range docIds {
+		for _, name := range i.documents[docId] {
+			kept := []symbols.Indexable{}
+			for _, symbol := range i.symbols[name] {
+				if !cleared[symbol.GetDocumentURI()] {
+					kept = append(kept, symbol)
+				}
+			}
+
+			if len(kept) == 0 {
+				delete(i.symbols, name)
+			} else {
+				i.symbols[name] = kept
+			}
+		}
+		delete(i.documents, docId)
+	}
+}
@@ -0,0 +1,54 @@
+package project_state
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/stretchr/testify/assert"
+)
+
+func fqns(items []symbols.Indexable) []string {
+	result := []string{}
+	for _, item := range items {
+		result = append(result, item.GetFQN())
+	}
+
+	return result
+}
+
+func TestNameIndex_Search(t *testing.T) {
+	index := newNameIndex()
+	index.Insert(symbols.NewFunctionBuilder("printn", symbols.NewTypeFromString("void", "std::io"), "std::io", "io").Build())
+	index.Insert(symbols.NewFunctionBuilder("printn", symbols.NewTypeFromString("void", "app::log"), "app::log", "log").Build())
+	index.Insert(symbols.NewFunctionBuilder("printn", symbols.NewTypeFromString("void", "app"), "app", "app").WithTypeIdentifier("Writer").Build())
+
+	assert.ElementsMatch(t, []string{"std::io::printn", "app::log::printn"}, fqns(index.Search("printn")), "Methods are not indexed")
+	assert.Empty(t, index.Search("print"))
+}
+
+func TestNameIndex_replaces_symbols_with_same_fqn(t *testing.T) {
+	index := newNameIndex()
+	index.Insert(symbols.NewFunctionBuilder("main", symbols.NewTypeFromString("void", "app"), "app", "a").Build())
+	index.Insert(symbols.NewFunctionBuilder("main", symbols.NewTypeFromString("void", "app"), "app", "b").Build())
+
+	result := index.Search("main")
+
+	assert.Len(t, result, 1)
+	assert.Equal(t, "b", result[0].GetDocumentURI())
+}
+
+func TestNameIndex_ClearByTags(t *testing.T) {
+	index := newNameIndex()
+	index.Insert(symbols.NewFunctionBuilder("printn", symbols.NewTypeFromString("void", "std::io"), "std::io", "io").Build())
+	index.Insert(symbols.NewFunctionBuilder("printn", symbols.NewTypeFromString("void", "app::log"), "app::log", "log").Build())
+	index.Insert(symbols.NewStructBuilder("Writer", "app::log", "log").Build())
+
+	index.ClearByTag("log")
+
+	assert.Equal(t, []string{"std::io::printn"}, fqns(index.Search("printn")))
+	assert.Empty(t, index.Search("Writer"))
+
+	index.ClearByTags([]string{"io"})
+
+	assert.Empty(t, index.Search("printn"))
+}
@@ -2,9 +2,12 @@ package project_state
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
+	"github.com/pherrymason/c3-lsp/internal/lsp/index_cache"
+	"github.com/pherrymason/c3-lsp/internal/lsp/reference_index"
 	trie "github.com/pherrymason/c3-lsp/internal/lsp/symbol_trie"
 	"github.com/pherrymason/c3-lsp/pkg/document"
 	"github.com/pherrymason/c3-lsp/pkg/fs"
@@ -19,11 +22,17 @@ import (
 
 // ProjectState is the central state manager that holds all parsed information.
 type ProjectState struct {
-	documents    *document.DocumentStore    // Active documents store
-	symbolsTable symbols_table.SymbolsTable // Source of truth - hierarchical storage (Document → Module → Symbols)
-	fqnIndex     *trie.Trie                 // Fast lookup index - trie-based Full Qualified Name search (module::symbol)
+	documents    *document.DocumentStore         // Active documents store
+	symbolsTable symbols_table.SymbolsTable      // Source of truth - hierarchical storage (Document → Module → Symbols)
+	fqnIndex     *trie.Trie                      // Fast lookup index - trie-based Full Qualified Name search (module::symbol)
+	nameIndex    *nameIndex                      // Module level symbols by unqualified name
+	references   *reference_index.ReferenceIndex // Identifier occurrences by name, used to find references of a symbol
 
-	diagnostics map[string][]protocol.Diagnostic
+	stdlib stdlibState // Currently loaded stdlib
+
+	diagnostics       map[string][]protocol.Diagnostic // Reported by c3c
+	serverDiagnostics map[string][]protocol.Diagnostic // Found by the server itself: syntax errors and lints
+	openDocuments     map[string]bool                  // Documents being edited in the client
 
 	logger       commonlog.Logger
 	debugEnabled bool
@@ -34,11 +43,15 @@ type ProjectState struct {
 
 func NewProjectState(logger commonlog.Logger, languageVersion option.Option[string], debug bool) ProjectState {
 	projectState := ProjectState{
-		documents:     document.NewDocumentStore(fs.FileStorage{}),
-		symbolsTable:  symbols_table.NewSymbolsTable(),
-		fqnIndex:      trie.NewTrie(),
-		diagnostics:   make(map[string][]protocol.Diagnostic),
-		documentLocks: make(map[string]*sync.Mutex),
+		documents:         document.NewDocumentStore(fs.FileStorage{}),
+		symbolsTable:      symbols_table.NewSymbolsTable(),
+		fqnIndex:          trie.NewTrie(),
+		nameIndex:         newNameIndex(),
+		references:        reference_index.NewReferenceIndex(),
+		diagnostics:       make(map[string][]protocol.Diagnostic),
+		serverDiagnostics: make(map[string][]protocol.Diagnostic),
+		openDocuments:     make(map[string]bool),
+		documentLocks:     make(map[string]*sync.Mutex),
 
 		logger:       logger,
 		debugEnabled: debug,
@@ -72,15 +85,103 @@ func (s *ProjectState) SearchByFQN(query string) []symbols.Indexable {
 	return s.fqnIndex.Search(query)
 }
 
+// SearchByName returns the module level symbols named name, from any module.
+func (s *ProjectState) SearchByName(name string) []symbols.Indexable {
+	return s.nameIndex.Search(name)
+}
+
+// ResolveName returns the module level symbol accepted by accept named name, which can be
+// qualified with its module path (`io::Writer`). Symbols of module are preferred.
+func (s *ProjectState) ResolveName(name string, module string, accept func(symbols.Indexable) bool) symbols.Indexable {
+	path := ""
+	if i := strings.LastIndex(name, "::"); i >= 0 {
+		path = name[:i]
+		name = name[i+2:]
+	}
+
+	var found symbols.Indexable
+	for _, symbol := range s.nameIndex.Search(name) {
+		if !accept(symbol) {
+			continue
+		}
+
+		symbolModule := symbol.GetModuleString()
+		if path != "" && symbolModule != path && !strings.HasSuffix(symbolModule, "::"+path) {
+			continue
+		}
+		if symbolModule == module {
+			return symbol
+		}
+		found = symbol
+	}
+
+	return found
+}
+
+// SearchFuzzy returns the indexed symbols whose name fuzzy matches query.
+func (s *ProjectState) SearchFuzzy(query string) []trie.FuzzyResult {
+	return s.fqnIndex.FuzzySearch(query)
+}
+
+// SearchReferences returns every occurrence of an identifier with the given name.
+// Occurrences are not resolved, so they may refer to different symbols sharing the same name.
+func (s *ProjectState) SearchReferences(name string) []reference_index.Reference {
+	return s.references.Search(name)
+}
+
 func (s *ProjectState) GetDocumentDiagnostics() map[string][]protocol.Diagnostic {
 	return s.diagnostics
 }
 
+type stdlibState struct {
+	docId      string
+	version    string
+	c3cLibPath string
+	documents  []string // Source files stdlib symbols belong to
+}
+
+// SetLanguageVersion loads the stdlib symbols of languageVersion, replacing the
+// ones previously loaded. Nothing is done if that same stdlib is already loaded.
 func (s *ProjectState) SetLanguageVersion(languageVersion string, c3cLibPath string) {
-	stdlibModules := LoadStdLib(s.logger, languageVersion, c3cLibPath)
+	if s.stdlib.docId != "" && s.stdlib.version == languageVersion && s.stdlib.c3cLibPath == c3cLibPath {
+		return
+	}
+
+	s.unloadStdlib()
+
+	stdlibModules := LoadSharedStdLib(s.logger, languageVersion, c3cLibPath)
 	s.indexParsedSymbols(stdlibModules, stdlibModules.DocId())
 
-	s.symbolsTable.Register(stdlibModules, symbols_table.PendingToResolve{})
+	s.symbolsTable.Register(stdlibModules, symbols_table.NewPendingToResolve())
+
+	documents := []string{stdlibModules.DocId()}
+	for _, module := range stdlibModules.Modules() {
+		documents = append(documents, module.GetDocumentURI())
+	}
+	s.stdlib = stdlibState{
+		docId:      stdlibModules.DocId(),
+		version:    languageVersion,
+		c3cLibPath: c3cLibPath,
+		documents:  documents,
+	}
+}
+
+// ReleaseStdlib unloads the stdlib symbols of a project that is no longer used,
+// so they can be freed once no other project uses them.
+func (s *ProjectState) ReleaseStdlib() {
+	s.unloadStdlib()
+}
+
+func (s *ProjectState) unloadStdlib() {
+	if s.stdlib.docId == "" {
+		return
+	}
+
+	s.symbolsTable.DeleteDocument(s.stdlib.docId)
+	s.fqnIndex.ClearByTags(s.stdlib.documents)
+	s.nameIndex.ClearByTags(s.stdlib.documents)
+	ReleaseSharedStdLib(s.stdlib.version, s.stdlib.c3cLibPath)
+	s.stdlib = stdlibState{}
 }
 
 func (s *ProjectState) SetDocumentDiagnostics(docId string, diagnostics []protocol.Diagnostic) {
@@ -96,6 +197,26 @@ func (s *ProjectState) RemoveDocumentDiagnostics(docId string) {
 	delete(s.diagnostics, docId)
 }
 
+// SetDocumentServerDiagnostics replaces the diagnostics the server found in docId. They are kept
+// apart from c3c diagnostics, so each source can be updated without clobbering the other.
+func (s *ProjectState) SetDocumentServerDiagnostics(docId string, diagnostics []protocol.Diagnostic) {
+	if len(diagnostics) == 0 {
+		delete(s.serverDiagnostics, docId)
+		return
+	}
+
+	s.serverDiagnostics[docId] = diagnostics
+}
+
+// GetMergedDocumentDiagnostics returns all the diagnostics of docId: the ones found by the server and the ones reported by c3c.
+func (s *ProjectState) GetMergedDocumentDiagnostics(docId string) []protocol.Diagnostic {
+	merged := []protocol.Diagnostic{}
+	merged = append(merged, s.serverDiagnostics[docId]...)
+	merged = append(merged, s.diagnostics[docId]...)
+
+	return merged
+}
+
 func (s *ProjectState) LockDocument(docId string) func() {
 	docLock := s.getDocumentLock(docId)
 	docLock.Lock()
@@ -125,6 +246,68 @@ func (s *ProjectState) RefreshDocumentIdentifiers(doc *document.Document, parser
 	s.documents.Set(doc)
 	s.symbolsTable.Register(parsedModules, pendingTypes)
 	s.indexParsedSymbols(parsedModules, doc.URI)
+	s.indexReferences(doc)
+}
+
+// ParsedDocument holds the symbols extracted from a document, ready to be registered in the state.
+type ParsedDocument struct {
+	Document   *document.Document
+	Modules    symbols_table.UnitModules
+	Pending    symbols_table.PendingToResolve
+	References []reference_index.Reference
+}
+
+// ParseDocument extracts the symbols of the document docId with content without
+// modifying the state, so several documents can be parsed concurrently as long
+// as each goroutine uses its own parser. When a cache is given, symbols are
+// loaded from it if the document did not change since it was cached, and its
+// syntax tree is left to be parsed once needed. Otherwise they are cached for next time.
+func (s *ProjectState) ParseDocument(docId string, content string, parser *parser.Parser, cache option.Option[*index_cache.Cache]) ParsedDocument {
+	if cache.IsSome() {
+		entry, err := cache.Get().Load(docId, content)
+		if err == nil {
+			doc := document.NewDocumentWithoutTree(docId, content)
+			parsedModules := symbols_table.NewParsedModules(&doc.URI)
+			for _, module := range entry.Modules {
+				parsedModules.RegisterModule(module)
+			}
+
+			return ParsedDocument{
+				Document:   &doc,
+				Modules:    parsedModules,
+				Pending:    symbols_table.NewPendingToResolveFromModules(parsedModules),
+				References: entry.References,
+			}
+		}
+	}
+
+	doc := document.NewDocumentFromString(docId, content)
+	parsedModules, pendingTypes := parser.ParseSymbols(&doc)
+	references := collectReferences(&doc)
+
+	// Cache before registering: registering resolves types against other documents.
+	if cache.IsSome() {
+		if err := cache.Get().Save(doc.URI, doc.SourceCode.Text, parsedModules, references); err != nil {
+			s.logger.Warningf("Failed to cache symbols of %s: %v", doc.URI, err)
+		}
+	}
+
+	return ParsedDocument{
+		Document:   &doc,
+		Modules:    parsedModules,
+		Pending:    pendingTypes,
+		References: references,
+	}
+}
+
+// RegisterParsedDocument stores a document parsed with ParseDocument in the state.
+func (s *ProjectState) RegisterParsedDocument(parsed ParsedDocument) {
+	docId := parsed.Document.URI
+
+	s.documents.Set(parsed.Document)
+	s.symbolsTable.Register(parsed.Modules, parsed.Pending)
+	s.indexParsedSymbols(parsed.Modules, docId)
+	s.references.IndexDocument(docId, parsed.References)
 }
 
 func (s *ProjectState) DeleteDocument(docId string) {
@@ -133,11 +316,15 @@ func (s *ProjectState) DeleteDocument(docId string) {
 
 	s.symbolsTable.DeleteDocument(docId)
 	s.fqnIndex.ClearByTag(docId)
+	s.nameIndex.ClearByTag(docId)
+	s.references.ClearByTag(docId)
 }
 
 func (s *ProjectState) RenameDocument(oldDocId string, newDocId string) {
 	s.fqnIndex.ClearByTag(oldDocId)
+	s.nameIndex.ClearByTag(oldDocId)
 	s.symbolsTable.RenameDocument(oldDocId, newDocId)
+	s.references.RenameDocument(oldDocId, newDocId)
 
 	x := s.symbolsTable.GetByDoc(newDocId)
 	s.indexParsedSymbols(*x, newDocId)
@@ -155,40 +342,92 @@ func (s *ProjectState) UpdateDocument(docURI protocol.DocumentUri, changes []int
 	s.RefreshDocumentIdentifiers(doc, parser)
 }
 
+// MarkDocumentOpened flags docId as opened in the client. While open, the
+// client owns its contents, so changes done on disk must not be indexed.
+func (s *ProjectState) MarkDocumentOpened(docId string) {
+	s.openDocuments[docId] = true
+}
+
+func (s *ProjectState) IsDocumentOpen(docId string) bool {
+	return s.openDocuments[docId]
+}
+
+// OpenDocuments returns the ids of the documents opened in the client, sorted.
+func (s *ProjectState) OpenDocuments() []string {
+	docIds := []string{}
+	for docId := range s.openDocuments {
+		docIds = append(docIds, docId)
+	}
+	sort.Strings(docIds)
+
+	return docIds
+}
+
 func (s *ProjectState) CloseDocument(uri protocol.DocumentUri) {
 	docId := utils.NormalizePath(uri)
 	s.documents.Close(docId)
+	delete(s.openDocuments, docId)
 }
 
 func (s *ProjectState) indexParsedSymbols(parsedModules symbols_table.UnitModules, docId string) {
 	s.fqnIndex.ClearByTag(docId)
+	s.nameIndex.ClearByTag(docId)
 
 	// Register in the index, the root elements
 	for _, module := range parsedModules.Modules() {
 		for _, fun := range module.ChildrenFunctions {
-			s.fqnIndex.Insert(fun)
+			s.index(fun)
 		}
 		for _, variable := range module.Variables {
-			s.fqnIndex.Insert(variable)
+			s.index(variable)
 		}
 		for _, enum := range module.Enums {
-			s.fqnIndex.Insert(enum)
+			s.index(enum)
 		}
 		for _, fault := range module.Faults {
-			s.fqnIndex.Insert(fault)
+			s.index(fault)
 		}
 		for _, strukt := range module.Structs {
-			s.fqnIndex.Insert(strukt)
+			s.index(strukt)
+		}
+		for _, bitstruct := range module.Bitstructs {
+			s.index(bitstruct)
+		}
+		for _, _interface := range module.Interfaces {
+			s.index(_interface)
 		}
 		for _, def := range module.Defs {
-			s.fqnIndex.Insert(def)
+			s.index(def)
 		}
 		for _, distinct := range module.Distincts {
-			s.fqnIndex.Insert(distinct)
+			s.index(distinct)
 		}
 	}
 }
 
+// index registers symbol in both the fqn and the name indexes.
+func (s *ProjectState) index(symbol symbols.Indexable) {
+	s.fqnIndex.Insert(symbol)
+	s.nameIndex.Insert(symbol)
+}
+
+func (s *ProjectState) indexReferences(doc *document.Document) {
+	if doc.SyntaxTree() == nil {
+		s.references.ClearByTag(doc.URI)
+		return
+	}
+
+	s.references.IndexDocument(doc.URI, collectReferences(doc))
+}
+
+func collectReferences(doc *document.Document) []reference_index.Reference {
+	if doc.SyntaxTree() == nil {
+		return nil
+	}
+
+	return reference_index.CollectReferences(doc.URI, doc.SyntaxTree().RootNode(), []byte(doc.SourceCode.Text))
+}
+
 func (s *ProjectState) debug(message string, debugger FindDebugger) {
 	if !s.debugEnabled {
 		return
@@ -38,3 +38,29 @@ func TestRefreshDocumentIdentifiers_should_clear_cached_stuff_test(t *testing.T)
 	result = s.fqnIndex.Search("app::something_new.main")
 	assert.Equal(t, 1, len(result))
 }
+
+func TestRefreshDocumentIdentifiers_should_index_references(t *testing.T) {
+	var logger commonlog.Logger
+	s := NewProjectState(logger, option.Some("dummy"), false)
+	p := parser.NewParser(logger)
+	doc := document.NewDocumentFromString(
+		"doc-id",
+		`module app;
+		fn void foo() {}
+		fn void main() { foo(); foo(); }
+		`)
+	s.RefreshDocumentIdentifiers(&doc, &p)
+	assert.Equal(t, 3, len(s.SearchReferences("foo")))
+
+	doc = document.NewDocumentFromString(
+		"doc-id",
+		`module app;
+		fn void foo() {}
+		fn void main() {}
+		`)
+	s.RefreshDocumentIdentifiers(&doc, &p)
+	assert.Equal(t, 1, len(s.SearchReferences("foo")))
+
+	s.DeleteDocument("doc-id")
+	assert.Equal(t, 0, len(s.SearchReferences("foo")))
+}
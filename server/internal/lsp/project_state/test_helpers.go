@@ -0,0 +1,23 @@
+package project_state
+
+import (
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	p "github.com/pherrymason/c3-lsp/pkg/parser"
+	"github.com/tliron/commonlog"
+)
+
+// NewTestProjectState returns a project state holding sources, keyed by their document id.
+// Used by tests of the features built on top of the project state.
+func NewTestProjectState(sources map[string]string) *ProjectState {
+	logger := commonlog.MockLogger{}
+	state := NewProjectState(logger, option.Some("dummy"), false)
+	parser := p.NewParser(logger)
+
+	for docId, source := range sources {
+		doc := document.NewDocument(docId, source)
+		state.RefreshDocumentIdentifiers(&doc, &parser)
+	}
+
+	return &state
+}
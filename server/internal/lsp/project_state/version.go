@@ -1,6 +1,8 @@
 package project_state
 
 import (
+	"sync"
+
 	"github.com/pherrymason/c3-lsp/internal/lsp/stdlib"
 	"github.com/pherrymason/c3-lsp/pkg/symbols_table"
 	"github.com/tliron/commonlog"
@@ -26,3 +28,58 @@ func LoadStdLib(logger commonlog.Logger, version string, c3cLibPath string) symb
 	logger.Infof("Loading stdlib for C3 version %s...", version)
 	return stdlib.LoadStdlib(logger, version, c3cLibPath)
 }
+
+type loadedStdlibKey struct {
+	version    string
+	c3cLibPath string
+}
+
+type loadedStdlib struct {
+	modules symbols_table.UnitModules
+	users   int // Number of projects using modules
+}
+
+// Stdlib symbols already loaded, shared between all the projects using the same stdlib.
+// Entries are dropped once the last project using them releases them.
+var (
+	loadedStdlibsMu sync.Mutex
+	loadedStdlibs   = map[loadedStdlibKey]*loadedStdlib{}
+)
+
+// LoadSharedStdLib works like LoadStdLib, but the stdlib is only loaded once for a
+// given version and path: next calls return the same symbols until every caller
+// released them with ReleaseSharedStdLib.
+// Shared symbols are registered without types pending to resolve, so resolving
+// the types of a project never modifies them.
+func LoadSharedStdLib(logger commonlog.Logger, version string, c3cLibPath string) symbols_table.UnitModules {
+	loadedStdlibsMu.Lock()
+	defer loadedStdlibsMu.Unlock()
+
+	key := loadedStdlibKey{version: version, c3cLibPath: c3cLibPath}
+	loaded, found := loadedStdlibs[key]
+	if !found {
+		loaded = &loadedStdlib{modules: LoadStdLib(logger, version, c3cLibPath)}
+		loadedStdlibs[key] = loaded
+	}
+	loaded.users++
+
+	return loaded.modules
+}
+
+// ReleaseSharedStdLib tells a project no longer uses the stdlib obtained with
+// LoadSharedStdLib, so it can be freed when no other project uses it.
+func ReleaseSharedStdLib(version string, c3cLibPath string) {
+	loadedStdlibsMu.Lock()
+	defer loadedStdlibsMu.Unlock()
+
+	key := loadedStdlibKey{version: version, c3cLibPath: c3cLibPath}
+	loaded, found := loadedStdlibs[key]
+	if !found {
+		return
+	}
+
+	loaded.users--
+	if loaded.users <= 0 {
+		delete(loadedStdlibs, key)
+	}
+}
@@ -0,0 +1,36 @@
+package project_state
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/pkg/symbols_table"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReleaseSharedStdLib_frees_stdlib_once_unused(t *testing.T) {
+	key := loadedStdlibKey{version: "test", c3cLibPath: "/c3c/lib"}
+	docId := "stdlib"
+	loadedStdlibs[key] = &loadedStdlib{modules: symbols_table.NewParsedModules(&docId), users: 2}
+	defer delete(loadedStdlibs, key)
+
+	ReleaseSharedStdLib("test", "/c3c/lib")
+	assert.Contains(t, loadedStdlibs, key, "Still used by another project")
+
+	ReleaseSharedStdLib("test", "/c3c/lib")
+	assert.NotContains(t, loadedStdlibs, key)
+
+	ReleaseSharedStdLib("test", "/c3c/lib")
+	assert.NotContains(t, loadedStdlibs, key, "Releasing an unknown stdlib does nothing")
+}
+
+func TestLoadSharedStdLib_shares_loaded_stdlib(t *testing.T) {
+	key := loadedStdlibKey{version: "test", c3cLibPath: "/c3c/lib"}
+	docId := "stdlib"
+	loadedStdlibs[key] = &loadedStdlib{modules: symbols_table.NewParsedModules(&docId), users: 1}
+	defer delete(loadedStdlibs, key)
+
+	modules := LoadSharedStdLib(nil, "test", "/c3c/lib")
+
+	assert.Equal(t, "stdlib", modules.DocId())
+	assert.Equal(t, 2, loadedStdlibs[key].users)
+}
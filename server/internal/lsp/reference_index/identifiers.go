@@ -0,0 +1,67 @@
+package reference_index
+
+import (
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Node types of the C3 grammar that hold an identifier that can refer to a symbol.
+var identifierNodeTypes = map[string]bool{
+	"ident":          true,
+	"const_ident":    true,
+	"type_ident":     true,
+	"at_ident":       true,
+	"hash_ident":     true,
+	"ct_ident":       true,
+	"ct_const_ident": true,
+	"ct_type_ident":  true,
+	"at_type_ident":  true,
+}
+
+func IsIdentifierNode(node *sitter.Node) bool {
+	return identifierNodeTypes[node.Type()]
+}
+
+// LastIdentifier returns the last identifier found in node, which names what node refers to.
+func LastIdentifier(node *sitter.Node) *sitter.Node {
+	if IsIdentifierNode(node) {
+		return node
+	}
+
+	for i := int(node.NamedChildCount()) - 1; i >= 0; i-- {
+		if found := LastIdentifier(node.NamedChild(i)); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// CollectReferences walks the syntax tree and returns every identifier occurrence found.
+func CollectReferences(docId string, root *sitter.Node, sourceCode []byte) []Reference {
+	var references []Reference
+	if root == nil {
+		return references
+	}
+
+	pending := []*sitter.Node{root}
+	for len(pending) > 0 {
+		node := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if IsIdentifierNode(node) {
+			references = append(references, Reference{
+				Name:  node.Content(sourceCode),
+				DocId: docId,
+				Range: symbols.NewRangeFromTreeSitterPositions(node.StartPoint(), node.EndPoint()),
+			})
+			continue
+		}
+
+		for i := int(node.NamedChildCount()) - 1; i >= 0; i-- {
+			pending = append(pending, node.NamedChild(i))
+		}
+	}
+
+	return references
+}
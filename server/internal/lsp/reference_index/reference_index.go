@@ -0,0 +1,104 @@
+package reference_index
+
+import (
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+)
+
+// Reference is a single occurrence of an identifier inside a document.
+// It is not resolved: it only tells where a given name is written, the
+// symbol it refers to is calculated on demand through the search engine.
+type Reference struct {
+	Name  string        `json:"name"`
+	DocId string        `json:"docId"`
+	Range symbols.Range `json:"range"`
+}
+
+// ReferenceIndex stores every identifier occurrence found in the indexed
+// documents, grouped by name, so all the places where a symbol may be
+// referenced can be found without traversing every syntax tree.
+type ReferenceIndex struct {
+	byName     map[string]map[string][]symbols.Range // name -> docId -> ranges
+	namesByDoc map[string][]string                   // docId -> names present in doc
+}
+
+func NewReferenceIndex() *ReferenceIndex {
+	return &ReferenceIndex{
+		byName:     make(map[string]map[string][]symbols.Range),
+		namesByDoc: make(map[string][]string),
+	}
+}
+
+// IndexDocument replaces all the references registered for docId.
+func (r *ReferenceIndex) IndexDocument(docId string, references []Reference) {
+	r.ClearByTag(docId)
+
+	names := []string{}
+	for _, ref := range references {
+		docs, ok := r.byName[ref.Name]
+		if !ok {
+			docs = make(map[string][]symbols.Range)
+			r.byName[ref.Name] = docs
+		}
+
+		if _, seen := docs[docId]; !seen {
+			names = append(names, ref.Name)
+		}
+		docs[docId] = append(docs[docId], ref.Range)
+	}
+
+	if len(names) > 0 {
+		r.namesByDoc[docId] = names
+	}
+}
+
+// ClearByTag removes every reference registered for docId.
+func (r *ReferenceIndex) ClearByTag(docId string) {
+	for _, name := range r.namesByDoc[docId] {
+		docs := r.byName[name]
+		delete(docs, docId)
+		if len(docs) == 0 {
+			delete(r.byName, name)
+		}
+	}
+
+	delete(r.namesByDoc, docId)
+}
+
+func (r *ReferenceIndex) RenameDocument(oldDocId string, newDocId string) {
+	names, ok := r.namesByDoc[oldDocId]
+	if !ok {
+		return
+	}
+
+	r.ClearByTag(newDocId)
+	for _, name := range names {
+		docs := r.byName[name]
+		docs[newDocId] = docs[oldDocId]
+		delete(docs, oldDocId)
+	}
+
+	r.namesByDoc[newDocId] = names
+	delete(r.namesByDoc, oldDocId)
+}
+
+// Search returns all the occurrences of name in every indexed document.
+func (r *ReferenceIndex) Search(name string) []Reference {
+	var results []Reference
+	for docId, ranges := range r.byName[name] {
+		for _, rng := range ranges {
+			results = append(results, Reference{Name: name, DocId: docId, Range: rng})
+		}
+	}
+
+	return results
+}
+
+// SearchInDocument returns the occurrences of name found in docId.
+func (r *ReferenceIndex) SearchInDocument(name string, docId string) []Reference {
+	var results []Reference
+	for _, rng := range r.byName[name][docId] {
+		results = append(results, Reference{Name: name, DocId: docId, Range: rng})
+	}
+
+	return results
+}
@@ -0,0 +1,66 @@
+package reference_index
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferenceIndex(t *testing.T) {
+	build := func() *ReferenceIndex {
+		index := NewReferenceIndex()
+		index.IndexDocument("a", []Reference{
+			{Name: "foo", DocId: "a", Range: symbols.NewRange(0, 3, 0, 6)},
+			{Name: "bar", DocId: "a", Range: symbols.NewRange(1, 0, 1, 3)},
+			{Name: "foo", DocId: "a", Range: symbols.NewRange(2, 4, 2, 7)},
+		})
+		index.IndexDocument("b", []Reference{
+			{Name: "foo", DocId: "b", Range: symbols.NewRange(5, 0, 5, 3)},
+		})
+
+		return index
+	}
+
+	t.Run("Search returns occurrences in all documents", func(t *testing.T) {
+		index := build()
+
+		assert.Equal(t, 3, len(index.Search("foo")))
+		assert.Equal(t, 1, len(index.Search("bar")))
+		assert.Equal(t, 0, len(index.Search("unknown")))
+	})
+
+	t.Run("SearchInDocument limits results to document", func(t *testing.T) {
+		index := build()
+
+		result := index.SearchInDocument("foo", "b")
+		assert.Equal(t, 1, len(result))
+		assert.Equal(t, symbols.NewRange(5, 0, 5, 3), result[0].Range)
+	})
+
+	t.Run("Reindexing a document replaces its references", func(t *testing.T) {
+		index := build()
+		index.IndexDocument("a", []Reference{
+			{Name: "bar", DocId: "a", Range: symbols.NewRange(3, 0, 3, 3)},
+		})
+
+		assert.Equal(t, 1, len(index.Search("foo")))
+		assert.Equal(t, symbols.NewRange(3, 0, 3, 3), index.Search("bar")[0].Range)
+	})
+
+	t.Run("ClearByTag removes document references", func(t *testing.T) {
+		index := build()
+		index.ClearByTag("a")
+
+		assert.Equal(t, 1, len(index.Search("foo")))
+		assert.Equal(t, 0, len(index.Search("bar")))
+	})
+
+	t.Run("RenameDocument moves references to new document", func(t *testing.T) {
+		index := build()
+		index.RenameDocument("b", "c")
+
+		assert.Equal(t, 0, len(index.SearchInDocument("foo", "b")))
+		assert.Equal(t, 1, len(index.SearchInDocument("foo", "c")))
+	})
+}
@@ -422,6 +422,17 @@ func (s *Search) findInParentSymbols(searchParams search_params.SearchParams, pr
 				symbolsHierarchy = append(symbolsHierarchy, elm)
 				state.Advance()
 			}
+
+		case *symbols.Interface:
+			// Values typed as an interface can only access the methods it declares.
+			_interface, _ := elm.(*symbols.Interface)
+			method := _interface.GetMethod(state.GetNextSymbol().Text())
+			if method == nil {
+				return NewSearchResultEmpty(trackedModules)
+			}
+			elm = method
+			symbolsHierarchy = append(symbolsHierarchy, elm)
+			state.Advance()
 		}
 
 		if state.IsEnd() {
@@ -305,6 +305,22 @@ func TestProjectState_findClosestSymbolDeclaration_access_path(t *testing.T) {
 		assert.True(t, symbolOption.IsNone(), "Struct method should not be found")
 	})
 
+	t.Run("Should find interface method of a value typed as the interface", func(t *testing.T) {
+		symbolOption := SearchUnderCursor_AccessPath(
+			`module app;
+			interface Shape {
+				fn float area();
+			}
+			fn void print(Shape shape) {
+				shape.ar|||ea();
+			}`,
+		)
+
+		assert.False(t, symbolOption.IsNone(), "Element not found")
+		fun := symbolOption.Get().(*idx.Function)
+		assert.Equal(t, "area", fun.GetName())
+	})
+
 	t.Run("Asking the selectedSymbol information in the very same declaration, should resolve to the correct selectedSymbol. Even if there is another selectedSymbol with same name in a different file.", func(t *testing.T) {
 		t.Skip()
 		// Should only resolve in very same module, unless module B is imported.
@@ -0,0 +1,59 @@
+package semantic_tokens
+
+import (
+	"strconv"
+	"sync"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+type result struct {
+	id   string
+	data []protocol.UInteger
+}
+
+// Cache keeps the last tokens sent for each document, so following requests
+// can be answered with a delta.
+type Cache struct {
+	mu      sync.Mutex
+	results map[string]result
+	lastId  int
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		results: make(map[string]result),
+	}
+}
+
+// Store saves data as the last result sent for docId and returns its result id.
+func (c *Cache) Store(docId string, data []protocol.UInteger) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastId++
+	id := strconv.Itoa(c.lastId)
+	c.results[docId] = result{id: id, data: data}
+
+	return id
+}
+
+// Get returns the data sent for docId only if resultId is still the last one sent.
+func (c *Cache) Get(docId string, resultId string) ([]protocol.UInteger, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stored, ok := c.results[docId]
+	if !ok || stored.id != resultId {
+		return nil, false
+	}
+
+	return stored.data, true
+}
+
+func (c *Cache) Forget(docId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.results, docId)
+}
@@ -0,0 +1,67 @@
+package semantic_tokens
+
+import (
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+type TokenType int
+
+// Token types, in the same order they are declared in the legend.
+const (
+	TypeNamespace TokenType = iota
+	TypeType
+	TypeStruct
+	TypeInterface
+	TypeEnum
+	TypeTypeParameter
+	TypeParameter
+	TypeVariable
+	TypeProperty
+	TypeEnumMember
+	TypeFunction
+	TypeMethod
+	TypeMacro
+	TypeTypeAlias
+	TypeFault
+)
+
+var tokenTypes = []string{
+	string(protocol.SemanticTokenTypeNamespace),
+	string(protocol.SemanticTokenTypeType),
+	string(protocol.SemanticTokenTypeStruct),
+	string(protocol.SemanticTokenTypeInterface),
+	string(protocol.SemanticTokenTypeEnum),
+	string(protocol.SemanticTokenTypeTypeParameter),
+	string(protocol.SemanticTokenTypeParameter),
+	string(protocol.SemanticTokenTypeVariable),
+	string(protocol.SemanticTokenTypeProperty),
+	string(protocol.SemanticTokenTypeEnumMember),
+	string(protocol.SemanticTokenTypeFunction),
+	string(protocol.SemanticTokenTypeMethod),
+	string(protocol.SemanticTokenTypeMacro),
+	// C3 specific types. Clients not knowing them fall back to no highlighting.
+	"typeAlias",
+	"fault",
+}
+
+// Modifiers are bit flags: the bit position is the index of the modifier in the legend.
+const (
+	ModifierDeclaration uint = 1 << iota
+	ModifierReadonly
+	ModifierDeprecated
+	ModifierDefaultLibrary
+)
+
+var tokenModifiers = []string{
+	string(protocol.SemanticTokenModifierDeclaration),
+	string(protocol.SemanticTokenModifierReadonly),
+	string(protocol.SemanticTokenModifierDeprecated),
+	string(protocol.SemanticTokenModifierDefaultLibrary),
+}
+
+func Legend() protocol.SemanticTokensLegend {
+	return protocol.SemanticTokensLegend{
+		TokenTypes:     tokenTypes,
+		TokenModifiers: tokenModifiers,
+	}
+}
@@ -0,0 +1,77 @@
+package semantic_tokens
+
+import (
+	"sort"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Token is a classified piece of source code. Tokens can't span multiple lines.
+type Token struct {
+	Line      uint
+	Character uint
+	Length    uint
+	Type      TokenType
+	Modifiers uint
+}
+
+// Encode converts tokens to the relative integer encoding defined by the LSP
+// specification: each token is stored as 5 integers
+// [deltaLine, deltaStartChar, length, tokenType, tokenModifiers].
+func Encode(tokens []Token) []protocol.UInteger {
+	sorted := make([]Token, len(tokens))
+	copy(sorted, tokens)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Line != sorted[j].Line {
+			return sorted[i].Line < sorted[j].Line
+		}
+
+		return sorted[i].Character < sorted[j].Character
+	})
+
+	data := make([]protocol.UInteger, 0, len(sorted)*5)
+	var prevLine, prevCharacter uint
+	for _, token := range sorted {
+		deltaCharacter := token.Character
+		if token.Line == prevLine {
+			deltaCharacter -= prevCharacter
+		}
+
+		data = append(data,
+			protocol.UInteger(token.Line-prevLine),
+			protocol.UInteger(deltaCharacter),
+			protocol.UInteger(token.Length),
+			protocol.UInteger(token.Type),
+			protocol.UInteger(token.Modifiers),
+		)
+		prevLine = token.Line
+		prevCharacter = token.Character
+	}
+
+	return data
+}
+
+// Diff returns the edits that transform previous encoded data into current.
+// A single edit replacing the part that changed is generated.
+func Diff(previous []protocol.UInteger, current []protocol.UInteger) []protocol.SemanticTokensEdit {
+	start := 0
+	for start < len(previous) && start < len(current) && previous[start] == current[start] {
+		start++
+	}
+
+	if start == len(previous) && start == len(current) {
+		return []protocol.SemanticTokensEdit{}
+	}
+
+	previousEnd, currentEnd := len(previous), len(current)
+	for previousEnd > start && currentEnd > start && previous[previousEnd-1] == current[currentEnd-1] {
+		previousEnd--
+		currentEnd--
+	}
+
+	return []protocol.SemanticTokensEdit{{
+		Start:       protocol.UInteger(start),
+		DeleteCount: protocol.UInteger(previousEnd - start),
+		Data:        current[start:currentEnd],
+	}}
+}
@@ -0,0 +1,69 @@
+package semantic_tokens
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestEncode(t *testing.T) {
+	t.Run("Encodes tokens relative to previous token", func(t *testing.T) {
+		data := Encode([]Token{
+			{Line: 2, Character: 5, Length: 3, Type: TypeVariable},
+			{Line: 0, Character: 7, Length: 4, Type: TypeNamespace, Modifiers: ModifierDeclaration},
+			{Line: 2, Character: 10, Length: 6, Type: TypeStruct, Modifiers: ModifierReadonly | ModifierDefaultLibrary},
+		})
+
+		assert.Equal(t, []protocol.UInteger{
+			0, 7, 4, protocol.UInteger(TypeNamespace), 1,
+			2, 5, 3, protocol.UInteger(TypeVariable), 0,
+			0, 5, 6, protocol.UInteger(TypeStruct), 10,
+		}, data)
+	})
+
+	t.Run("Legend declares every token type", func(t *testing.T) {
+		assert.Equal(t, int(TypeFault)+1, len(Legend().TokenTypes))
+	})
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("No edits when data did not change", func(t *testing.T) {
+		edits := Diff([]protocol.UInteger{1, 2, 3}, []protocol.UInteger{1, 2, 3})
+
+		assert.Equal(t, 0, len(edits))
+	})
+
+	t.Run("Replaces the changed part", func(t *testing.T) {
+		edits := Diff([]protocol.UInteger{1, 2, 3, 4, 5}, []protocol.UInteger{1, 2, 9, 9, 9, 4, 5})
+
+		assert.Equal(t, []protocol.SemanticTokensEdit{
+			{Start: 2, DeleteCount: 1, Data: []protocol.UInteger{9, 9, 9}},
+		}, edits)
+	})
+
+	t.Run("Deletes removed data", func(t *testing.T) {
+		edits := Diff([]protocol.UInteger{1, 2, 3, 4, 5}, []protocol.UInteger{1, 2})
+
+		assert.Equal(t, []protocol.SemanticTokensEdit{
+			{Start: 2, DeleteCount: 3, Data: []protocol.UInteger{}},
+		}, edits)
+	})
+}
+
+func TestCache(t *testing.T) {
+	cache := NewCache()
+	first := cache.Store("doc", []protocol.UInteger{1})
+	second := cache.Store("doc", []protocol.UInteger{2})
+
+	_, found := cache.Get("doc", first)
+	assert.False(t, found, "Outdated result ids should not be found")
+
+	data, found := cache.Get("doc", second)
+	assert.True(t, found)
+	assert.Equal(t, []protocol.UInteger{2}, data)
+
+	cache.Forget("doc")
+	_, found = cache.Get("doc", second)
+	assert.False(t, found)
+}
@@ -1,33 +1,89 @@
 package server
 
 import (
+	"bytes"
 	"log"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/pherrymason/c3-lsp/internal/c3c"
-	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/diagnostics"
 	"github.com/pherrymason/c3-lsp/pkg/cast"
+	"github.com/pherrymason/c3-lsp/pkg/document"
 	"github.com/pherrymason/c3-lsp/pkg/fs"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
-func (s *Server) RunDiagnostics(state *project_state.ProjectState, notify glsp.NotifyFunc, delay bool) {
-	if !s.options.Diagnostics.Enabled {
+// RunDiagnostics checks project with c3c and publishes the errors found.
+// Depending on the configured mode, either the whole project or just the open documents are checked.
+func (s *Server) RunDiagnostics(project *Project, notify glsp.NotifyFunc, delay bool) {
+	if !project.options.Diagnostics.Enabled {
 		return
 	}
 
+	// Results of a check started before this change would be outdated.
+	project.cancelRunningDiagnostics()
+
 	runDiagnostics := func() {
-		out, stdErr, err := c3c.CheckC3ErrorsCommand(s.options.C3, state.GetProjectRootURI())
+		ctx := project.startDiagnostics()
+
+		s.stateLock.Lock()
+		options := project.options
+		checkFiles := s.usesFileDiagnostics(project)
+		contents := map[string]string{}
+		if checkFiles {
+			contents = s.snapshotContents(project)
+		}
+		s.stateLock.Unlock()
+
+		var out, stdErr bytes.Buffer
+		var err error
+		originalPath := func(path string) string { return path }
+		source := "c3c build --lsp"
+		if checkFiles {
+			if len(contents) == 0 {
+				return
+			}
+
+			snapshot, snapshotErr := c3c.NewSnapshot(project.root, contents)
+			if snapshotErr != nil {
+				log.Println("Diagnostics report: could not write files to check:", snapshotErr)
+				return
+			}
+			defer snapshot.Remove()
+
+			out, stdErr, err = c3c.CheckSnapshotErrorsCommand(ctx, options.C3, snapshot)
+			originalPath = snapshot.OriginalPath
+			source = "c3c compile-only --lsp"
+		} else {
+			out, stdErr, err = c3c.CheckC3ErrorsCommand(ctx, options.C3, project.root)
+		}
+
+		if ctx.Err() != nil {
+			// Cancelled by a more recent check.
+			return
+		}
 		log.Println("output:", out.String())
 		log.Println("output:", stdErr.String())
 
-		errorsInfo, diagnosticsDisabled := extractErrorDiagnostics(stdErr.String())
+		// c3c runs without holding the lock, but the results are stored in the state.
+		s.stateLock.Lock()
+		defer s.stateLock.Unlock()
+
+		errorsInfo, diagnosticsDisabled := extractErrorDiagnostics(stdErr.String(), source)
+		for i := range errorsInfo {
+			errorsInfo[i].File = originalPath(errorsInfo[i].File)
+			for j := range errorsInfo[i].Notes {
+				errorsInfo[i].Notes[j].File = originalPath(errorsInfo[i].Notes[j].File)
+			}
+		}
 
 		if diagnosticsDisabled {
-			s.options.Diagnostics.Enabled = false
-			s.clearOldDiagnostics(s.state, notify)
+			project.options.Diagnostics.Enabled = false
+			s.clearOldDiagnostics(project, notify)
 			return
 		}
 
@@ -44,59 +100,207 @@ func (s *Server) RunDiagnostics(state *project_state.ProjectState, notify glsp.N
 			}
 
 			// Clear old diagnostics since we can't generate new ones
-			s.clearOldDiagnostics(s.state, notify)
+			s.clearOldDiagnostics(project, notify)
 			return
 		}
 
 		if len(errorsInfo) == 0 && err == nil {
 			// No diagnostics to report, clear existing ones.
-			s.clearOldDiagnostics(s.state, notify)
+			s.clearOldDiagnostics(project, notify)
 			return
 		}
 
 		if err != nil {
 			log.Println("Diagnostics report:", err)
 		}
-		// Send empty diagnostics for those files that had previously an error, but not anymore.
-		// If this is not done, the IDE will keep displaying the errors.
-		for k := range s.state.GetDocumentDiagnostics() {
-			if !hasDiagnosticForFile(k, errorsInfo) {
-				s.state.RemoveDocumentDiagnostics(k)
-				notify(protocol.ServerTextDocumentPublishDiagnostics,
-					protocol.PublishDiagnosticsParams{
-						URI:         fs.ConvertPathToURI(k, s.options.C3.StdlibPath),
-						Diagnostics: []protocol.Diagnostic{},
-					})
-			}
-		}
 
-		for _, errInfo := range errorsInfo {
-			newDiagnostics := []protocol.Diagnostic{
-				errInfo.Diagnostic,
-			}
-			state.SetDocumentDiagnostics(errInfo.File, newDiagnostics)
-			notify(
-				protocol.ServerTextDocumentPublishDiagnostics,
-				protocol.PublishDiagnosticsParams{
-					URI:         fs.ConvertPathToURI(errInfo.File, s.options.C3.StdlibPath),
-					Diagnostics: newDiagnostics,
-				})
-		}
+		s.publishC3cDiagnostics(project, notify, errorsInfo)
 	}
 
 	if delay {
-		s.diagnosticDebounced(runDiagnostics)
+		project.diagnosticDebounced(runDiagnostics)
 	} else {
 		runDiagnostics()
 	}
 }
 
+// usesFileDiagnostics tells if c3c must check the open documents of project instead of building it.
+func (s *Server) usesFileDiagnostics(project *Project) bool {
+	switch project.options.Diagnostics.Mode {
+	case DiagnosticsModeProject:
+		return false
+	case DiagnosticsModeFile:
+		return true
+	}
+
+	if project.root == "" {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(project.root, "project.json"))
+	return err != nil
+}
+
+// snapshotContents collects the sources c3c needs to check the documents open in project:
+// the files of their modules and of the modules they import, with the contents being edited.
+func (s *Server) snapshotContents(project *Project) map[string]string {
+	unitModules := project.state.GetAllUnitModules()
+	docsByModule := map[string][]string{}
+	for docId, unit := range unitModules {
+		for _, module := range unit.Modules() {
+			docsByModule[module.GetName()] = append(docsByModule[module.GetName()], docId)
+		}
+	}
+
+	contents := map[string]string{}
+	pending := project.state.OpenDocuments()
+	for len(pending) > 0 {
+		docId := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		// Stdlib symbols have no document: c3c already knows them.
+		doc := project.state.GetDocument(docId)
+		if _, found := contents[docId]; found || doc == nil {
+			continue
+		}
+		contents[docId] = doc.SourceCode.Text
+
+		unit := unitModules[docId]
+		for _, module := range unit.Modules() {
+			pending = append(pending, docsByModule[module.GetName()]...)
+			for _, imported := range module.Imports {
+				for name, docIds := range docsByModule {
+					if name == imported || strings.HasPrefix(name, imported+"::") {
+						pending = append(pending, docIds...)
+					}
+				}
+			}
+		}
+	}
+
+	return contents
+}
+
+// publishC3cDiagnostics replaces the diagnostics reported by c3c with errorsInfo, publishing all
+// the errors of each file at once.
+func (s *Server) publishC3cDiagnostics(project *Project, notify glsp.NotifyFunc, errorsInfo []ErrorInfo) {
+	state := project.state
+	files := []string{}
+	diagnosticsByFile := map[string][]protocol.Diagnostic{}
+	for _, errInfo := range errorsInfo {
+		file, diagnostic := s.locateDiagnostic(project, errInfo)
+		if _, found := diagnosticsByFile[file]; !found {
+			files = append(files, file)
+		}
+		diagnosticsByFile[file] = append(diagnosticsByFile[file], diagnostic)
+	}
+
+	// Send empty diagnostics for those files that had previously an error, but not anymore.
+	// If this is not done, the IDE will keep displaying the errors.
+	for k := range state.GetDocumentDiagnostics() {
+		if _, found := diagnosticsByFile[k]; !found {
+			state.RemoveDocumentDiagnostics(k)
+			s.publishDocumentDiagnostics(project, notify, k)
+		}
+	}
+
+	for _, file := range files {
+		state.SetDocumentDiagnostics(file, diagnosticsByFile[file])
+		s.publishDocumentDiagnostics(project, notify, file)
+	}
+}
+
+// locateDiagnostic resolves the file errInfo belongs to and widens its ranges to the offending tokens.
+// Errors found in the stdlib or in dependencies while compiling workspace code are reported on the
+// workspace location c3c notes, so they are visible where they can be fixed.
+func (s *Server) locateDiagnostic(project *Project, errInfo ErrorInfo) (string, protocol.Diagnostic) {
+	file := s.resolveReportedPath(project, errInfo.File)
+	diagnostic := errInfo.Diagnostic
+	diagnostic.Range = s.widenReportedRange(project, file, diagnostic.Range)
+
+	related := []protocol.DiagnosticRelatedInformation{}
+	workspaceNote := -1
+	for i, note := range errInfo.Notes {
+		noteFile := s.resolveReportedPath(project, note.File)
+		if workspaceNote < 0 && s.isWorkspaceSource(project, noteFile) {
+			workspaceNote = i
+		}
+
+		related = append(related, protocol.DiagnosticRelatedInformation{
+			Location: protocol.Location{
+				URI:   fs.ConvertPathToURI(noteFile, project.options.C3.StdlibPath),
+				Range: s.widenReportedRange(project, noteFile, note.Range),
+			},
+			Message: note.Message,
+		})
+	}
+
+	if !s.isWorkspaceSource(project, file) && workspaceNote >= 0 {
+		original := protocol.DiagnosticRelatedInformation{
+			Location: protocol.Location{
+				URI:   fs.ConvertPathToURI(file, project.options.C3.StdlibPath),
+				Range: diagnostic.Range,
+			},
+			Message: diagnostic.Message,
+		}
+
+		file = s.resolveReportedPath(project, errInfo.Notes[workspaceNote].File)
+		diagnostic.Range = related[workspaceNote].Location.Range
+		related = append([]protocol.DiagnosticRelatedInformation{original}, related...)
+	}
+
+	if len(related) > 0 {
+		diagnostic.RelatedInformation = related
+	}
+
+	return file, diagnostic
+}
+
+// resolveReportedPath turns the paths c3c reports, which can be relative to the project folder, into document ids.
+func (s *Server) resolveReportedPath(project *Project, path string) string {
+	if !filepath.IsAbs(path) && project.root != "" {
+		path = filepath.Join(project.root, path)
+	}
+
+	return filepath.Clean(path)
+}
+
+// isWorkspaceSource tells if file is a source of project, and not a stdlib or dependency one.
+func (s *Server) isWorkspaceSource(project *Project, file string) bool {
+	return isWorkspaceDocument(project, file) && !project.dependencies[file]
+}
+
+// widenReportedRange extends the range c3c reports, which only covers the first character
+// of the offending token, to the whole token.
+func (s *Server) widenReportedRange(project *Project, file string, reported protocol.Range) protocol.Range {
+	doc := project.state.GetDocument(file)
+	if doc == nil {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return reported
+		}
+		parsed := document.NewDocumentFromString(file, string(content))
+		doc = &parsed
+	}
+
+	tokenRange := diagnostics.TokenRangeAt(doc, reported.Start)
+	return tokenRange.GetOrElse(reported)
+}
+
 type ErrorInfo struct {
 	File       string
 	Diagnostic protocol.Diagnostic
+	Notes      []ErrorNote // Secondary locations of the error
 }
 
-func extractErrorDiagnostics(output string) ([]ErrorInfo, bool) {
+type ErrorNote struct {
+	File    string
+	Range   protocol.Range
+	Message string
+}
+
+// extractErrorDiagnostics parses the errors c3c reported in output. source names
+// the c3c command that produced them.
+func extractErrorDiagnostics(output string, source string) ([]ErrorInfo, bool) {
 	errorsInfo := []ErrorInfo{}
 	diagnosticsDisabled := false
 
@@ -120,6 +324,8 @@ func extractErrorDiagnostics(output string) ([]ErrorInfo, bool) {
 			severity = protocol.DiagnosticSeverityError
 		case "warning":
 			severity = protocol.DiagnosticSeverityWarning
+		case "note":
+			severity = protocol.DiagnosticSeverityInformation
 		default:
 			continue
 		}
@@ -135,16 +341,28 @@ func extractErrorDiagnostics(output string) ([]ErrorInfo, bool) {
 		}
 		character--
 
+		file := strings.Trim(parts[2], `"`)
 		message := strings.Trim(parts[5], `"`)
+		errorRange := protocol.Range{
+			Start: protocol.Position{Line: protocol.UInteger(errorLine), Character: protocol.UInteger(character)},
+			End:   protocol.Position{Line: protocol.UInteger(errorLine), Character: protocol.UInteger(character + 1)},
+		}
+
+		if severity == protocol.DiagnosticSeverityInformation {
+			// Notes give more context about the error reported right before them.
+			if len(errorsInfo) > 0 {
+				last := &errorsInfo[len(errorsInfo)-1]
+				last.Notes = append(last.Notes, ErrorNote{File: file, Range: errorRange, Message: message})
+			}
+			continue
+		}
+
 		errorsInfo = append(errorsInfo, ErrorInfo{
-			File: strings.Trim(parts[2], `"`),
+			File: file,
 			Diagnostic: protocol.Diagnostic{
-				Range: protocol.Range{
-					Start: protocol.Position{Line: protocol.UInteger(errorLine), Character: protocol.UInteger(character)},
-					End:   protocol.Position{Line: protocol.UInteger(errorLine), Character: protocol.UInteger(character + 1)},
-				},
+				Range:    errorRange,
 				Severity: cast.ToPtr(severity),
-				Source:   cast.ToPtr("c3c build --lsp"),
+				Source:   cast.ToPtr(source),
 				Message:  message,
 			},
 		})
@@ -153,23 +371,102 @@ func extractErrorDiagnostics(output string) ([]ErrorInfo, bool) {
 	return errorsInfo, diagnosticsDisabled
 }
 
-func (s *Server) clearOldDiagnostics(state *project_state.ProjectState, notify glsp.NotifyFunc) {
-	for k := range state.GetDocumentDiagnostics() {
-		go notify(protocol.ServerTextDocumentPublishDiagnostics,
-			protocol.PublishDiagnosticsParams{
-				URI:         fs.ConvertPathToURI(k, s.options.C3.StdlibPath),
-				Diagnostics: []protocol.Diagnostic{},
-			})
+// clearOldDiagnostics removes the diagnostics reported by c3c. Syntax errors are kept.
+func (s *Server) clearOldDiagnostics(project *Project, notify glsp.NotifyFunc) {
+	docIds := []string{}
+	for k := range project.state.GetDocumentDiagnostics() {
+		docIds = append(docIds, k)
+	}
+	project.state.ClearDocumentDiagnostics()
+
+	if s.pullDiagnosticsSupported {
+		s.requestDiagnosticsRefresh()
+		return
+	}
+
+	for _, docId := range docIds {
+		params := s.documentDiagnosticsParams(project, docId)
+		go notify(protocol.ServerTextDocumentPublishDiagnostics, params)
 	}
-	state.ClearDocumentDiagnostics()
 }
 
-func hasDiagnosticForFile(file string, errorsInfo []ErrorInfo) bool {
-	for _, v := range errorsInfo {
-		if file == v.File {
-			return true
-		}
+// refreshServerDiagnostics looks for syntax errors and lints in docId and publishes
+// them right away, along with the last diagnostics reported by c3c.
+func (s *Server) refreshServerDiagnostics(project *Project, notify glsp.NotifyFunc, docId string) {
+	if !project.options.Diagnostics.Enabled {
+		return
+	}
+
+	doc := project.state.GetDocument(docId)
+	if doc == nil {
+		return
+	}
+
+	project.state.SetDocumentServerDiagnostics(docId, s.serverDiagnostics(project, doc))
+	s.publishDocumentDiagnostics(project, notify, docId)
+}
+
+func (s *Server) serverDiagnostics(project *Project, doc *document.Document) []protocol.Diagnostic {
+	disabled := map[string]bool{}
+	for _, code := range project.options.Diagnostics.Disabled {
+		disabled[code] = true
+	}
+
+	found := diagnostics.SyntaxErrors(doc)
+	found = append(found, diagnostics.Lint(doc, project.state, s.search, diagnostics.LintOptions{
+		Disabled: disabled,
+		Resolve:  project.indexed && project.stdlibLoaded,
+	})...)
+
+	return found
+}
+
+// refreshOpenDocumentsDiagnostics updates the diagnostics found by the server in every document open in project.
+func (s *Server) refreshOpenDocumentsDiagnostics(project *Project, notify glsp.NotifyFunc) {
+	for _, docId := range project.state.OpenDocuments() {
+		s.refreshServerDiagnostics(project, notify, docId)
+	}
+}
+
+// clearServerDiagnostics stops reporting the diagnostics the server found in docId, which is no longer edited.
+func (s *Server) clearServerDiagnostics(project *Project, notify glsp.NotifyFunc, docId string) {
+	if !project.options.Diagnostics.Enabled {
+		return
+	}
+
+	project.state.SetDocumentServerDiagnostics(docId, nil)
+	s.publishDocumentDiagnostics(project, notify, docId)
+}
+
+// publishDocumentDiagnostics sends every diagnostic known for docId, whatever its source.
+// The client replaces the diagnostics of a document with each notification, so they can't be sent separately.
+// Clients pulling diagnostics are asked to pull them again instead.
+func (s *Server) publishDocumentDiagnostics(project *Project, notify glsp.NotifyFunc, docId string) {
+	if s.pullDiagnosticsSupported {
+		s.requestDiagnosticsRefresh()
+		return
+	}
+
+	notify(protocol.ServerTextDocumentPublishDiagnostics, s.documentDiagnosticsParams(project, docId))
+}
+
+func (s *Server) documentDiagnosticsParams(project *Project, docId string) protocol.PublishDiagnosticsParams {
+	return protocol.PublishDiagnosticsParams{
+		URI:         fs.ConvertPathToURI(docId, project.options.C3.StdlibPath),
+		Diagnostics: project.state.GetMergedDocumentDiagnostics(docId),
+	}
+}
+
+// requestDiagnosticsRefresh asks the client to pull diagnostics again. Requests made while
+// one is waiting to be sent are merged into it.
+func (s *Server) requestDiagnosticsRefresh() {
+	if !s.diagnosticRefreshSupported || !s.diagnosticRefreshPending.CompareAndSwap(false, true) {
+		return
 	}
 
-	return false
+	// Requests to the client can't wait for its response while a handler is running.
+	go func() {
+		s.diagnosticRefreshPending.Store(false)
+		s.clientCall(ServerWorkspaceDiagnosticRefresh, nil, nil)
+	}()
 }
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// addClosedDocument registers a document of the project that is not opened in the client.
+func addClosedDocument(h *Server, project *Project, name string, source string) {
+	doc := document.NewDocument(testDocId(name), source)
+	project.state.RefreshDocumentIdentifiers(&doc, h.parser)
+}
+
+func TestSnapshotContents(t *testing.T) {
+	h, project := newTestServer(t, map[string]string{
+		"app.c3": "module app;\nimport std::io;\nfn void main() {}",
+	})
+	addClosedDocument(h, project, "app_helpers.c3", "module app;\nfn void help() {}")
+	addClosedDocument(h, project, "io.c3", "module std::io;\nimport std::io::os;\nfn void printn() {}")
+	addClosedDocument(h, project, "io_file.c3", "module std::io::file;\nfn void open() {}")
+	addClosedDocument(h, project, "os.c3", "module std::io::os;\nfn void exit() {}")
+	addClosedDocument(h, project, "math.c3", "module std::math;\nfn int abs(int x) { return x; }")
+
+	contents := h.snapshotContents(project)
+
+	t.Run("Includes open documents with their contents being edited", func(t *testing.T) {
+		assert.Equal(t, "module app;\nimport std::io;\nfn void main() {}", contents[testDocId("app.c3")])
+	})
+
+	t.Run("Includes the other files of their modules", func(t *testing.T) {
+		assert.Contains(t, contents, testDocId("app_helpers.c3"))
+	})
+
+	t.Run("Includes imported modules and their submodules, transitively", func(t *testing.T) {
+		assert.Contains(t, contents, testDocId("io.c3"))
+		assert.Contains(t, contents, testDocId("io_file.c3"))
+		assert.Contains(t, contents, testDocId("os.c3"))
+	})
+
+	t.Run("Skips modules not imported", func(t *testing.T) {
+		assert.NotContains(t, contents, testDocId("math.c3"))
+		assert.Len(t, contents, 5)
+	})
+}
+
+func TestSnapshotContents_without_open_documents(t *testing.T) {
+	h, project := newTestServer(t, map[string]string{})
+	addClosedDocument(h, project, "app.c3", "module app;")
+
+	assert.Empty(t, h.snapshotContents(project))
+}
+
+func TestPublishDocumentDiagnostics(t *testing.T) {
+	t.Run("Publishes the diagnostics to clients not pulling them", func(t *testing.T) {
+		h, project := newTestServer(t, map[string]string{"app.c3": "module app;"})
+		project.state.SetDocumentDiagnostics(testDocId("app.c3"), []protocol.Diagnostic{diagnostic(0, "c3c error")})
+
+		published := []any{}
+		h.publishDocumentDiagnostics(project, func(method string, params any) {
+			assert.Equal(t, protocol.ServerTextDocumentPublishDiagnostics, method)
+			published = append(published, params)
+		}, testDocId("app.c3"))
+
+		assert.Equal(t, []any{protocol.PublishDiagnosticsParams{
+			URI:         testDocURI("app.c3"),
+			Diagnostics: []protocol.Diagnostic{diagnostic(0, "c3c error")},
+		}}, published)
+	})
+
+	t.Run("Asks clients pulling diagnostics to pull them again", func(t *testing.T) {
+		h, project := newTestServer(t, map[string]string{"app.c3": "module app;"})
+		h.pullDiagnosticsSupported = true
+		h.diagnosticRefreshSupported = true
+
+		requested := make(chan string, 1)
+		h.clientCall = func(method string, params any, result any) {
+			requested <- method
+		}
+
+		h.publishDocumentDiagnostics(project, func(method string, params any) {
+			t.Errorf("Diagnostics should not be published, got %s", method)
+		}, testDocId("app.c3"))
+
+		select {
+		case method := <-requested:
+			assert.Equal(t, ServerWorkspaceDiagnosticRefresh, method)
+		case <-time.After(time.Second):
+			t.Error("Refresh was not requested")
+		}
+	})
+}
+
+func TestExtractErrorDiagnostics(t *testing.T) {
+	output := "> LSPERR|error|\"/workspace/app.c3\"|2|5|\"Expected ';'\"\n" +
+		"> LSPERR|note|\"/workspace/lib.c3\"|1|1|\"Declared here\"\n"
+
+	errorsInfo, diagnosticsDisabled := extractErrorDiagnostics(output, "c3c compile-only --lsp")
+
+	assert.False(t, diagnosticsDisabled)
+	assert.Len(t, errorsInfo, 1)
+
+	t.Run("Labels diagnostics with the command that reported them", func(t *testing.T) {
+		assert.Equal(t, "c3c compile-only --lsp", *errorsInfo[0].Diagnostic.Source)
+	})
+
+	t.Run("Attaches notes to the error reported before them", func(t *testing.T) {
+		assert.Equal(t, "/workspace/app.c3", errorsInfo[0].File)
+		assert.Equal(t, []ErrorNote{{
+			File:    "/workspace/lib.c3",
+			Range:   protocol.Range{Start: protocol.Position{Line: 0, Character: 0}, End: protocol.Position{Line: 0, Character: 1}},
+			Message: "Declared here",
+		}}, errorsInfo[0].Notes)
+	})
+}
@@ -1,14 +1,22 @@
 package server
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
 
+	"github.com/pherrymason/c3-lsp/internal/lsp/index_cache"
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/semantic_tokens"
 	"github.com/pherrymason/c3-lsp/pkg/cast"
-	"github.com/pherrymason/c3-lsp/pkg/document"
 	"github.com/pherrymason/c3-lsp/pkg/fs"
-	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	p "github.com/pherrymason/c3-lsp/pkg/parser"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
+	protocol317 "github.com/tliron/glsp/protocol_3_17"
 )
 
 // Support "Hover"
@@ -22,6 +30,14 @@ func (s *Server) Initialize(serverName string, serverVersion string, capabilitie
 		Save:      cast.ToPtr(true),
 	}
 	capabilities.DeclarationProvider = true
+	capabilities.ReferencesProvider = true
+	capabilities.RenameProvider = protocol.RenameOptions{PrepareProvider: cast.ToPtr(true)}
+	capabilities.DocumentSymbolProvider = true
+	capabilities.SemanticTokensProvider = protocol.SemanticTokensOptions{
+		Legend: semantic_tokens.Legend(),
+		Range:  true,
+		Full:   protocol.SemanticDelta{Delta: cast.ToPtr(true)},
+	}
 	capabilities.CompletionProvider = &protocol.CompletionOptions{
 		TriggerCharacters: []string{".", ":"},
 	}
@@ -29,6 +45,17 @@ func (s *Server) Initialize(serverName string, serverVersion string, capabilitie
 		TriggerCharacters:   []string{"(", ","},
 		RetriggerCharacters: []string{")"},
 	}
+	capabilities.CodeActionProvider = protocol.CodeActionOptions{
+		CodeActionKinds: []protocol.CodeActionKind{protocol.CodeActionKindQuickFix},
+	}
+	capabilities.CallHierarchyProvider = true
+	capabilities.ImplementationProvider = true
+	capabilities.DocumentFormattingProvider = true
+	capabilities.DocumentRangeFormattingProvider = true
+	capabilities.DocumentOnTypeFormattingProvider = &protocol.DocumentOnTypeFormattingOptions{
+		FirstTriggerCharacter: onTypeFormattingTriggers[0],
+		MoreTriggerCharacter:  onTypeFormattingTriggers[1:],
+	}
 	capabilities.Workspace = &protocol.ServerCapabilitiesWorkspace{
 		FileOperations: &protocol.ServerCapabilitiesWorkspaceFileOperations{
 			DidDelete: &protocol.FileOperationRegistrationOptions{
@@ -48,68 +75,273 @@ func (s *Server) Initialize(serverName string, serverVersion string, capabilitie
 		},
 	}
 
-	if params.RootURI != nil {
-		s.state.SetProjectRootURI(utils.NormalizePath(*params.RootURI))
-		path, _ := fs.UriToPath(*params.RootURI)
-		s.loadServerConfigurationForWorkspace(path)
-		s.indexWorkspace()
-		s.RunDiagnostics(s.state, context.Notify, false)
+	capabilities.Workspace.WorkspaceFolders = &protocol.WorkspaceFoldersServerCapabilities{
+		Supported:           cast.ToPtr(true),
+		ChangeNotifications: &protocol.BoolOrString{Value: true},
+	}
+
+	// Capabilities of the diagnostics pull model are not known by the 3.16 params.
+	var pullCapabilities pullDiagnosticsClientCapabilities
+	if err := json.Unmarshal(context.Params, &pullCapabilities); err == nil {
+		s.pullDiagnosticsSupported = pullCapabilities.Capabilities.TextDocument.Diagnostic != nil
+		s.diagnosticRefreshSupported = pullCapabilities.Capabilities.Workspace.Diagnostics.RefreshSupport
+	}
+
+	// Disable diagnostics only if the client does not support publishDiagnostics nor pulling them.
+	if !s.pullDiagnosticsSupported && (params.Capabilities.TextDocument == nil || params.Capabilities.TextDocument.PublishDiagnostics == nil) {
+		s.diagnosticsSupported = false
+	}
+	s.clientCall = context.Call
+
+	if workspace := params.Capabilities.Workspace; workspace != nil {
+		s.watchedFilesRegistrationSupported = workspace.DidChangeWatchedFiles != nil &&
+			workspace.DidChangeWatchedFiles.DynamicRegistration != nil &&
+			*workspace.DidChangeWatchedFiles.DynamicRegistration
+		s.configurationRegistrationSupported = workspace.DidChangeConfiguration != nil &&
+			workspace.DidChangeConfiguration.DynamicRegistration != nil &&
+			*workspace.DidChangeConfiguration.DynamicRegistration
+		s.configurationRequestSupported = workspace.Configuration != nil && *workspace.Configuration
+	}
+	s.workDoneProgressSupported = params.Capabilities.Window != nil &&
+		params.Capabilities.Window.WorkDoneProgress != nil &&
+		*params.Capabilities.Window.WorkDoneProgress
+
+	folders := params.WorkspaceFolders
+	if len(folders) == 0 && params.RootURI != nil {
+		folders = []protocol.WorkspaceFolder{{URI: *params.RootURI}}
 	}
 
-	// Disable diagnostics only if the client does not support publishDiagnostics at all.
-	if params.Capabilities.TextDocument == nil || params.Capabilities.TextDocument.PublishDiagnostics == nil {
-		s.options.Diagnostics.Enabled = false
+	if len(folders) > 0 {
+		s.projects = []*Project{}
+		for _, folder := range folders {
+			s.addProject(folder.URI)
+		}
 	}
 
-	return protocol.InitializeResult{
-		Capabilities: capabilities,
-		ServerInfo: &protocol.InitializeResultServerInfo{
-			Name:    serverName,
-			Version: &serverVersion,
+	serverInfo := &protocol.InitializeResultServerInfo{
+		Name:    serverName,
+		Version: &serverVersion,
+	}
+	result := InitializeResult{
+		Capabilities: ServerCapabilities{
+			ServerCapabilities:    protocol317.ServerCapabilities{ServerCapabilities: capabilities},
+			InlayHintProvider:     InlayHintOptions{ResolveProvider: true},
+			TypeHierarchyProvider: true,
 		},
-	}, nil
+		ServerInfo: serverInfo,
+	}
+	if s.pullDiagnosticsSupported {
+		result.Capabilities.DiagnosticProvider = protocol317.DiagnosticOptions{
+			InterFileDependencies: true,
+			WorkspaceDiagnostics:  true,
+		}
+	}
+
+	return result, nil
+}
+
+// InitializeResult is the result of initialize with the capabilities of LSP 3.17 that glsp does not know.
+type InitializeResult struct {
+	Capabilities ServerCapabilities                   `json:"capabilities"`
+	ServerInfo   *protocol.InitializeResultServerInfo `json:"serverInfo,omitempty"`
+}
+
+type ServerCapabilities struct {
+	protocol317.ServerCapabilities
+
+	InlayHintProvider     any `json:"inlayHintProvider,omitempty"`     // nil | InlayHintOptions
+	TypeHierarchyProvider any `json:"typeHierarchyProvider,omitempty"` // nil | bool
+}
+
+// pullDiagnosticsClientCapabilities are the client capabilities of the diagnostics pull model.
+type pullDiagnosticsClientCapabilities struct {
+	Capabilities struct {
+		TextDocument struct {
+			Diagnostic *protocol317.DiagnosticClientCapabilities `json:"diagnostic"`
+		} `json:"textDocument"`
+		Workspace struct {
+			Diagnostics struct {
+				RefreshSupport bool `json:"refreshSupport"`
+			} `json:"diagnostics"`
+		} `json:"workspace"`
+	} `json:"capabilities"`
+}
+
+// Initialized starts indexing the workspace folders in background, so the client is not
+// blocked while files are parsed. Requests received meanwhile are answered with the
+// symbols indexed so far.
+func (s *Server) Initialized(context *glsp.Context, params *protocol.InitializedParams) error {
+	projects := []*Project{}
+	for _, project := range s.projects {
+		if project.root != "" {
+			projects = append(projects, project)
+		}
+	}
+
+	go func() {
+		s.registerCapabilities(context)
+		s.pullClientConfiguration(context)
+
+		for _, project := range projects {
+			s.indexWorkspace(context, project)
+			s.RunDiagnostics(project, context.Notify, false)
+		}
+	}()
+
+	return nil
+}
+
+// registerCapabilities registers the capabilities the client expects to be registered dynamically.
+// Like any request to the client, it must not be called from a request handler.
+func (h *Server) registerCapabilities(context *glsp.Context) {
+	registrations := []protocol.Registration{}
+	if h.watchedFilesRegistrationSupported {
+		registrations = append(registrations, fileWatchersRegistration())
+	}
+	if h.configurationRegistrationSupported {
+		registrations = append(registrations, configurationRegistration())
+	}
+
+	if len(registrations) == 0 {
+		return
+	}
+
+	context.Call(protocol.ServerClientRegisterCapability, protocol.RegistrationParams{Registrations: registrations}, nil)
 }
 
-func (h *Server) indexWorkspace() {
-	path := h.state.GetProjectRootURI()
-	canonicalPath := fs.GetCanonicalPath(path)
+func (h *Server) indexWorkspace(context *glsp.Context, project *Project) {
+	canonicalPath := fs.GetCanonicalPath(project.root)
 
-	// Index workspace source files
+	// Workspace source files
 	files, _ := fs.ScanForC3(canonicalPath)
-	for _, filePath := range files {
-		content, _ := os.ReadFile(filePath)
-		doc := document.NewDocumentFromString(filePath, string(content))
-		h.state.RefreshDocumentIdentifiers(&doc, h.parser)
+
+	// Dependency libraries from project.json
+	dependencyFiles := h.dependencyFiles(canonicalPath)
+	files = append(files, dependencyFiles...)
+
+	h.stateLock.Lock()
+	project.dependencies = map[string]bool{}
+	for _, filePath := range dependencyFiles {
+		project.dependencies[filePath] = true
+	}
+	cache := h.indexCache(project)
+	h.stateLock.Unlock()
+
+	progress := h.beginWorkDoneProgress(context, "Indexing "+filepath.Base(project.root))
+	h.indexFiles(project, files, cache, progress)
+	if progress.IsSome() {
+		progress.Get().end(fmt.Sprintf("Indexed %d files", len(files)))
+	}
+
+	// Lints resolving symbols are only reliable once every file is indexed.
+	h.stateLock.Lock()
+	project.indexed = true
+	h.refreshOpenDocumentsDiagnostics(project, context.Notify)
+	h.stateLock.Unlock()
+}
+
+// Maximum number of files parsed at the same time while indexing.
+var maxIndexingWorkers = runtime.NumCPU()
+
+// indexFiles parses files using a pool of workers and registers their symbols.
+// Each worker has its own parser, as they can't be shared between goroutines.
+// Registering in the state is done one file at a time while holding the state lock.
+func (h *Server) indexFiles(project *Project, files []string, cache option.Option[*index_cache.Cache], progress option.Option[*workDoneProgress]) {
+	jobs := make(chan string)
+	results := make(chan option.Option[project_state.ParsedDocument])
+
+	workers := min(maxIndexingWorkers, len(files))
+	for range workers {
+		go func() {
+			parser := p.NewParser(h.server.Log)
+			for filePath := range jobs {
+				content, err := os.ReadFile(filePath)
+				if err != nil {
+					h.server.Log.Warningf("Failed to read %s: %v", filePath, err)
+					results <- option.None[project_state.ParsedDocument]()
+					continue
+				}
+
+				results <- option.Some(project.state.ParseDocument(filePath, string(content), &parser, cache))
+			}
+		}()
 	}
 
-	// Index dependency libraries from project.json
-	h.indexDependencies(canonicalPath)
+	go func() {
+		for _, filePath := range files {
+			jobs <- filePath
+		}
+		close(jobs)
+	}()
+
+	for done := 1; done <= len(files); done++ {
+		result := <-results
+		if result.IsSome() {
+			h.registerIndexedDocument(project, result.Get())
+		}
+
+		if progress.IsSome() {
+			progress.Get().report(fmt.Sprintf("Indexing %d/%d files", done, len(files)), done, len(files))
+		}
+	}
 }
 
-// indexDependencies reads the project.json file in the workspace root,
+func (h *Server) registerIndexedDocument(project *Project, parsed project_state.ParsedDocument) {
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+
+	// Documents opened while indexing have more recent contents than the ones on disk.
+	if project.state.GetDocument(parsed.Document.URI) != nil {
+		return
+	}
+
+	project.state.RegisterParsedDocument(parsed)
+}
+
+// indexCache returns the on-disk cache of parsed symbols of project, if enabled.
+func (h *Server) indexCache(project *Project) option.Option[*index_cache.Cache] {
+	if !project.options.Cache.Enabled {
+		return option.None[*index_cache.Cache]()
+	}
+
+	cachePath := project.options.Cache.Path
+	if cachePath.IsNone() {
+		defaultPath, err := index_cache.GetDefaultCachePath()
+		if err != nil {
+			h.server.Log.Warningf("Index cache disabled, could not determine cache directory: %v", err)
+			return option.None[*index_cache.Cache]()
+		}
+		cachePath = option.Some(defaultPath)
+	}
+
+	return option.Some(index_cache.NewCache(cachePath.Get(), h.version))
+}
+
+// dependencyFiles reads the project.json file in the workspace root,
 // resolves declared dependencies by searching dependency-search-paths
-// for .c3l library directories, and indexes all .c3/.c3i files found
+// for .c3l library directories, and returns all .c3/.c3i files found
 // in those libraries.
-func (h *Server) indexDependencies(projectDir string) {
+func (h *Server) dependencyFiles(projectDir string) []string {
 	config, err := fs.ReadC3ProjectConfig(projectDir)
 	if err != nil {
 		h.server.Log.Warningf("Failed to read project.json: %v", err)
-		return
+		return nil
 	}
 
 	if config == nil {
 		// No project.json found — nothing to resolve
-		return
+		return nil
 	}
 
 	if len(config.Dependencies) == 0 {
-		return
+		return nil
 	}
 
 	h.server.Log.Infof("project.json: found %d dependencies: %v", len(config.Dependencies), config.Dependencies)
 	h.server.Log.Infof("project.json: dependency search paths: %v", config.DependencySearchPaths)
 
 	resolutions := fs.ResolveDependencies(projectDir, config)
+	files := []string{}
 
 	for _, res := range resolutions {
 		if !res.Found {
@@ -128,14 +360,8 @@ func (h *Server) indexDependencies(projectDir string) {
 
 		h.server.Log.Infof("  Found %d source files in dependency '%s'", len(depFiles), res.Name)
 
-		for _, filePath := range depFiles {
-			content, err := os.ReadFile(filePath)
-			if err != nil {
-				h.server.Log.Warningf("  Failed to read %s: %v", filePath, err)
-				continue
-			}
-			doc := document.NewDocumentFromString(filePath, string(content))
-			h.state.RefreshDocumentIdentifiers(&doc, h.parser)
-		}
+		files = append(files, depFiles...)
 	}
+
+	return files
 }
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"github.com/pherrymason/c3-lsp/internal/lsp/call_hierarchy"
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/pkg/cast"
+	"github.com/pherrymason/c3-lsp/pkg/fs"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Support "Call hierarchy"
+// Prepares the function or macro declared or called at the position.
+func (h *Server) TextDocumentPrepareCallHierarchy(context *glsp.Context, params *protocol.CallHierarchyPrepareParams) ([]protocol.CallHierarchyItem, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := h.projectFor(docId)
+
+	resolved := h.search.FindSymbolDeclarationInWorkspace(docId, symbols.NewPositionFromLSPPosition(params.Position), project.state)
+	if resolved.IsNone() {
+		return nil, nil
+	}
+	function, ok := resolved.Get().(*symbols.Function)
+	if !ok || !hasLocation(project, function) {
+		return nil, nil
+	}
+
+	return []protocol.CallHierarchyItem{h.callHierarchyItem(project, function)}, nil
+}
+
+// Support "Call hierarchy incoming calls"
+func (h *Server) CallHierarchyIncomingCalls(context *glsp.Context, params *protocol.CallHierarchyIncomingCallsParams) ([]protocol.CallHierarchyIncomingCall, error) {
+	project, function := h.callHierarchyFunction(params.Item)
+	if function == nil {
+		return nil, nil
+	}
+
+	incoming := []protocol.CallHierarchyIncomingCall{}
+	for _, call := range call_hierarchy.IncomingCalls(function, project.state, h.search) {
+		incoming = append(incoming, protocol.CallHierarchyIncomingCall{
+			From:       h.callHierarchyItem(project, call.Function),
+			FromRanges: callRanges(call),
+		})
+	}
+
+	return incoming, nil
+}
+
+// Support "Call hierarchy outgoing calls"
+func (h *Server) CallHierarchyOutgoingCalls(context *glsp.Context, params *protocol.CallHierarchyOutgoingCallsParams) ([]protocol.CallHierarchyOutgoingCall, error) {
+	project, function := h.callHierarchyFunction(params.Item)
+	if function == nil {
+		return nil, nil
+	}
+
+	outgoing := []protocol.CallHierarchyOutgoingCall{}
+	for _, call := range call_hierarchy.OutgoingCalls(function, project.state, h.search) {
+		if !hasLocation(project, call.Function) {
+			continue
+		}
+
+		outgoing = append(outgoing, protocol.CallHierarchyOutgoingCall{
+			To:         h.callHierarchyItem(project, call.Function),
+			FromRanges: callRanges(call),
+		})
+	}
+
+	return outgoing, nil
+}
+
+// callHierarchyFunction finds the function an item prepared before refers to.
+func (h *Server) callHierarchyFunction(item protocol.CallHierarchyItem) (*Project, *symbols.Function) {
+	docId := utils.NormalizePath(item.URI)
+	project := h.projectFor(docId)
+
+	return project, call_hierarchy.FunctionDeclaredAt(project.state, docId, symbols.NewPositionFromLSPPosition(item.SelectionRange.Start))
+}
+
+func (h *Server) callHierarchyItem(project *Project, function *symbols.Function) protocol.CallHierarchyItem {
+	kind := protocol.SymbolKindFunction
+	if function.GetTypeIdentifier() != "" {
+		kind = protocol.SymbolKindMethod
+	}
+
+	return protocol.CallHierarchyItem{
+		Name:           function.GetName(),
+		Kind:           kind,
+		Detail:         cast.ToPtr(function.GetModuleString()),
+		URI:            fs.ConvertPathToURI(function.GetDocumentURI(), project.options.C3.StdlibPath),
+		Range:          _prot.Lsp_NewRangeFromRange(function.GetDocumentRange()),
+		SelectionRange: _prot.Lsp_NewRangeFromRange(function.GetIdRange()),
+	}
+}
+
+// hasLocation tells if the client can be sent to the source code of symbol.
+func hasLocation(project *Project, symbol symbols.Indexable) bool {
+	return symbol.HasSourceCode() || project.options.C3.StdlibPath.IsSome()
+}
+
+func callRanges(call call_hierarchy.Call) []protocol.Range {
+	ranges := []protocol.Range{}
+	for _, callRange := range call.Ranges {
+		ranges = append(ranges, _prot.Lsp_NewRangeFromRange(callRange))
+	}
+
+	return ranges
+}
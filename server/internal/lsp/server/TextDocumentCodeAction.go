@@ -0,0 +1,33 @@
+package server
+
+import (
+	"github.com/pherrymason/c3-lsp/internal/lsp/code_actions"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Support "Code actions"
+// Quick fixes are computed for the start of the requested range.
+func (h *Server) TextDocumentCodeAction(context *glsp.Context, params *protocol.CodeActionParams) (any, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := h.projectFor(docId)
+	doc := project.state.GetDocument(docId)
+	if doc == nil {
+		return nil, nil
+	}
+
+	request := code_actions.Request{
+		Doc:         doc,
+		URI:         params.TextDocument.URI,
+		Position:    params.Range.Start,
+		Diagnostics: params.Context.Diagnostics,
+	}
+
+	actions := []protocol.CodeAction{}
+	actions = append(actions, code_actions.ImportFixes(request, project.state, h.search)...)
+	actions = append(actions, code_actions.InterfaceStubs(request, project.state)...)
+	actions = append(actions, code_actions.SwitchCases(request, project.state, h.search)...)
+
+	return actions, nil
+}
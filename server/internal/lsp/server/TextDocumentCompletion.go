@@ -10,16 +10,18 @@ import (
 // Support "Completion"
 // Returns: []CompletionItem | CompletionList | nil
 func (h *Server) TextDocumentCompletion(context *glsp.Context, params *protocol.CompletionParams) (any, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	state := h.projectFor(docId).state
 
 	cursorContext := ctx.BuildFromDocumentPosition(
 		params.Position,
-		utils.NormalizePath(params.TextDocument.URI),
-		h.state,
+		docId,
+		state,
 	)
 
 	suggestions := h.search.BuildCompletionList(
 		cursorContext,
-		h.state,
+		state,
 	)
 	return suggestions, nil
 }
@@ -11,10 +11,12 @@ import (
 
 // Support "Go to declaration"
 func (h *Server) TextDocumentDeclaration(context *glsp.Context, params *protocol.DeclarationParams) (any, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := h.projectFor(docId)
 	identifierOption := h.search.FindSymbolDeclarationInWorkspace(
-		utils.NormalizePath(params.TextDocument.URI),
+		docId,
 		symbols.NewPositionFromLSPPosition(params.Position),
-		h.state,
+		project.state,
 	)
 
 	if identifierOption.IsNone() {
@@ -22,12 +24,12 @@ func (h *Server) TextDocumentDeclaration(context *glsp.Context, params *protocol
 	}
 
 	symbol := identifierOption.Get()
-	if !symbol.HasSourceCode() && h.options.C3.StdlibPath.IsNone() {
+	if !symbol.HasSourceCode() && project.options.C3.StdlibPath.IsNone() {
 		return nil, nil
 	}
 
 	return protocol.Location{
-		URI:   fs.ConvertPathToURI(symbol.GetDocumentURI(), h.options.C3.StdlibPath),
+		URI:   fs.ConvertPathToURI(symbol.GetDocumentURI(), project.options.C3.StdlibPath),
 		Range: _prot.Lsp_NewRangeFromRange(symbol.GetIdRange()),
 	}, nil
 }
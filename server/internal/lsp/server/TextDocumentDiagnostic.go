@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+	protocol317 "github.com/tliron/glsp/protocol_3_17"
+)
+
+// Support "Pull diagnostics"
+// Returns the diagnostics known for the document, the same ones that would be published.
+// When they did not change since the report identified by previousResultId, the client is told to keep it.
+func (h *Server) TextDocumentDiagnostic(context *glsp.Context, params *protocol317.DocumentDiagnosticParams) (any, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := h.projectFor(docId)
+
+	items := h.pulledDiagnostics(project, docId)
+	resultId := diagnosticsResultId(items)
+	if params.PreviousResultId != nil && *params.PreviousResultId == resultId {
+		return protocol317.RelatedUnchangedDocumentDiagnosticReport{
+			UnchangedDocumentDiagnosticReport: protocol317.UnchangedDocumentDiagnosticReport{
+				Kind:     string(protocol317.DocumentDiagnosticReportKindUnchanged),
+				ResultID: resultId,
+			},
+		}, nil
+	}
+
+	return protocol317.RelatedFullDocumentDiagnosticReport{
+		FullDocumentDiagnosticReport: protocol317.FullDocumentDiagnosticReport{
+			Kind:     string(protocol317.DocumentDiagnosticReportKindFull),
+			ResultID: &resultId,
+			Items:    items,
+		},
+	}, nil
+}
+
+func (h *Server) pulledDiagnostics(project *Project, docId string) []protocol.Diagnostic {
+	if !project.options.Diagnostics.Enabled {
+		return []protocol.Diagnostic{}
+	}
+
+	return project.state.GetMergedDocumentDiagnostics(docId)
+}
+
+// diagnosticsResultId identifies a report by its contents, so an unchanged report gets the same
+// id even if the diagnostics were recalculated in between.
+func diagnosticsResultId(diagnostics []protocol.Diagnostic) string {
+	encoded, _ := json.Marshal(diagnostics)
+	hash := fnv.New64a()
+	hash.Write(encoded)
+
+	return strconv.FormatUint(hash.Sum64(), 16)
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+	protocol317 "github.com/tliron/glsp/protocol_3_17"
+)
+
+func diagnostic(line uint32, message string) protocol.Diagnostic {
+	return protocol.Diagnostic{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: 0},
+			End:   protocol.Position{Line: line, Character: 1},
+		},
+		Message: message,
+	}
+}
+
+func pullDocumentDiagnostics(t *testing.T, h *Server, previousResultId *string) any {
+	result, err := h.TextDocumentDiagnostic(nil, &protocol317.DocumentDiagnosticParams{
+		TextDocument:     protocol.TextDocumentIdentifier{URI: testDocURI("app.c3")},
+		PreviousResultId: previousResultId,
+	})
+	assert.NoError(t, err)
+
+	return result
+}
+
+func TestTextDocumentDiagnostic(t *testing.T) {
+	h, project := newTestServer(t, map[string]string{"app.c3": "module app;"})
+	project.options.Diagnostics.Enabled = true
+	project.state.SetDocumentDiagnostics(testDocId("app.c3"), []protocol.Diagnostic{diagnostic(0, "c3c error")})
+	project.state.SetDocumentServerDiagnostics(testDocId("app.c3"), []protocol.Diagnostic{diagnostic(0, "lint")})
+
+	first := pullDocumentDiagnostics(t, h, nil).(protocol317.RelatedFullDocumentDiagnosticReport)
+
+	t.Run("Reports the diagnostics found by the server and by c3c", func(t *testing.T) {
+		assert.Equal(t, string(protocol317.DocumentDiagnosticReportKindFull), first.Kind)
+		assert.Equal(t, []protocol.Diagnostic{diagnostic(0, "lint"), diagnostic(0, "c3c error")}, first.Items)
+		assert.NotNil(t, first.ResultID)
+	})
+
+	t.Run("Reports unchanged when diagnostics are the same of the previous result", func(t *testing.T) {
+		// Recalculated diagnostics keep the result id while their contents don't change.
+		project.state.SetDocumentServerDiagnostics(testDocId("app.c3"), []protocol.Diagnostic{diagnostic(0, "lint")})
+
+		result := pullDocumentDiagnostics(t, h, first.ResultID)
+
+		assert.Equal(t, protocol317.RelatedUnchangedDocumentDiagnosticReport{
+			UnchangedDocumentDiagnosticReport: protocol317.UnchangedDocumentDiagnosticReport{
+				Kind:     string(protocol317.DocumentDiagnosticReportKindUnchanged),
+				ResultID: *first.ResultID,
+			},
+		}, result)
+	})
+
+	t.Run("Reports full diagnostics with a new result id when they changed", func(t *testing.T) {
+		project.state.SetDocumentServerDiagnostics(testDocId("app.c3"), nil)
+
+		result := pullDocumentDiagnostics(t, h, first.ResultID).(protocol317.RelatedFullDocumentDiagnosticReport)
+
+		assert.Equal(t, []protocol.Diagnostic{diagnostic(0, "c3c error")}, result.Items)
+		assert.NotEqual(t, *first.ResultID, *result.ResultID)
+	})
+
+	t.Run("Reports full diagnostics when the previous result id is unknown", func(t *testing.T) {
+		unknown := "unknown"
+
+		result := pullDocumentDiagnostics(t, h, &unknown)
+
+		assert.IsType(t, protocol317.RelatedFullDocumentDiagnosticReport{}, result)
+	})
+}
+
+func TestTextDocumentDiagnostic_reports_nothing_when_diagnostics_are_disabled(t *testing.T) {
+	h, project := newTestServer(t, map[string]string{"app.c3": "module app;"})
+	project.options.Diagnostics.Enabled = false
+	project.state.SetDocumentDiagnostics(testDocId("app.c3"), []protocol.Diagnostic{diagnostic(0, "c3c error")})
+
+	result := pullDocumentDiagnostics(t, h, nil).(protocol317.RelatedFullDocumentDiagnosticReport)
+
+	assert.Empty(t, result.Items)
+}
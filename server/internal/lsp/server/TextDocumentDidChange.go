@@ -1,14 +1,18 @@
 package server
 
 import (
+	"github.com/pherrymason/c3-lsp/pkg/utils"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 func (s *Server) TextDocumentDidChange(context *glsp.Context, params *protocol.DidChangeTextDocumentParams) error {
-	s.state.UpdateDocument(params.TextDocument.URI, params.ContentChanges, s.parser)
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := s.projectFor(docId)
+	project.state.UpdateDocument(params.TextDocument.URI, params.ContentChanges, s.parser)
+	s.refreshServerDiagnostics(project, context.Notify, docId)
 
-	s.RunDiagnostics(s.state, context.Notify, true)
+	s.RunDiagnostics(project, context.Notify, true)
 
 	return nil
 }
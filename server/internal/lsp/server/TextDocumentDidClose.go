@@ -1,11 +1,28 @@
 package server
 
 import (
+	"os"
+
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 func (h *Server) TextDocumentDidClose(context *glsp.Context, params *protocol.DidCloseTextDocumentParams) error {
-	h.state.CloseDocument(params.TextDocument.URI)
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := h.projectFor(docId)
+	state := project.state
+	state.CloseDocument(params.TextDocument.URI)
+	h.semanticTokens.Forget(docId)
+	h.clearServerDiagnostics(project, context.Notify, docId)
+
+	// A closed file is still part of the workspace: reload its saved content
+	// so its symbols and references keep being resolvable.
+	if content, err := os.ReadFile(docId); err == nil {
+		doc := document.NewDocumentFromString(docId, string(content))
+		state.RefreshDocumentIdentifiers(&doc, h.parser)
+	}
+
 	return nil
 }
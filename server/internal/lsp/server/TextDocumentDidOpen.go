@@ -25,7 +25,10 @@ func (h *Server) TextDocumentDidOpen(context *glsp.Context, params *protocol.Did
 	}
 
 	doc := document.NewDocumentFromDocURI(params.TextDocument.URI, params.TextDocument.Text, params.TextDocument.Version)
-	h.state.RefreshDocumentIdentifiers(doc, h.parser)
+	project := h.projectFor(doc.URI)
+	project.state.RefreshDocumentIdentifiers(doc, h.parser)
+	project.state.MarkDocumentOpened(doc.URI)
+	h.refreshServerDiagnostics(project, context.Notify, doc.URI)
 
 	return nil
 }
@@ -1,12 +1,13 @@
 package server
 
 import (
+	"github.com/pherrymason/c3-lsp/pkg/utils"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 // Support "Hover"
 func (s *Server) TextDocumentDidSave(ctx *glsp.Context, params *protocol.DidSaveTextDocumentParams) error {
-	s.RunDiagnostics(s.state, ctx.Notify, true)
+	s.RunDiagnostics(s.projectFor(utils.NormalizePath(params.TextDocument.URI)), ctx.Notify, true)
 	return nil
 }
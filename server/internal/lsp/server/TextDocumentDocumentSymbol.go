@@ -0,0 +1,230 @@
+package server
+
+import (
+	"sort"
+
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/pkg/cast"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Support "Document symbols"
+// Returns: []DocumentSymbol | []SymbolInformation | nil
+func (h *Server) TextDocumentDocumentSymbol(context *glsp.Context, params *protocol.DocumentSymbolParams) (any, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	state := h.projectFor(docId).state
+	if state.GetDocument(docId) == nil {
+		return nil, nil
+	}
+
+	documentSymbols := []protocol.DocumentSymbol{}
+	for _, module := range state.GetUnitModulesByDoc(docId).Modules() {
+		documentSymbols = append(documentSymbols, moduleToDocumentSymbol(module))
+	}
+
+	return documentSymbols, nil
+}
+
+// outlineNode is a DocumentSymbol under construction.
+type outlineNode struct {
+	symbol   protocol.DocumentSymbol
+	children []*outlineNode
+	methodOf string // Type of the method the node is, if any
+}
+
+func moduleToDocumentSymbol(module *symbols.Module) protocol.DocumentSymbol {
+	root := newOutlineNode(module.GetName(), protocol.SymbolKindModule, "", module.GetDocumentRange(), module.GetIdRange())
+
+	for _, variable := range module.Variables {
+		root.children = append(root.children, variableNode(variable))
+	}
+
+	for _, enum := range module.Enums {
+		node := indexableNode(enum, protocol.SymbolKindEnum, enum.GetType())
+		for _, enumerator := range enum.GetEnumerators() {
+			node.children = append(node.children, indexableNode(enumerator, protocol.SymbolKindEnumMember, ""))
+		}
+		root.children = append(root.children, node)
+	}
+
+	for _, fault := range module.Faults {
+		// Since C3 0.7 faultdef constants are not grouped under a name.
+		for _, constant := range fault.GetConstants() {
+			root.children = append(root.children, indexableNode(constant, protocol.SymbolKindConstant, "fault"))
+		}
+	}
+
+	for _, strukt := range module.Structs {
+		detail := "struct"
+		if strukt.IsUnion() {
+			detail = "union"
+		}
+		node := indexableNode(strukt, protocol.SymbolKindStruct, detail)
+		node.children = membersNodes(strukt.GetMembers(), strukt.GetDocumentRange())
+		root.children = append(root.children, node)
+	}
+
+	for _, bitstruct := range module.Bitstructs {
+		node := indexableNode(bitstruct, protocol.SymbolKindStruct, "bitstruct : "+bitstruct.Type().String())
+		node.children = membersNodes(bitstruct.Members(), bitstruct.GetDocumentRange())
+		root.children = append(root.children, node)
+	}
+
+	for _, _interface := range module.Interfaces {
+		node := indexableNode(_interface, protocol.SymbolKindInterface, "interface")
+		for _, child := range _interface.Children() {
+			if method, ok := child.(*symbols.Function); ok {
+				node.children = append(node.children, functionNode(method))
+			}
+		}
+		root.children = append(root.children, node)
+	}
+
+	for _, def := range module.Defs {
+		root.children = append(root.children, indexableNode(def, protocol.SymbolKindTypeParameter, def.GetResolvesTo()))
+	}
+
+	for _, distinct := range module.Distincts {
+		root.children = append(root.children, indexableNode(distinct, protocol.SymbolKindTypeParameter, distinct.GetBaseType().String()))
+	}
+
+	for _, function := range module.ChildrenFunctions {
+		node := functionNode(function)
+		node.methodOf = function.GetTypeIdentifier()
+		root.children = append(root.children, node)
+	}
+
+	sortNodes(root.children)
+	root.children = groupMethods(root.children)
+
+	return root.build()
+}
+
+// groupMethods puts consecutive methods of the same type under a container named as the
+// type. Methods are declared apart from their type, so they can't be placed under it:
+// the range of a symbol must only contain its own declaration.
+func groupMethods(nodes []*outlineNode) []*outlineNode {
+	grouped := []*outlineNode{}
+	var container *outlineNode
+	for _, node := range nodes {
+		if node.methodOf == "" {
+			grouped = append(grouped, node)
+			container = nil
+			continue
+		}
+
+		if container == nil || container.symbol.Name != node.methodOf {
+			container = &outlineNode{
+				symbol: protocol.DocumentSymbol{
+					Name:           node.methodOf,
+					Kind:           protocol.SymbolKindClass,
+					Detail:         cast.ToPtr("methods"),
+					Range:          node.symbol.Range,
+					SelectionRange: node.symbol.SelectionRange,
+				},
+			}
+			grouped = append(grouped, container)
+		}
+		container.children = append(container.children, node)
+		container.symbol.Range.End = node.symbol.Range.End
+	}
+
+	return grouped
+}
+
+func newOutlineNode(name string, kind protocol.SymbolKind, detail string, docRange symbols.Range, idRange symbols.Range) *outlineNode {
+	if docRange == symbols.NewRange(0, 0, 0, 0) {
+		// Some symbols, like struct members, only know where their identifier is.
+		docRange = idRange
+	}
+	if name == "" {
+		name = "<anonymous>"
+	}
+
+	node := &outlineNode{
+		symbol: protocol.DocumentSymbol{
+			Name:           name,
+			Kind:           kind,
+			Range:          _prot.Lsp_NewRangeFromRange(docRange),
+			SelectionRange: _prot.Lsp_NewRangeFromRange(idRange),
+		},
+	}
+	if detail != "" {
+		node.symbol.Detail = cast.ToPtr(detail)
+	}
+
+	return node
+}
+
+func indexableNode(symbol symbols.Indexable, kind protocol.SymbolKind, detail string) *outlineNode {
+	return newOutlineNode(symbol.GetName(), kind, detail, symbol.GetDocumentRange(), symbol.GetIdRange())
+}
+
+func variableNode(variable *symbols.Variable) *outlineNode {
+	kind := protocol.SymbolKindVariable
+	if variable.GetKind() == protocol.CompletionItemKindConstant {
+		kind = protocol.SymbolKindConstant
+	}
+
+	return indexableNode(variable, kind, variable.GetType().String())
+}
+
+func functionNode(function *symbols.Function) *outlineNode {
+	kind := protocol.SymbolKindFunction
+	if function.GetTypeIdentifier() != "" {
+		kind = protocol.SymbolKindMethod
+	}
+
+	return newOutlineNode(function.GetMethodName(), kind, function.GetCompletionDetail(), function.GetDocumentRange(), function.GetIdRange())
+}
+
+func membersNodes(members []*symbols.StructMember, parentRange symbols.Range) []*outlineNode {
+	nodes := []*outlineNode{}
+	for _, member := range members {
+		if !parentRange.HasPosition(member.GetIdRange().Start) {
+			// Members inherited from an inlined struct are declared in that struct.
+			continue
+		}
+
+		if member.IsStruct() {
+			node := indexableNode(member, protocol.SymbolKindField, "struct")
+			node.children = membersNodes(member.Substruct().Get().GetMembers(), parentRange)
+			nodes = append(nodes, node)
+			continue
+		}
+
+		nodes = append(nodes, indexableNode(member, protocol.SymbolKindField, member.GetType().String()))
+	}
+
+	return nodes
+}
+
+func sortNodes(nodes []*outlineNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return isPositionBefore(nodes[i].symbol.Range.Start, nodes[j].symbol.Range.Start)
+	})
+}
+
+// build converts the node into a DocumentSymbol, sorting children by their position in the document.
+func (n *outlineNode) build() protocol.DocumentSymbol {
+	symbol := n.symbol
+	if len(n.children) == 0 {
+		return symbol
+	}
+
+	sortNodes(n.children)
+
+	symbol.Children = []protocol.DocumentSymbol{}
+	for _, child := range n.children {
+		symbol.Children = append(symbol.Children, child.build())
+	}
+
+	return symbol
+}
+
+func isPositionBefore(a protocol.Position, b protocol.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func documentSymbols(t *testing.T, source string) []protocol.DocumentSymbol {
+	h, _ := newTestServer(t, map[string]string{"app.c3": source})
+
+	result, err := h.TextDocumentDocumentSymbol(nil, &protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: testDocURI("app.c3")},
+	})
+	assert.NoError(t, err)
+
+	return result.([]protocol.DocumentSymbol)
+}
+
+func childrenNames(symbol protocol.DocumentSymbol) []string {
+	names := []string{}
+	for _, child := range symbol.Children {
+		names = append(names, child.Name)
+	}
+
+	return names
+}
+
+func rangeContains(outer protocol.Range, inner protocol.Range) bool {
+	return !isPositionBefore(inner.Start, outer.Start) && !isPositionBefore(outer.End, inner.End)
+}
+
+// assertChildrenContained checks the range of every symbol contains the ranges of its children.
+func assertChildrenContained(t *testing.T, symbol protocol.DocumentSymbol) {
+	for _, child := range symbol.Children {
+		assert.True(t, rangeContains(symbol.Range, child.Range), "'%s' should contain '%s'", symbol.Name, child.Name)
+		assertChildrenContained(t, child)
+	}
+}
+
+func TestTextDocumentDocumentSymbol(t *testing.T) {
+	source := `module app;
+enum Color { RED, GREEN }
+struct Point { int x; int y; }
+fn void main() {}
+fn void Point.move(&self) {}
+fn void Point.grow(&self) {}
+fn void List.push(&self) {}`
+
+	symbols := documentSymbols(t, source)
+
+	assert.Len(t, symbols, 1)
+	module := symbols[0]
+
+	t.Run("Groups declarations under their module", func(t *testing.T) {
+		assert.Equal(t, "app", module.Name)
+		assert.Equal(t, protocol.SymbolKindModule, module.Kind)
+		assert.Equal(t, []string{"Color", "Point", "main", "Point", "List"}, childrenNames(module))
+	})
+
+	t.Run("Nests enumerators and members under their type", func(t *testing.T) {
+		assert.Equal(t, []string{"RED", "GREEN"}, childrenNames(module.Children[0]))
+		assert.Equal(t, []string{"x", "y"}, childrenNames(module.Children[1]))
+	})
+
+	t.Run("Groups consecutive methods of a type in a container", func(t *testing.T) {
+		container := module.Children[3]
+
+		assert.Equal(t, protocol.SymbolKindClass, container.Kind)
+		assert.Equal(t, []string{"move", "grow"}, childrenNames(container))
+		assert.Equal(t, protocol.SymbolKindMethod, container.Children[0].Kind)
+		assert.Equal(t, protocol.Range{
+			Start: protocol.Position{Line: 4, Character: 0},
+			End:   protocol.Position{Line: 5, Character: 28},
+		}, container.Range)
+		assert.Equal(t, []string{"push"}, childrenNames(module.Children[4]))
+	})
+
+	t.Run("Ranges only contain their own declaration", func(t *testing.T) {
+		assertChildrenContained(t, module)
+		assert.Equal(t, protocol.Range{
+			Start: protocol.Position{Line: 2, Character: 0},
+			End:   protocol.Position{Line: 2, Character: 30},
+		}, module.Children[1].Range)
+	})
+}
+
+func TestTextDocumentDocumentSymbol_splits_methods_declared_apart(t *testing.T) {
+	source := `module app;
+struct Point { int x; }
+fn void Point.move(&self) {}
+fn void main() {}
+fn void Point.grow(&self) {}`
+
+	module := documentSymbols(t, source)[0]
+
+	assert.Equal(t, []string{"Point", "Point", "main", "Point"}, childrenNames(module))
+	assertChildrenContained(t, module)
+	assert.False(t, rangeContains(module.Children[1].Range, module.Children[2].Range), "Container must not span main")
+}
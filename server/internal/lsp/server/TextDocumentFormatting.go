@@ -0,0 +1,56 @@
+package server
+
+import (
+	"github.com/pherrymason/c3-lsp/internal/lsp/formatter"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Support "Format document"
+// Documents with syntax errors are not formatted.
+func (h *Server) TextDocumentFormatting(context *glsp.Context, params *protocol.DocumentFormattingParams) ([]protocol.TextEdit, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := h.projectFor(docId)
+	doc := project.state.GetDocument(docId)
+	if doc == nil {
+		return nil, nil
+	}
+
+	formatted, err := formatter.Format(doc.SourceCode.Text, formattingOptions(project, params.Options))
+	if err != nil {
+		h.server.Log.Infof("Could not format %s: %v", docId, err)
+		return nil, nil
+	}
+
+	return formatter.Edits(doc.SourceCode.Text, formatted), nil
+}
+
+// formattingOptions combines the formatting configuration of project with the settings of the
+// editor sent in formatting requests. The configuration takes precedence.
+func formattingOptions(project *Project, settings protocol.FormattingOptions) formatter.Options {
+	options := formatter.DefaultOptions()
+
+	switch tabSize := settings[protocol.FormattingOptionTabSize].(type) {
+	case float64:
+		options.IndentWidth = int(tabSize)
+	case int:
+		options.IndentWidth = tabSize
+	}
+	if insertSpaces, ok := settings[protocol.FormattingOptionInsertSpaces].(bool); ok {
+		options.UseTabs = !insertSpaces
+	}
+
+	configured := project.options.Formatting
+	if configured.IndentWidth.IsSome() {
+		options.IndentWidth = configured.IndentWidth.Get()
+	}
+	if configured.UseTabs.IsSome() {
+		options.UseTabs = configured.UseTabs.Get()
+	}
+	if configured.MaxLineWidth.IsSome() {
+		options.MaxLineWidth = configured.MaxLineWidth.Get()
+	}
+
+	return options.Normalized()
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 
+	"github.com/pherrymason/c3-lsp/internal/lsp/layout"
 	"github.com/pherrymason/c3-lsp/pkg/symbols"
 	"github.com/pherrymason/c3-lsp/pkg/utils"
 	"github.com/tliron/glsp"
@@ -13,7 +14,8 @@ import (
 func (h *Server) TextDocumentHover(context *glsp.Context, params *protocol.HoverParams) (*protocol.Hover, error) {
 	pos := symbols.NewPositionFromLSPPosition(params.Position)
 	docId := utils.NormalizePath(params.TextDocument.URI)
-	foundSymbolOption := h.search.FindSymbolDeclarationInWorkspace(docId, pos, h.state)
+	project := h.projectFor(docId)
+	foundSymbolOption := h.search.FindSymbolDeclarationInWorkspace(docId, pos, project.state)
 	if foundSymbolOption.IsNone() {
 		return nil, nil
 	}
@@ -39,9 +41,12 @@ func (h *Server) TextDocumentHover(context *glsp.Context, params *protocol.Hover
 	}
 
 	sizeInfo := ""
-	if utils.IsFeatureEnabled("SIZE_ON_HOVER") {
-		if hasSize(foundSymbol) {
-			sizeInfo = "// size = " + calculateSize(foundSymbol) + ", align = " + calculateAlignment(foundSymbol) + "\n"
+	members := ""
+	symbolLayout := layout.NewCalculator(layoutTarget(project), project.state).SymbolLayout(foundSymbol)
+	if symbolLayout.IsSome() {
+		sizeInfo = fmt.Sprintf("// size = %d, align = %d\n", symbolLayout.Get().Size, symbolLayout.Get().Align)
+		if _, isVariable := foundSymbol.(*symbols.Variable); !isVariable {
+			members = layoutTable(symbolLayout.Get())
 		}
 	}
 
@@ -52,6 +57,7 @@ func (h *Server) TextDocumentHover(context *glsp.Context, params *protocol.Hover
 				sizeInfo +
 				foundSymbol.GetHoverInfo() + "\n```" +
 				extraLine +
+				members +
 				docComment,
 		},
 	}
@@ -59,113 +65,35 @@ func (h *Server) TextDocumentHover(context *glsp.Context, params *protocol.Hover
 	return &hover, nil
 }
 
-const (
-	UNKNOWN = iota
-	VAR
-	STRUCT
-	STRUCT_MEMBER
-	BITSTRUCT
-	FAULT
-	ENUM
-)
-
-func typeOfSymbol(symbol symbols.Indexable) uint {
-	_, isVariable := symbol.(*symbols.Variable)
-	if isVariable {
-		return VAR
-	}
-
-	_, isMember := symbol.(*symbols.StructMember)
-	if isMember {
-		return STRUCT_MEMBER
-	}
-
-	_, isStruct := symbol.(*symbols.Struct)
-	if isStruct {
-		return STRUCT
-	}
+// layoutTarget returns the target whose ABI is used to compute the layout of types in project.
+func layoutTarget(project *Project) layout.Target {
+	target, _ := layout.TargetByName(project.options.C3.Target.GetOrElse(""))
 
-	_, isBitStruct := symbol.(*symbols.Bitstruct)
-	if isBitStruct {
-		return BITSTRUCT
-	}
-
-	_, isFault := symbol.(*symbols.Fault)
-	if isFault {
-		return FAULT
-	}
-	_, isEnum := symbol.(*symbols.Enum)
-	if isEnum {
-		return ENUM
-	}
-
-	return UNKNOWN
+	return target
 }
 
-func hasSize(symbol symbols.Indexable) bool {
-	kind := typeOfSymbol(symbol)
-
-	sizeableKinds := []uint{VAR, STRUCT, STRUCT_MEMBER, BITSTRUCT, FAULT, ENUM}
-	for _, v := range sizeableKinds {
-		if v == kind {
-			return true
-		}
+// layoutTable lists the members of a struct, union or bitstruct with their offsets, padding included.
+func layoutTable(symbolLayout layout.Layout) string {
+	if len(symbolLayout.Members) == 0 {
+		return ""
 	}
 
-	return false
-}
-
-func calculateSize(symbol symbols.Indexable) string {
-
-	switch typeOfSymbol(symbol) {
-	case VAR:
-		variable := symbol.(*symbols.Variable)
-		if variable.Type.IsPointer() {
-			return fmt.Sprintf("%d", utils.PointerSize())
-		}
-
-		if variable.Type.IsBaseTypeLanguage() {
-			return fmt.Sprintf("%d", getLanguageTypeSize(variable.Type.GetName()))
-		}
-
-	case STRUCT_MEMBER:
-		member := symbol.(*symbols.StructMember)
-		if member.GetType().IsPointer() {
-			return fmt.Sprintf("%d", utils.PointerSize())
+	table := "\n\n| Offset | Member | Type | Size |\n|---:|---|---|---:|\n"
+	for _, member := range symbolLayout.Members {
+		offset := fmt.Sprintf("%d", member.Offset)
+		size := fmt.Sprintf("%d", member.Size)
+		if member.BitRange.IsSome() {
+			bits := member.BitRange.Get()
+			offset += fmt.Sprintf(" (bits %d..%d)", bits[0], bits[1])
+			size = fmt.Sprintf("%d bits", bits[1]-bits[0]+1)
 		}
 
-		if member.GetType().IsBaseTypeLanguage() {
-			return fmt.Sprintf("%d", getLanguageTypeSize(member.GetType().GetName()))
+		if member.Padding {
+			table += fmt.Sprintf("| %s | *padding* | | %s |\n", offset, size)
+		} else {
+			table += fmt.Sprintf("| %s | %s | `%s` | %s |\n", offset, member.Name, member.Type, size)
 		}
 	}
 
-	return "?"
-}
-
-func calculateAlignment(symbol symbols.Indexable) string {
-	return ""
-}
-
-func getLanguageTypeSize(typeName string) uint {
-	size := uint(0)
-	switch typeName {
-	case "bool":
-		size = 1
-	case "ichar", "char":
-		size = 1
-	case "short", "ushort":
-		size = 16 / 8
-	case "int", "uint":
-		size = 32 / 8
-	case "long", "ulong":
-		size = 64 / 8
-	case "int128", "uint128":
-		size = 128 / 8
-	case "iptr", "uptr":
-		size = utils.PointerSize()
-	case "isz", "usz":
-		size = 0
-	}
-
-	return size
+	return table
 }
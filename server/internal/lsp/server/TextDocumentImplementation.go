@@ -0,0 +1,36 @@
+package server
+
+import (
+	"github.com/pherrymason/c3-lsp/internal/lsp/implementation"
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/pkg/fs"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Support "Go to implementation"
+// Lists the structs implementing an interface, or the @dynamic methods implementing an
+// interface method, also when called through an `any` value.
+func (h *Server) TextDocumentImplementation(context *glsp.Context, params *protocol.ImplementationParams) (any, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := h.projectFor(docId)
+	if project.state.GetDocument(docId) == nil {
+		return nil, nil
+	}
+
+	locations := []protocol.Location{}
+	for _, symbol := range implementation.ImplementationsAt(docId, symbols.NewPositionFromLSPPosition(params.Position), project.state, h.search) {
+		if !hasLocation(project, symbol) {
+			continue
+		}
+
+		locations = append(locations, protocol.Location{
+			URI:   fs.ConvertPathToURI(symbol.GetDocumentURI(), project.options.C3.StdlibPath),
+			Range: _prot.Lsp_NewRangeFromRange(symbol.GetIdRange()),
+		})
+	}
+
+	return locations, nil
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"github.com/pherrymason/c3-lsp/internal/lsp/inlay_hints"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Messages of inlay hints, added in LSP 3.17, that glsp does not implement.
+const (
+	MethodTextDocumentInlayHint = protocol.Method("textDocument/inlayHint")
+	MethodInlayHintResolve      = protocol.Method("inlayHint/resolve")
+)
+
+type TextDocumentInlayHintFunc func(context *glsp.Context, params *InlayHintParams) ([]InlayHint, error)
+
+type InlayHintResolveFunc func(context *glsp.Context, params *InlayHint) (*InlayHint, error)
+
+type InlayHintOptions struct {
+	ResolveProvider bool `json:"resolveProvider,omitempty"`
+}
+
+type InlayHintParams struct {
+	protocol.WorkDoneProgressParams
+
+	TextDocument protocol.TextDocumentIdentifier `json:"textDocument"`
+	// The visible document range for which inlay hints should be computed.
+	Range protocol.Range `json:"range"`
+}
+
+type InlayHintKind protocol.UInteger
+
+const (
+	InlayHintKindType      = InlayHintKind(1)
+	InlayHintKindParameter = InlayHintKind(2)
+)
+
+type InlayHint struct {
+	Position protocol.Position `json:"position"`
+	// Label parts are never sent, so it is always a string.
+	Label        string            `json:"label"`
+	Kind         *InlayHintKind    `json:"kind,omitempty"`
+	Tooltip      any               `json:"tooltip,omitempty"` // nil | string | MarkupContent
+	PaddingLeft  bool              `json:"paddingLeft,omitempty"`
+	PaddingRight bool              `json:"paddingRight,omitempty"`
+	Data         *inlay_hints.Data `json:"data,omitempty"`
+}
+
+// Support "Inlay hints"
+// Hints are computed for the visible range. Their tooltips are left to inlayHint/resolve.
+func (h *Server) TextDocumentInlayHint(context *glsp.Context, params *InlayHintParams) ([]InlayHint, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := h.projectFor(docId)
+	doc := project.state.GetDocument(docId)
+	if doc == nil {
+		return nil, nil
+	}
+
+	options := inlay_hints.Options{
+		ParameterNames: project.options.InlayHints.ParameterNames,
+		InferredTypes:  project.options.InlayHints.InferredTypes,
+		EnumValues:     project.options.InlayHints.EnumValues,
+	}
+	visible := symbols.NewRange(
+		uint(params.Range.Start.Line), uint(params.Range.Start.Character),
+		uint(params.Range.End.Line), uint(params.Range.End.Character),
+	)
+
+	hints := []InlayHint{}
+	for _, hint := range inlay_hints.Hints(doc, visible, project.state, h.search, options) {
+		inlayHint := InlayHint{
+			Position:     hint.Position.ToLSPPosition(),
+			Label:        hint.Label,
+			PaddingLeft:  hint.PaddingLeft,
+			PaddingRight: hint.PaddingRight,
+		}
+		if hint.Kind != 0 {
+			kind := InlayHintKind(hint.Kind)
+			inlayHint.Kind = &kind
+		}
+		if hint.Data.Callee != nil || hint.Data.Type != "" {
+			data := hint.Data
+			inlayHint.Data = &data
+		}
+
+		hints = append(hints, inlayHint)
+	}
+
+	return hints, nil
+}
+
+// Support "Inlay hint resolve"
+// Adds the documentation of the called function or the inferred type as tooltip.
+func (h *Server) InlayHintResolve(context *glsp.Context, params *InlayHint) (*InlayHint, error) {
+	if params.Data == nil {
+		return params, nil
+	}
+
+	project := h.projectFor(params.Data.URI)
+	if tooltip := inlay_hints.Tooltip(*params.Data, project.state, h.search); tooltip != "" {
+		params.Tooltip = protocol.MarkupContent{
+			Kind:  protocol.MarkupKindMarkdown,
+			Value: tooltip,
+		}
+	}
+
+	return params, nil
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"github.com/pherrymason/c3-lsp/internal/lsp/formatter"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Characters that trigger formatting while typing: the end of blocks and statements.
+var onTypeFormattingTriggers = []string{"}", ";"}
+
+// Support "Format on type"
+// The top level declaration where the character was typed is formatted.
+func (h *Server) TextDocumentOnTypeFormatting(context *glsp.Context, params *protocol.DocumentOnTypeFormattingParams) ([]protocol.TextEdit, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := h.projectFor(docId)
+	doc := project.state.GetDocument(docId)
+	if doc == nil {
+		return nil, nil
+	}
+
+	return formatter.FormatRange(doc.SourceCode.Text, params.Position.Line, params.Position.Line, formattingOptions(project, params.Options)), nil
+}
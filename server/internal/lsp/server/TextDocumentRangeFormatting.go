@@ -0,0 +1,21 @@
+package server
+
+import (
+	"github.com/pherrymason/c3-lsp/internal/lsp/formatter"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Support "Format selection"
+// The top level declarations touched by the range are formatted entirely.
+func (h *Server) TextDocumentRangeFormatting(context *glsp.Context, params *protocol.DocumentRangeFormattingParams) ([]protocol.TextEdit, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := h.projectFor(docId)
+	doc := project.state.GetDocument(docId)
+	if doc == nil {
+		return nil, nil
+	}
+
+	return formatter.FormatRange(doc.SourceCode.Text, params.Range.Start.Line, params.Range.End.Line, formattingOptions(project, params.Options)), nil
+}
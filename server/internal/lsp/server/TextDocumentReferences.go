@@ -0,0 +1,126 @@
+package server
+
+import (
+	"sort"
+	"strings"
+
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/internal/lsp/reference_index"
+	"github.com/pherrymason/c3-lsp/pkg/fs"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Support "Find All References"
+func (h *Server) TextDocumentReferences(context *glsp.Context, params *protocol.ReferenceParams) ([]protocol.Location, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := h.projectFor(docId)
+	identifierOption := h.search.FindSymbolDeclarationInWorkspace(
+		docId,
+		symbols.NewPositionFromLSPPosition(params.Position),
+		project.state,
+	)
+
+	if identifierOption.IsNone() {
+		return nil, nil
+	}
+
+	symbol := identifierOption.Get()
+	references := h.findReferences(project, symbol, params.Context.IncludeDeclaration)
+
+	locations := []protocol.Location{}
+	for _, ref := range references {
+		locations = append(locations, protocol.Location{
+			URI:   fs.ConvertPathToURI(ref.DocId, project.options.C3.StdlibPath),
+			Range: _prot.Lsp_NewRangeFromRange(ref.Range),
+		})
+	}
+
+	if params.Context.IncludeDeclaration && !containsDeclaration(references, symbol) {
+		if symbol.HasSourceCode() || project.options.C3.StdlibPath.IsSome() {
+			locations = append([]protocol.Location{{
+				URI:   fs.ConvertPathToURI(symbol.GetDocumentURI(), project.options.C3.StdlibPath),
+				Range: _prot.Lsp_NewRangeFromRange(symbol.GetIdRange()),
+			}}, locations...)
+		}
+	}
+
+	return locations, nil
+}
+
+// findReferences collects the occurrences in project of every identifier named like symbol,
+// and keeps those that resolve to symbol itself.
+func (h *Server) findReferences(project *Project, symbol symbols.Indexable, includeDeclaration bool) []reference_index.Reference {
+	references := []reference_index.Reference{}
+	for _, candidate := range project.state.SearchReferences(referenceName(symbol)) {
+		if project.state.GetDocument(candidate.DocId) == nil {
+			continue
+		}
+
+		if !includeDeclaration && isDeclaration(candidate, symbol) {
+			continue
+		}
+
+		resolved := h.search.FindSymbolDeclarationInWorkspace(candidate.DocId, candidate.Range.Start, project.state)
+		if resolved.IsNone() || !isSameSymbol(resolved.Get(), symbol) {
+			continue
+		}
+
+		references = append(references, candidate)
+	}
+
+	sort.Slice(references, func(i, j int) bool {
+		if references[i].DocId != references[j].DocId {
+			return references[i].DocId < references[j].DocId
+		}
+
+		return references[j].Range.IsBeforePosition(references[i].Range.Start)
+	})
+
+	return references
+}
+
+// referenceName returns the name a symbol is written with when it is referenced in source code.
+func referenceName(symbol symbols.Indexable) string {
+	if function, ok := symbol.(*symbols.Function); ok {
+		// Methods are referenced without their type prefix: `Foo.bar` is written as `bar`
+		return function.GetMethodName()
+	}
+
+	name := symbol.GetName()
+	if i := strings.LastIndex(name, "::"); i >= 0 {
+		// Modules are referenced by each of their path components: `std::io` -> `io`
+		name = name[i+2:]
+	}
+
+	return name
+}
+
+func isSameSymbol(a symbols.Indexable, b symbols.Indexable) bool {
+	_, aIsModule := a.(*symbols.Module)
+	_, bIsModule := b.(*symbols.Module)
+	if aIsModule || bIsModule {
+		// A module can be split in multiple files.
+		return aIsModule && bIsModule && a.GetName() == b.GetName()
+	}
+
+	return a.GetName() == b.GetName() &&
+		a.GetDocumentURI() == b.GetDocumentURI() &&
+		a.GetIdRange() == b.GetIdRange()
+}
+
+func isDeclaration(ref reference_index.Reference, symbol symbols.Indexable) bool {
+	return ref.DocId == symbol.GetDocumentURI() && ref.Range == symbol.GetIdRange()
+}
+
+func containsDeclaration(references []reference_index.Reference, symbol symbols.Indexable) bool {
+	for _, ref := range references {
+		if isDeclaration(ref, symbol) {
+			return true
+		}
+	}
+
+	return false
+}
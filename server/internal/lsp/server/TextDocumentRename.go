@@ -0,0 +1,194 @@
+package server
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/pkg/fs"
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Naming rules of C3 identifiers, once their sigil (`@`, `$` or `#`) is removed.
+var (
+	typeNamePattern     = regexp.MustCompile(`^_*[A-Z][_A-Z0-9]*[a-z][_a-zA-Z0-9]*$`)
+	constantNamePattern = regexp.MustCompile(`^_*[A-Z][_A-Z0-9]*$`)
+	identifierPattern   = regexp.MustCompile(`^_*[a-z][_a-zA-Z0-9]*$`)
+)
+
+// Support "Prepare rename"
+// Returns: Range | RangeWithPlaceholder | DefaultBehavior | nil
+func (h *Server) TextDocumentPrepareRename(context *glsp.Context, params *protocol.PrepareRenameParams) (any, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	position := symbols.NewPositionFromLSPPosition(params.Position)
+
+	project := h.projectFor(docId)
+	symbolOption, err := h.findRenameableSymbol(project, docId, position)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := project.state.GetDocument(docId)
+	word := doc.SourceCode.SymbolInPosition(position, project.state.GetUnitModulesByDoc(docId))
+	if word.Text() != referenceName(symbolOption.Get()) {
+		return nil, errors.New("the element under cursor can't be renamed")
+	}
+
+	return protocol.RangeWithPlaceholder{
+		Range:       _prot.Lsp_NewRangeFromRange(word.TextRange()),
+		Placeholder: word.Text(),
+	}, nil
+}
+
+// Support "Rename"
+func (h *Server) TextDocumentRename(context *glsp.Context, params *protocol.RenameParams) (*protocol.WorkspaceEdit, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	position := symbols.NewPositionFromLSPPosition(params.Position)
+
+	project := h.projectFor(docId)
+	symbolOption, err := h.findRenameableSymbol(project, docId, position)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := symbolOption.Get()
+	if err := validateNewName(symbol, params.NewName); err != nil {
+		return nil, err
+	}
+
+	changes := map[protocol.DocumentUri][]protocol.TextEdit{}
+	for _, ref := range h.findReferences(project, symbol, true) {
+		if !isWorkspaceDocument(project, ref.DocId) {
+			continue
+		}
+
+		uri := fs.ConvertPathToURI(ref.DocId, project.options.C3.StdlibPath)
+		changes[uri] = append(changes[uri], protocol.TextEdit{
+			Range:   _prot.Lsp_NewRangeFromRange(ref.Range),
+			NewText: params.NewName,
+		})
+	}
+
+	return &protocol.WorkspaceEdit{Changes: changes}, nil
+}
+
+// validateNewName checks newName follows the naming rules of the kind of symbol, and keeps
+// the sigil of its current name: `@` for macros, `$` for compile time and `#` for expression parameters.
+func validateNewName(symbol symbols.Indexable, newName string) error {
+	currentName := referenceName(symbol)
+	sigil := nameSigil(currentName)
+	if nameSigil(newName) != sigil {
+		if sigil == "" {
+			return errors.New("'" + newName + "' can't start with '" + nameSigil(newName) + "'")
+		}
+		return errors.New("'" + newName + "' must start with '" + sigil + "'")
+	}
+
+	pattern, rule := namingRule(symbol, strings.TrimPrefix(currentName, sigil))
+	if !pattern.MatchString(strings.TrimPrefix(newName, sigil)) {
+		return errors.New("'" + newName + "' is not a valid name: " + rule)
+	}
+
+	return nil
+}
+
+func nameSigil(name string) string {
+	if name != "" && strings.ContainsAny(name[:1], "@$#") {
+		return name[:1]
+	}
+
+	return ""
+}
+
+// namingRule returns the pattern the names of symbol must match. Symbols whose kind does
+// not determine it, like aliases or generic parameters, follow the rule of their current name.
+func namingRule(symbol symbols.Indexable, currentName string) (*regexp.Regexp, string) {
+	typeRule := "type names must start with an uppercase letter and contain a lowercase one"
+	constantRule := "constant names must be uppercase"
+	identifierRule := "names must start with a lowercase letter"
+
+	switch s := symbol.(type) {
+	case *symbols.Struct, *symbols.Bitstruct, *symbols.Enum, *symbols.Interface, *symbols.Distinct, *symbols.Fault:
+		return typeNamePattern, typeRule
+	case *symbols.Enumerator, *symbols.FaultConstant:
+		return constantNamePattern, constantRule
+	case *symbols.Variable:
+		if s.IsConstant() {
+			return constantNamePattern, constantRule
+		}
+		if typeNamePattern.MatchString(currentName) {
+			// Compile time type parameters: `$Type`
+			return typeNamePattern, typeRule
+		}
+		return identifierPattern, identifierRule
+	case *symbols.Function, *symbols.StructMember:
+		return identifierPattern, identifierRule
+	}
+
+	switch {
+	case typeNamePattern.MatchString(currentName):
+		return typeNamePattern, typeRule
+	case constantNamePattern.MatchString(currentName):
+		return constantNamePattern, constantRule
+	}
+
+	return identifierPattern, identifierRule
+}
+
+// findRenameableSymbol resolves the symbol under cursor and checks it is declared in
+// the workspace folder of project: symbols from stdlib or libraries can't be renamed.
+func (h *Server) findRenameableSymbol(project *Project, docId string, position symbols.Position) (option.Option[symbols.Indexable], error) {
+	symbolOption := h.search.FindSymbolDeclarationInWorkspace(docId, position, project.state)
+	if symbolOption.IsNone() {
+		return symbolOption, errors.New("no symbol found under cursor")
+	}
+
+	symbol := symbolOption.Get()
+	if _, isModule := symbol.(*symbols.Module); isModule {
+		return symbolOption, errors.New("modules can't be renamed")
+	}
+
+	if !symbol.HasSourceCode() || !isWorkspaceDocument(project, symbol.GetDocumentURI()) {
+		return symbolOption, errors.New("'" + symbol.GetName() + "' is not declared in this workspace and can't be renamed")
+	}
+
+	return symbolOption, nil
+}
+
+// isWorkspaceDocument tells if docId belongs to the project sources, excluding
+// any .c3l library that could be placed inside the project folder.
+// Projects without a root folder only own the documents opened in the client,
+// as long as they are not part of the stdlib.
+func isWorkspaceDocument(project *Project, docId string) bool {
+	relative := docId
+	if project.root == "" {
+		if !project.state.IsDocumentOpen(docId) {
+			return false
+		}
+
+		stdlibPath := project.options.C3.StdlibPath
+		if stdlibPath.IsSome() && isPathInside(docId, stdlibPath.Get()) {
+			return false
+		}
+	} else {
+		if !isPathInside(docId, project.root) {
+			return false
+		}
+
+		relative, _ = filepath.Rel(project.root, docId)
+	}
+
+	for _, part := range strings.Split(filepath.ToSlash(relative), "/") {
+		if strings.HasSuffix(part, ".c3l") {
+			return false
+		}
+	}
+
+	return true
+}
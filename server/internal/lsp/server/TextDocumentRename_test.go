@@ -0,0 +1,152 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const renameSource = `module app;
+struct Foo { int x; }
+const int MAX = 3;
+fn int bar() { return MAX; }
+fn void main() { Foo f; bar(); }
+macro @twice(x) { return x * 2; }`
+
+func renameParams(position protocol.Position, newName string) *protocol.RenameParams {
+	return &protocol.RenameParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: testDocURI("app.c3")},
+			Position:     position,
+		},
+		NewName: newName,
+	}
+}
+
+func TestTextDocumentPrepareRename(t *testing.T) {
+	h, _ := newTestServer(t, map[string]string{"app.c3": renameSource})
+
+	t.Run("Returns the range of the symbol under cursor", func(t *testing.T) {
+		result, err := h.TextDocumentPrepareRename(nil, &protocol.PrepareRenameParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: testDocURI("app.c3")},
+				Position:     protocol.Position{Line: 4, Character: 25},
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, protocol.RangeWithPlaceholder{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 4, Character: 24},
+				End:   protocol.Position{Line: 4, Character: 27},
+			},
+			Placeholder: "bar",
+		}, result)
+	})
+
+	t.Run("Modules can't be renamed", func(t *testing.T) {
+		_, err := h.TextDocumentPrepareRename(nil, &protocol.PrepareRenameParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: testDocURI("app.c3")},
+				Position:     protocol.Position{Line: 0, Character: 8},
+			},
+		})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestTextDocumentRename(t *testing.T) {
+	h, _ := newTestServer(t, map[string]string{"app.c3": renameSource})
+
+	t.Run("Renames declaration and references", func(t *testing.T) {
+		edit, err := h.TextDocumentRename(nil, renameParams(protocol.Position{Line: 3, Character: 8}, "baz"))
+
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []protocol.TextEdit{
+			{Range: protocol.Range{Start: protocol.Position{Line: 3, Character: 7}, End: protocol.Position{Line: 3, Character: 10}}, NewText: "baz"},
+			{Range: protocol.Range{Start: protocol.Position{Line: 4, Character: 24}, End: protocol.Position{Line: 4, Character: 27}}, NewText: "baz"},
+		}, edit.Changes[testDocURI("app.c3")])
+	})
+
+	cases := []struct {
+		name     string
+		position protocol.Position
+		newName  string
+		valid    bool
+	}{
+		{"Type to type name", protocol.Position{Line: 1, Character: 8}, "Bar", true},
+		{"Type to lowercase name", protocol.Position{Line: 1, Character: 8}, "foo", false},
+		{"Type to constant name", protocol.Position{Line: 1, Character: 8}, "FOO", false},
+		{"Function to lowercase name", protocol.Position{Line: 3, Character: 8}, "baz", true},
+		{"Function to type name", protocol.Position{Line: 3, Character: 8}, "Bar", false},
+		{"Constant to uppercase name", protocol.Position{Line: 2, Character: 11}, "LIMIT", true},
+		{"Constant to lowercase name", protocol.Position{Line: 2, Character: 11}, "max", false},
+		{"Macro keeping its sigil", protocol.Position{Line: 5, Character: 8}, "@double", true},
+		{"Macro losing its sigil", protocol.Position{Line: 5, Character: 8}, "double", false},
+		{"Function gaining a sigil", protocol.Position{Line: 3, Character: 8}, "@baz", false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := h.TextDocumentRename(nil, renameParams(tt.position, tt.newName))
+
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestTextDocumentRename_without_project_root(t *testing.T) {
+	h, project := newTestServer(t, map[string]string{"app.c3": renameSource})
+	project.root = ""
+
+	edit, err := h.TextDocumentRename(nil, renameParams(protocol.Position{Line: 3, Character: 8}, "baz"))
+
+	assert.NoError(t, err)
+	assert.Len(t, edit.Changes[testDocURI("app.c3")], 2)
+}
+
+func TestIsWorkspaceDocument_without_project_root(t *testing.T) {
+	h, project := newTestServer(t, map[string]string{
+		"app.c3":           "module app;",
+		"lib/io.c3l/io.c3": "module io;",
+		"stdlib/std/io.c3": "module std::io;",
+	})
+	addClosedDocument(h, project, "closed.c3", "module app;")
+	project.root = ""
+	project.options.C3.StdlibPath = option.Some(testDocId("stdlib"))
+
+	t.Run("Open documents belong to the project", func(t *testing.T) {
+		assert.True(t, isWorkspaceDocument(project, testDocId("app.c3")))
+	})
+
+	t.Run("Documents not open don't belong to the project", func(t *testing.T) {
+		assert.False(t, isWorkspaceDocument(project, testDocId("closed.c3")))
+	})
+
+	t.Run("Open documents of libraries or the stdlib don't belong to the project", func(t *testing.T) {
+		assert.False(t, isWorkspaceDocument(project, testDocId("lib/io.c3l/io.c3")))
+		assert.False(t, isWorkspaceDocument(project, testDocId("stdlib/std/io.c3")))
+	})
+}
+
+func TestValidateNewName_keeps_sigils(t *testing.T) {
+	compileTime := symbols.NewVariableBuilder("$x", symbols.NewTypeFromString("int", "app"), "app", "app.c3").Build()
+	compileTimeType := symbols.NewVariableBuilder("$Type", symbols.NewTypeFromString("typeid", "app"), "app", "app.c3").Build()
+	expression := symbols.NewVariableBuilder("#expr", symbols.NewTypeFromString("int", "app"), "app", "app.c3").Build()
+
+	assert.NoError(t, validateNewName(compileTime, "$y"))
+	assert.Error(t, validateNewName(compileTime, "y"))
+	assert.Error(t, validateNewName(compileTime, "#y"))
+	assert.NoError(t, validateNewName(compileTimeType, "$Other"))
+	assert.Error(t, validateNewName(compileTimeType, "$other"))
+	assert.NoError(t, validateNewName(expression, "#value"))
+	assert.Error(t, validateNewName(expression, "value"))
+}
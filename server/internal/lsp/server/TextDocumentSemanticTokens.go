@@ -0,0 +1,225 @@
+package server
+
+import (
+	"github.com/pherrymason/c3-lsp/internal/lsp/reference_index"
+	st "github.com/pherrymason/c3-lsp/internal/lsp/semantic_tokens"
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Support "Semantic tokens"
+func (h *Server) TextDocumentSemanticTokensFull(context *glsp.Context, params *protocol.SemanticTokensParams) (*protocol.SemanticTokens, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	data := st.Encode(h.collectSemanticTokens(docId, option.None[symbols.Range]()))
+	resultId := h.semanticTokens.Store(docId, data)
+
+	return &protocol.SemanticTokens{ResultID: &resultId, Data: data}, nil
+}
+
+// Support "Semantic tokens delta"
+// Returns: SemanticTokens | SemanticTokensDelta | nil
+func (h *Server) TextDocumentSemanticTokensFullDelta(context *glsp.Context, params *protocol.SemanticTokensDeltaParams) (any, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	previous, found := h.semanticTokens.Get(docId, params.PreviousResultID)
+	data := st.Encode(h.collectSemanticTokens(docId, option.None[symbols.Range]()))
+	resultId := h.semanticTokens.Store(docId, data)
+
+	if !found {
+		return &protocol.SemanticTokens{ResultID: &resultId, Data: data}, nil
+	}
+
+	return &protocol.SemanticTokensDelta{ResultId: &resultId, Edits: st.Diff(previous, data)}, nil
+}
+
+// Support "Semantic tokens range"
+// Returns: SemanticTokens | nil
+func (h *Server) TextDocumentSemanticTokensRange(context *glsp.Context, params *protocol.SemanticTokensRangeParams) (any, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	tokensRange := symbols.NewRange(
+		uint(params.Range.Start.Line), uint(params.Range.Start.Character),
+		uint(params.Range.End.Line), uint(params.Range.End.Character),
+	)
+
+	return &protocol.SemanticTokens{
+		Data: st.Encode(h.collectSemanticTokens(docId, option.Some(tokensRange))),
+	}, nil
+}
+
+// collectSemanticTokens classifies every identifier of the document by resolving
+// the symbol it refers to. When limit is set, only identifiers inside it are classified.
+func (h *Server) collectSemanticTokens(docId string, limit option.Option[symbols.Range]) []st.Token {
+	state := h.projectFor(docId).state
+	doc := state.GetDocument(docId)
+	if doc == nil || doc.SyntaxTree() == nil {
+		return nil
+	}
+
+	parameters := functionParameters(state.GetUnitModulesByDoc(docId).Modules())
+	sourceCode := []byte(doc.SourceCode.Text)
+	tokens := []st.Token{}
+
+	pending := []*sitter.Node{doc.SyntaxTree().RootNode()}
+	for len(pending) > 0 {
+		node := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		nodeRange := symbols.NewRangeFromTreeSitterPositions(node.StartPoint(), node.EndPoint())
+		if limit.IsSome() && !rangesOverlap(limit.Get(), nodeRange) {
+			continue
+		}
+
+		if node.Type() == "builtin" {
+			tokens = append(tokens, newToken(nodeRange, st.TypeMacro, st.ModifierDefaultLibrary))
+			continue
+		}
+
+		if !reference_index.IsIdentifierNode(node) {
+			for i := int(node.NamedChildCount()) - 1; i >= 0; i-- {
+				pending = append(pending, node.NamedChild(i))
+			}
+			continue
+		}
+
+		if nodeRange.Start.Line != nodeRange.End.Line || node.Content(sourceCode) == "" {
+			continue
+		}
+
+		resolved := h.search.FindSymbolDeclarationInWorkspace(docId, nodeRange.Start, state)
+		if resolved.IsNone() {
+			if tokenType, ok := unresolvedTokenType(node.Type()); ok {
+				tokens = append(tokens, newToken(nodeRange, tokenType, 0))
+			}
+			continue
+		}
+
+		symbol := resolved.Get()
+		tokenType, modifiers := classifySymbol(symbol, parameters)
+		if symbol.GetDocumentURI() == docId && symbol.GetIdRange() == nodeRange {
+			modifiers |= st.ModifierDeclaration
+		}
+
+		tokens = append(tokens, newToken(nodeRange, tokenType, modifiers))
+	}
+
+	return tokens
+}
+
+func newToken(r symbols.Range, tokenType st.TokenType, modifiers uint) st.Token {
+	return st.Token{
+		Line:      r.Start.Line,
+		Character: r.Start.Character,
+		Length:    r.End.Character - r.Start.Character,
+		Type:      tokenType,
+		Modifiers: modifiers,
+	}
+}
+
+// functionParameters collects the id range of every function parameter declared in modules.
+func functionParameters(modules []*symbols.Module) map[symbols.Range]bool {
+	parameters := map[symbols.Range]bool{}
+	for _, module := range modules {
+		for _, function := range module.ChildrenFunctions {
+			for _, argument := range function.GetArguments() {
+				if argument != nil {
+					parameters[argument.GetIdRange()] = true
+				}
+			}
+		}
+	}
+
+	return parameters
+}
+
+func classifySymbol(symbol symbols.Indexable, parameters map[symbols.Range]bool) (st.TokenType, uint) {
+	var modifiers uint
+	if !symbol.HasSourceCode() {
+		modifiers |= st.ModifierDefaultLibrary
+	}
+	if isDeprecated(symbol) {
+		modifiers |= st.ModifierDeprecated
+	}
+
+	switch s := symbol.(type) {
+	case *symbols.Module:
+		return st.TypeNamespace, modifiers
+	case *symbols.Struct, *symbols.Bitstruct:
+		return st.TypeStruct, modifiers
+	case *symbols.Interface:
+		return st.TypeInterface, modifiers
+	case *symbols.Enum:
+		return st.TypeEnum, modifiers
+	case *symbols.Fault:
+		return st.TypeFault, modifiers
+	case *symbols.Distinct:
+		return st.TypeType, modifiers
+	case *symbols.Def:
+		return st.TypeTypeAlias, modifiers
+	case *symbols.GenericParameter:
+		return st.TypeTypeParameter, modifiers
+	case *symbols.Enumerator:
+		return st.TypeEnumMember, modifiers | st.ModifierReadonly
+	case *symbols.FaultConstant:
+		return st.TypeFault, modifiers | st.ModifierReadonly
+	case *symbols.StructMember:
+		return st.TypeProperty, modifiers
+	case *symbols.Function:
+		switch {
+		case s.FunctionType() == symbols.Macro:
+			return st.TypeMacro, modifiers
+		case s.GetTypeIdentifier() != "":
+			return st.TypeMethod, modifiers
+		}
+		return st.TypeFunction, modifiers
+	case *symbols.Variable:
+		if s.GetKind() == protocol.CompletionItemKindConstant {
+			modifiers |= st.ModifierReadonly
+		}
+		if parameters[s.GetIdRange()] {
+			return st.TypeParameter, modifiers
+		}
+		return st.TypeVariable, modifiers
+	}
+
+	return st.TypeVariable, modifiers
+}
+
+// unresolvedTokenType classifies identifiers whose declaration can't be found,
+// like compile time variables, based only on their syntax.
+func unresolvedTokenType(nodeType string) (st.TokenType, bool) {
+	switch nodeType {
+	case "type_ident", "ct_type_ident", "at_type_ident":
+		return st.TypeType, true
+	case "ct_ident", "ct_const_ident":
+		return st.TypeVariable, true
+	}
+
+	return 0, false
+}
+
+func isDeprecated(symbol symbols.Indexable) bool {
+	if withAttributes, ok := symbol.(interface{ GetAttributes() []string }); ok {
+		for _, attribute := range withAttributes.GetAttributes() {
+			if attribute == "@deprecated" {
+				return true
+			}
+		}
+	}
+
+	if docComment := symbol.GetDocComment(); docComment != nil {
+		for _, contract := range docComment.GetContracts() {
+			if contract.GetName() == "@deprecated" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func rangesOverlap(a symbols.Range, b symbols.Range) bool {
+	return !a.IsBeforePosition(b.End) && !b.IsBeforePosition(a.End)
+}
@@ -15,7 +15,8 @@ import (
 func (h *Server) TextDocumentSignatureHelp(context *glsp.Context, params *protocol.SignatureHelpParams) (*protocol.SignatureHelp, error) {
 	// Rewind position after previous "("
 	docId := utils.NormalizePath(params.TextDocument.URI)
-	doc := h.state.GetDocument(docId)
+	state := h.projectFor(docId).state
+	doc := state.GetDocument(docId)
 	posOption := doc.SourceCode.RewindBeforePreviousParenthesis(symbols.NewPositionFromLSPPosition(params.Position))
 
 	if posOption.IsNone() {
@@ -25,7 +26,7 @@ func (h *Server) TextDocumentSignatureHelp(context *glsp.Context, params *protoc
 	foundSymbolOption := h.search.FindSymbolDeclarationInWorkspace(
 		docId,
 		posOption.Get(),
-		h.state,
+		state,
 	)
 	if foundSymbolOption.IsNone() {
 		return nil, nil
@@ -0,0 +1,121 @@
+package server
+
+import (
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	"github.com/pherrymason/c3-lsp/internal/lsp/type_hierarchy"
+	"github.com/pherrymason/c3-lsp/pkg/cast"
+	"github.com/pherrymason/c3-lsp/pkg/fs"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Messages of type hierarchy, added in LSP 3.17, that glsp does not implement.
+const (
+	MethodTextDocumentPrepareTypeHierarchy = protocol.Method("textDocument/prepareTypeHierarchy")
+	MethodTypeHierarchySupertypes          = protocol.Method("typeHierarchy/supertypes")
+	MethodTypeHierarchySubtypes            = protocol.Method("typeHierarchy/subtypes")
+)
+
+type TextDocumentPrepareTypeHierarchyFunc func(context *glsp.Context, params *TypeHierarchyPrepareParams) ([]TypeHierarchyItem, error)
+
+type TypeHierarchySupertypesFunc func(context *glsp.Context, params *TypeHierarchySupertypesParams) ([]TypeHierarchyItem, error)
+
+type TypeHierarchySubtypesFunc func(context *glsp.Context, params *TypeHierarchySubtypesParams) ([]TypeHierarchyItem, error)
+
+type TypeHierarchyPrepareParams struct {
+	protocol.TextDocumentPositionParams
+	protocol.WorkDoneProgressParams
+}
+
+type TypeHierarchyItem struct {
+	Name           string               `json:"name"`
+	Kind           protocol.SymbolKind  `json:"kind"`
+	Detail         *string              `json:"detail,omitempty"`
+	URI            protocol.DocumentUri `json:"uri"`
+	Range          protocol.Range       `json:"range"`
+	SelectionRange protocol.Range       `json:"selectionRange"`
+}
+
+type TypeHierarchySupertypesParams struct {
+	protocol.WorkDoneProgressParams
+	protocol.PartialResultParams
+
+	Item TypeHierarchyItem `json:"item"`
+}
+
+type TypeHierarchySubtypesParams struct {
+	protocol.WorkDoneProgressParams
+	protocol.PartialResultParams
+
+	Item TypeHierarchyItem `json:"item"`
+}
+
+// Support "Type hierarchy"
+// Prepares the struct, interface or typedef declared or used at the position.
+func (h *Server) TextDocumentPrepareTypeHierarchy(context *glsp.Context, params *TypeHierarchyPrepareParams) ([]TypeHierarchyItem, error) {
+	docId := utils.NormalizePath(params.TextDocument.URI)
+	project := h.projectFor(docId)
+
+	resolved := h.search.FindSymbolDeclarationInWorkspace(docId, symbols.NewPositionFromLSPPosition(params.Position), project.state)
+	if resolved.IsNone() {
+		return nil, nil
+	}
+	symbol := resolved.Get()
+	if !type_hierarchy.IsHierarchyType(symbol) || !hasLocation(project, symbol) {
+		return nil, nil
+	}
+
+	return []TypeHierarchyItem{typeHierarchyItem(project, symbol)}, nil
+}
+
+// Support "Type hierarchy supertypes"
+func (h *Server) TypeHierarchySupertypes(context *glsp.Context, params *TypeHierarchySupertypesParams) ([]TypeHierarchyItem, error) {
+	project, symbol := h.typeHierarchySymbol(params.Item)
+	if symbol == nil {
+		return nil, nil
+	}
+
+	return typeHierarchyItems(project, type_hierarchy.Supertypes(symbol, project.state)), nil
+}
+
+// Support "Type hierarchy subtypes"
+func (h *Server) TypeHierarchySubtypes(context *glsp.Context, params *TypeHierarchySubtypesParams) ([]TypeHierarchyItem, error) {
+	project, symbol := h.typeHierarchySymbol(params.Item)
+	if symbol == nil {
+		return nil, nil
+	}
+
+	return typeHierarchyItems(project, type_hierarchy.Subtypes(symbol, project.state)), nil
+}
+
+// typeHierarchySymbol finds the type an item prepared before refers to.
+func (h *Server) typeHierarchySymbol(item TypeHierarchyItem) (*Project, symbols.Indexable) {
+	docId := utils.NormalizePath(item.URI)
+	project := h.projectFor(docId)
+
+	return project, type_hierarchy.TypeDeclaredAt(project.state, docId, symbols.NewPositionFromLSPPosition(item.SelectionRange.Start))
+}
+
+func typeHierarchyItems(project *Project, types []symbols.Indexable) []TypeHierarchyItem {
+	items := []TypeHierarchyItem{}
+	for _, symbol := range types {
+		if hasLocation(project, symbol) {
+			items = append(items, typeHierarchyItem(project, symbol))
+		}
+	}
+
+	return items
+}
+
+func typeHierarchyItem(project *Project, symbol symbols.Indexable) TypeHierarchyItem {
+	return TypeHierarchyItem{
+		Name:           symbol.GetName(),
+		Kind:           symbolKind(symbol),
+		Detail:         cast.ToPtr(symbol.GetModuleString()),
+		URI:            fs.ConvertPathToURI(symbol.GetDocumentURI(), project.options.C3.StdlibPath),
+		Range:          _prot.Lsp_NewRangeFromRange(symbol.GetDocumentRange()),
+		SelectionRange: _prot.Lsp_NewRangeFromRange(symbol.GetIdRange()),
+	}
+}
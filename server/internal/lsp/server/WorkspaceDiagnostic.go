@@ -0,0 +1,114 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/pherrymason/c3-lsp/pkg/fs"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+	protocol317 "github.com/tliron/glsp/protocol_3_17"
+)
+
+// Messages of the diagnostics pull model that glsp does not implement.
+const (
+	MethodWorkspaceDiagnostic        = protocol.Method("workspace/diagnostic")
+	ServerWorkspaceDiagnosticRefresh = protocol.Method("workspace/diagnostic/refresh")
+)
+
+type WorkspaceDiagnosticFunc func(context *glsp.Context, params *WorkspaceDiagnosticParams) (any, error)
+
+type WorkspaceDiagnosticParams struct {
+	protocol.WorkDoneProgressParams
+	protocol.PartialResultParams
+
+	Identifier *string `json:"identifier,omitempty"`
+
+	// The result ids of the reports the client currently has.
+	PreviousResultIds []PreviousResultId `json:"previousResultIds"`
+}
+
+type PreviousResultId struct {
+	URI   protocol.DocumentUri `json:"uri"`
+	Value string               `json:"value"`
+}
+
+type WorkspaceDiagnosticReport struct {
+	Items []any `json:"items"` // WorkspaceFullDocumentDiagnosticReport | WorkspaceUnchangedDocumentDiagnosticReport
+}
+
+type WorkspaceFullDocumentDiagnosticReport struct {
+	protocol317.FullDocumentDiagnosticReport
+
+	URI protocol.DocumentUri `json:"uri"`
+	// Version of the open document the report is about. Null for files that are not open.
+	Version *protocol.Integer `json:"version"`
+}
+
+type WorkspaceUnchangedDocumentDiagnosticReport struct {
+	protocol317.UnchangedDocumentDiagnosticReport
+
+	URI     protocol.DocumentUri `json:"uri"`
+	Version *protocol.Integer    `json:"version"`
+}
+
+// Support "Workspace diagnostics"
+// Reports the diagnostics of the workspace files that are not open, which are only found by c3c.
+// Open documents are left to textDocument/diagnostic. Files the client has a report for are
+// always included, so it learns when their errors are gone.
+func (h *Server) WorkspaceDiagnostic(context *glsp.Context, params *WorkspaceDiagnosticParams) (any, error) {
+	previousResultIds := map[string]string{}
+	for _, previous := range params.PreviousResultIds {
+		previousResultIds[utils.NormalizePath(previous.URI)] = previous.Value
+	}
+
+	report := WorkspaceDiagnosticReport{Items: []any{}}
+	for _, project := range h.projects {
+		openDocuments := map[string]bool{}
+		for _, docId := range project.state.OpenDocuments() {
+			openDocuments[docId] = true
+		}
+
+		docIds := []string{}
+		for docId := range project.state.GetDocumentDiagnostics() {
+			docIds = append(docIds, docId)
+		}
+		for docId := range previousResultIds {
+			if h.projectFor(docId) == project {
+				docIds = append(docIds, docId)
+			}
+		}
+		sort.Strings(docIds)
+
+		for i, docId := range docIds {
+			if openDocuments[docId] || (i > 0 && docIds[i-1] == docId) {
+				continue
+			}
+
+			uri := fs.ConvertPathToURI(docId, project.options.C3.StdlibPath)
+			items := h.pulledDiagnostics(project, docId)
+			resultId := diagnosticsResultId(items)
+			if previous, found := previousResultIds[docId]; found && previous == resultId {
+				report.Items = append(report.Items, WorkspaceUnchangedDocumentDiagnosticReport{
+					UnchangedDocumentDiagnosticReport: protocol317.UnchangedDocumentDiagnosticReport{
+						Kind:     string(protocol317.DocumentDiagnosticReportKindUnchanged),
+						ResultID: resultId,
+					},
+					URI: uri,
+				})
+				continue
+			}
+
+			report.Items = append(report.Items, WorkspaceFullDocumentDiagnosticReport{
+				FullDocumentDiagnosticReport: protocol317.FullDocumentDiagnosticReport{
+					Kind:     string(protocol317.DocumentDiagnosticReportKindFull),
+					ResultID: &resultId,
+					Items:    items,
+				},
+				URI: uri,
+			})
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+	protocol317 "github.com/tliron/glsp/protocol_3_17"
+)
+
+func pullWorkspaceDiagnostics(t *testing.T, h *Server, previous ...PreviousResultId) []any {
+	result, err := h.WorkspaceDiagnostic(nil, &WorkspaceDiagnosticParams{PreviousResultIds: previous})
+	assert.NoError(t, err)
+
+	return result.(WorkspaceDiagnosticReport).Items
+}
+
+func TestWorkspaceDiagnostic(t *testing.T) {
+	h, project := newTestServer(t, map[string]string{"app.c3": "module app;"})
+	project.options.Diagnostics.Enabled = true
+	addClosedDocument(h, project, "lib.c3", "module lib;")
+	project.state.SetDocumentDiagnostics(testDocId("app.c3"), []protocol.Diagnostic{diagnostic(0, "open")})
+	project.state.SetDocumentDiagnostics(testDocId("lib.c3"), []protocol.Diagnostic{diagnostic(0, "closed")})
+
+	items := pullWorkspaceDiagnostics(t, h)
+
+	t.Run("Reports files that are not open, leaving open documents to the document pull", func(t *testing.T) {
+		assert.Len(t, items, 1)
+
+		report := items[0].(WorkspaceFullDocumentDiagnosticReport)
+		assert.Equal(t, testDocURI("lib.c3"), report.URI)
+		assert.Equal(t, []protocol.Diagnostic{diagnostic(0, "closed")}, report.Items)
+	})
+
+	resultId := *items[0].(WorkspaceFullDocumentDiagnosticReport).ResultID
+
+	t.Run("Reports unchanged when diagnostics are the same of the previous result", func(t *testing.T) {
+		items := pullWorkspaceDiagnostics(t, h, PreviousResultId{URI: testDocURI("lib.c3"), Value: resultId})
+
+		assert.Equal(t, []any{WorkspaceUnchangedDocumentDiagnosticReport{
+			UnchangedDocumentDiagnosticReport: protocol317.UnchangedDocumentDiagnosticReport{
+				Kind:     string(protocol317.DocumentDiagnosticReportKindUnchanged),
+				ResultID: resultId,
+			},
+			URI: testDocURI("lib.c3"),
+		}}, items)
+	})
+
+	t.Run("Reports files of previous results whose errors are gone", func(t *testing.T) {
+		delete(project.state.GetDocumentDiagnostics(), testDocId("lib.c3"))
+
+		items := pullWorkspaceDiagnostics(t, h, PreviousResultId{URI: testDocURI("lib.c3"), Value: resultId})
+
+		assert.Len(t, items, 1)
+		report := items[0].(WorkspaceFullDocumentDiagnosticReport)
+		assert.Equal(t, testDocURI("lib.c3"), report.URI)
+		assert.Empty(t, report.Items)
+		assert.NotEqual(t, resultId, *report.ResultID)
+	})
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Support "Configuration change"
+// Clients either push the new settings or just notify they changed, in which case they are pulled.
+func (h *Server) WorkspaceDidChangeConfiguration(context *glsp.Context, params *protocol.DidChangeConfigurationParams) error {
+	if settings, ok := params.Settings.(map[string]any); ok && settings[configurationSection] != nil {
+		// Pushed settings are not scoped to a workspace folder: they apply to all of them.
+		for _, project := range h.projects {
+			h.setClientConfiguration(project, settings[configurationSection])
+			h.refreshOpenDocumentsDiagnostics(project, context.Notify)
+			h.RunDiagnostics(project, context.Notify, true)
+		}
+		return nil
+	}
+
+	// Requests to the client can't be done while handling a notification.
+	go func() {
+		h.pullClientConfiguration(context)
+
+		h.stateLock.Lock()
+		projects := append([]*Project{}, h.projects...)
+		for _, project := range projects {
+			h.refreshOpenDocumentsDiagnostics(project, context.Notify)
+		}
+		h.stateLock.Unlock()
+
+		for _, project := range projects {
+			h.RunDiagnostics(project, context.Notify, true)
+		}
+	}()
+
+	return nil
+}
+
+func configurationRegistration() protocol.Registration {
+	return protocol.Registration{
+		ID:     "c3-lsp/configuration",
+		Method: string(protocol.MethodWorkspaceDidChangeConfiguration),
+	}
+}
@@ -1,21 +1,144 @@
 package server
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/pherrymason/c3-lsp/pkg/fs"
 	"github.com/pherrymason/c3-lsp/pkg/utils"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
+// Files watched by the client, so changes done outside the editor (git checkout,
+// code generators, other editors...) are indexed.
+var watchedFilesGlobs = []string{"**/*.c3", "**/*.c3i", "**/project.json", "**/c3lsp.json"}
+
+// fileWatchersRegistration asks the client to notify changes on watchedFilesGlobs.
+func fileWatchersRegistration() protocol.Registration {
+	watchers := []protocol.FileSystemWatcher{}
+	for _, glob := range watchedFilesGlobs {
+		watchers = append(watchers, protocol.FileSystemWatcher{GlobPattern: glob})
+	}
+
+	return protocol.Registration{
+		ID:              "c3-lsp/watched-files",
+		Method:          string(protocol.MethodWorkspaceDidChangeWatchedFiles),
+		RegisterOptions: protocol.DidChangeWatchedFilesRegistrationOptions{Watchers: watchers},
+	}
+}
+
+// Support "Watched files"
 func (h *Server) WorkspaceDidChangeWatchedFiles(context *glsp.Context, params *protocol.DidChangeWatchedFilesParams) error {
+	changedProjects := []*Project{}
+	reloadConfiguration := map[*Project]bool{}
+	reloadDependencies := map[*Project]bool{}
+
+	for _, change := range params.Changes {
+		docId := utils.NormalizePath(change.URI)
+		project := h.projectFor(docId)
+		if !slices.Contains(changedProjects, project) {
+			changedProjects = append(changedProjects, project)
+		}
+
+		if filepath.Dir(docId) == project.root {
+			switch filepath.Base(docId) {
+			case "c3lsp.json":
+				reloadConfiguration[project] = true
+				continue
+			case "project.json":
+				reloadDependencies[project] = true
+				continue
+			}
+		}
+
+		extension := filepath.Ext(docId)
+		if (extension != ".c3" && extension != ".c3i") || project.state.IsDocumentOpen(docId) {
+			continue
+		}
+
+		switch change.Type {
+		case protocol.FileChangeTypeCreated, protocol.FileChangeTypeChanged:
+			h.indexFileFromDisk(project, docId)
+
+		case protocol.FileChangeTypeDeleted:
+			project.state.DeleteDocument(docId)
+			h.parser.ForgetDocument(docId)
+		}
+	}
+
+	for _, project := range changedProjects {
+		if reloadConfiguration[project] {
+			h.loadServerConfigurationForWorkspace(project, project.root)
+			h.refreshOpenDocumentsDiagnostics(project, context.Notify)
+		}
+
+		if reloadDependencies[project] {
+			h.reindexDependencies(project, context)
+		}
+
+		h.RunDiagnostics(project, context.Notify, true)
+	}
+
 	return nil
 }
 
+// indexFileFromDisk parses the saved content of docId and registers it in project.
+func (h *Server) indexFileFromDisk(project *Project, docId string) {
+	content, err := os.ReadFile(docId)
+	if err != nil {
+		h.server.Log.Warningf("Failed to read %s: %v", docId, err)
+		return
+	}
+
+	project.state.RegisterParsedDocument(project.state.ParseDocument(docId, string(content), h.parser, h.indexCache(project)))
+}
+
+// reindexDependencies resolves the dependencies declared in project.json again,
+// removing the files of the ones no longer used and indexing the new ones in background.
+func (h *Server) reindexDependencies(project *Project, context *glsp.Context) {
+	files := h.dependencyFiles(fs.GetCanonicalPath(project.root))
+
+	dependencies := map[string]bool{}
+	added := []string{}
+	for _, filePath := range files {
+		dependencies[filePath] = true
+		if !project.dependencies[filePath] {
+			added = append(added, filePath)
+		}
+	}
+
+	for filePath := range project.dependencies {
+		if !dependencies[filePath] {
+			project.state.DeleteDocument(filePath)
+			h.parser.ForgetDocument(filePath)
+		}
+	}
+	project.dependencies = dependencies
+
+	if len(added) == 0 {
+		return
+	}
+
+	cache := h.indexCache(project)
+	go func() {
+		progress := h.beginWorkDoneProgress(context, "Indexing dependencies")
+		h.indexFiles(project, added, cache, progress)
+		if progress.IsSome() {
+			progress.Get().end(fmt.Sprintf("Indexed %d files", len(added)))
+		}
+	}()
+}
+
 func (h *Server) WorkspaceDidDeleteFiles(context *glsp.Context, params *protocol.DeleteFilesParams) error {
 	for _, file := range params.Files {
 		// The file has been removed! update our indices
 		docId := utils.NormalizePath(file.URI)
 		//h.documents.Delete(file.URI)
-		h.state.DeleteDocument(docId)
+		h.projectFor(docId).state.DeleteDocument(docId)
+		h.parser.ForgetDocument(docId)
 	}
 
 	return nil
@@ -27,7 +150,16 @@ func (h *Server) WorkspaceDidRenameFiles(context *glsp.Context, params *protocol
 
 		oldDocId := utils.NormalizePath(file.OldURI)
 		newDocId := utils.NormalizePath(file.NewURI)
-		h.state.RenameDocument(oldDocId, newDocId)
+		oldProject := h.projectFor(oldDocId)
+		newProject := h.projectFor(newDocId)
+		if oldProject == newProject {
+			oldProject.state.RenameDocument(oldDocId, newDocId)
+		} else {
+			// Moved to another workspace folder.
+			oldProject.state.DeleteDocument(oldDocId)
+			h.indexFileFromDisk(newProject, newDocId)
+		}
+		h.parser.ForgetDocument(oldDocId)
 	}
 
 	return nil
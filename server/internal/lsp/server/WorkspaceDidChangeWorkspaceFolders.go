@@ -0,0 +1,84 @@
+package server
+
+import (
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Support "Workspace folders"
+// Each added folder gets its own project, indexed in background. Documents opened
+// in the editor are moved to the project owning them after the change.
+func (h *Server) WorkspaceDidChangeWorkspaceFolders(context *glsp.Context, params *protocol.DidChangeWorkspaceFoldersParams) error {
+	for _, folder := range params.Event.Removed {
+		removed := h.removeProject(folder.URI)
+		if removed.IsNone() {
+			continue
+		}
+
+		project := removed.Get()
+		h.clearOldDiagnostics(project, context.Notify)
+		project.state.ReleaseStdlib()
+		if len(h.projects) == 0 {
+			h.projects = []*Project{h.newProject("")}
+		}
+		h.moveOpenDocuments(project)
+	}
+
+	added := []*Project{}
+	for _, folder := range params.Event.Added {
+		project := h.addProject(folder.URI)
+		added = append(added, project)
+
+		for _, other := range h.projects {
+			if other != project {
+				h.moveOpenDocuments(other)
+			}
+		}
+	}
+
+	// A project without root is only used while there are no workspace folders.
+	if len(h.projects) > 1 && h.projects[0].root == "" {
+		rootless := h.projects[0]
+		h.projects = h.projects[1:]
+		h.moveOpenDocuments(rootless)
+		rootless.state.ReleaseStdlib()
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	go func() {
+		for _, project := range added {
+			h.indexWorkspace(context, project)
+			h.RunDiagnostics(project, context.Notify, false)
+		}
+	}()
+
+	return nil
+}
+
+// moveOpenDocuments registers the documents opened in project into the project
+// owning them now, if it is a different one.
+func (h *Server) moveOpenDocuments(project *Project) {
+	for _, docId := range project.state.OpenDocuments() {
+		owner := h.projectFor(docId)
+		if owner == project {
+			continue
+		}
+
+		doc := project.state.GetDocument(docId)
+		project.state.CloseDocument(docId)
+		project.state.DeleteDocument(docId)
+		project.state.SetDocumentServerDiagnostics(docId, nil)
+		if doc == nil {
+			continue
+		}
+
+		owner.state.RefreshDocumentIdentifiers(doc, h.parser)
+		owner.state.MarkDocumentOpened(docId)
+		if owner.options.Diagnostics.Enabled {
+			owner.state.SetDocumentServerDiagnostics(docId, h.serverDiagnostics(owner, doc))
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"sort"
+
+	_prot "github.com/pherrymason/c3-lsp/internal/lsp/protocol"
+	trie "github.com/pherrymason/c3-lsp/internal/lsp/symbol_trie"
+	"github.com/pherrymason/c3-lsp/pkg/fs"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Maximum number of symbols returned by a workspace symbol search.
+const maxWorkspaceSymbols = 200
+
+// Where a symbol comes from. Among equally good matches, lower values are listed first.
+type symbolOrigin int
+
+const (
+	originWorkspace symbolOrigin = iota
+	originDependency
+	originStdlib
+)
+
+// Support "Workspace symbols"
+// Symbols of every workspace folder are searched.
+func (h *Server) WorkspaceSymbol(context *glsp.Context, params *protocol.WorkspaceSymbolParams) ([]protocol.SymbolInformation, error) {
+	type rankedResult struct {
+		trie.FuzzyResult
+		origin  symbolOrigin
+		project *Project
+	}
+
+	ranked := []rankedResult{}
+	// Stdlib symbols are shared between projects: list them once.
+	seen := map[symbols.Indexable]bool{}
+	for _, project := range h.projects {
+		for _, result := range project.state.SearchFuzzy(params.Query) {
+			if seen[result.Symbol] {
+				continue
+			}
+
+			origin := symbolOriginIn(project, result.Symbol)
+			if origin == originStdlib && project.options.C3.StdlibPath.IsNone() {
+				// Without the stdlib sources there is no location to jump to.
+				// Other projects may know where they are, so it is not marked as seen.
+				continue
+			}
+			seen[result.Symbol] = true
+
+			ranked = append(ranked, rankedResult{FuzzyResult: result, origin: origin, project: project})
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		if ranked[i].origin != ranked[j].origin {
+			return ranked[i].origin < ranked[j].origin
+		}
+
+		return ranked[i].Symbol.GetFQN() < ranked[j].Symbol.GetFQN()
+	})
+
+	if len(ranked) > maxWorkspaceSymbols {
+		ranked = ranked[:maxWorkspaceSymbols]
+	}
+
+	symbolsInformation := []protocol.SymbolInformation{}
+	for _, result := range ranked {
+		symbol := result.Symbol
+		containerName := symbol.GetModuleString()
+		symbolsInformation = append(symbolsInformation, protocol.SymbolInformation{
+			Name: symbol.GetName(),
+			Kind: symbolKind(symbol),
+			Location: protocol.Location{
+				URI:   fs.ConvertPathToURI(symbol.GetDocumentURI(), result.project.options.C3.StdlibPath),
+				Range: _prot.Lsp_NewRangeFromRange(symbol.GetIdRange()),
+			},
+			ContainerName: &containerName,
+		})
+	}
+
+	return symbolsInformation, nil
+}
+
+func symbolOriginIn(project *Project, symbol symbols.Indexable) symbolOrigin {
+	if !symbol.HasSourceCode() {
+		return originStdlib
+	}
+
+	if isWorkspaceDocument(project, symbol.GetDocumentURI()) {
+		return originWorkspace
+	}
+
+	return originDependency
+}
+
+func symbolKind(symbol symbols.Indexable) protocol.SymbolKind {
+	switch s := symbol.(type) {
+	case *symbols.Module:
+		return protocol.SymbolKindModule
+	case *symbols.Function:
+		if s.GetTypeIdentifier() != "" {
+			return protocol.SymbolKindMethod
+		}
+		return protocol.SymbolKindFunction
+	case *symbols.Variable:
+		if s.GetKind() == protocol.CompletionItemKindConstant {
+			return protocol.SymbolKindConstant
+		}
+		return protocol.SymbolKindVariable
+	case *symbols.Struct, *symbols.Bitstruct:
+		return protocol.SymbolKindStruct
+	case *symbols.Interface:
+		return protocol.SymbolKindInterface
+	case *symbols.Enum:
+		return protocol.SymbolKindEnum
+	case *symbols.Enumerator:
+		return protocol.SymbolKindEnumMember
+	case *symbols.FaultConstant:
+		return protocol.SymbolKindConstant
+	case *symbols.StructMember:
+		return protocol.SymbolKindField
+	}
+
+	return protocol.SymbolKindTypeParameter
+}
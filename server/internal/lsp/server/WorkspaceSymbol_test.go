@@ -0,0 +1,69 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/symbols_table"
+	"github.com/stretchr/testify/assert"
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func workspaceSymbolNames(t *testing.T, h *Server, query string) []string {
+	result, err := h.WorkspaceSymbol(nil, &protocol.WorkspaceSymbolParams{Query: query})
+	assert.NoError(t, err)
+
+	names := []string{}
+	for _, symbol := range result {
+		names = append(names, *symbol.ContainerName+"::"+symbol.Name)
+	}
+
+	return names
+}
+
+func TestWorkspaceSymbol(t *testing.T) {
+	h, project := newTestServer(t, map[string]string{
+		"app.c3": "module app;\nfn void print() {}\nfn void sprint_all() {}",
+	})
+	addClosedDocument(h, project, "lib/io.c3l/io.c3", "module io;\nfn void print() {}\nfn void println() {}")
+
+	names := workspaceSymbolNames(t, h, "print")
+	assert.Len(t, names, 4)
+
+	t.Run("Better matches are listed first, whatever their origin", func(t *testing.T) {
+		assert.Equal(t, []string{"io::println", "app::sprint_all"}, names[2:])
+	})
+
+	t.Run("Equally good matches of the workspace are listed before the ones of dependencies", func(t *testing.T) {
+		assert.Equal(t, []string{"app::print", "io::print"}, names[:2])
+	})
+}
+
+func TestWorkspaceSymbol_lists_stdlib_symbols_of_projects_knowing_their_sources(t *testing.T) {
+	h, _ := newTestServer(t, map[string]string{"app.c3": "module app;"})
+	state := project_state.NewProjectState(commonlog.MockLogger{}, option.Some("dummy"), false)
+	withStdlib := &Project{root: "/other", state: &state, dependencies: map[string]bool{}, indexed: true}
+	withStdlib.options.C3.StdlibPath = option.Some("/c3/lib")
+	h.projects = append(h.projects, withStdlib)
+
+	// Stdlib symbols are shared by the projects using the same stdlib.
+	docId := "/c3/lib/std/io.c3"
+	doc := document.NewDocumentWithoutTree(docId, "")
+	stdlib := symbols_table.NewParsedModules(&doc.URI)
+	module := symbols.NewModuleBuilder("std::io", docId).WithoutSourceCode().Build()
+	module.AddFunction(symbols.NewFunctionBuilder("printn", symbols.NewTypeFromString("void", "std::io"), "std::io", docId).WithoutSourceCode().Build())
+	stdlib.RegisterModule(module)
+	for _, project := range h.projects {
+		project.state.RegisterParsedDocument(project_state.ParsedDocument{
+			Document: &doc,
+			Modules:  stdlib,
+			Pending:  symbols_table.NewPendingToResolve(),
+		})
+	}
+
+	assert.Equal(t, []string{"std::io::printn"}, workspaceSymbolNames(t, h, "printn"))
+}
@@ -4,21 +4,64 @@ import (
 	"encoding/json"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"time"
 
+	"github.com/bep/debounce"
 	"github.com/pherrymason/c3-lsp/internal/c3c"
+	"github.com/pherrymason/c3-lsp/internal/lsp/diagnostics"
+	"github.com/pherrymason/c3-lsp/internal/lsp/layout"
+	"github.com/pherrymason/c3-lsp/pkg/cast"
+	"github.com/pherrymason/c3-lsp/pkg/fs"
 	"github.com/pherrymason/c3-lsp/pkg/option"
+	"github.com/tliron/commonlog"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 type DiagnosticsOpts struct {
-	Enabled bool          `json:"enabled"`
-	Delay   time.Duration `json:"delay"`
+	Enabled  bool          `json:"enabled"`
+	Delay    time.Duration `json:"delay"`
+	Mode     string        `json:"mode"`
+	Disabled []string      `json:"disabled"` // Codes of the lints not to report
+}
+
+// How c3c is run to compute diagnostics.
+const (
+	DiagnosticsModeAuto    = "auto"    // project if the workspace has a project.json, file otherwise
+	DiagnosticsModeProject = "project" // c3c build of the whole project, only saved contents are checked
+	DiagnosticsModeFile    = "file"    // c3c compile-only of the open documents and the modules they use, unsaved contents included
+)
+
+// CacheOpts configures the on-disk cache of parsed workspace and dependency symbols.
+type CacheOpts struct {
+	Enabled bool                  `json:"enabled"`
+	Path    option.Option[string] `json:"path"`
+}
+
+// FormattingOpts configures the formatter. Unset indentation options are taken from the
+// settings the client sends with each formatting request.
+type FormattingOpts struct {
+	IndentWidth  option.Option[int]  `json:"indent-width"`
+	UseTabs      option.Option[bool] `json:"use-tabs"`
+	MaxLineWidth option.Option[int]  `json:"max-line-width"`
+}
+
+// InlayHintsOpts selects the kinds of inlay hints shown.
+type InlayHintsOpts struct {
+	ParameterNames bool `json:"parameter-names"`
+	InferredTypes  bool `json:"inferred-types"`
+	EnumValues     bool `json:"enum-values"`
 }
 
 // ServerOpts holds the options to create a new Server.
 type ServerOpts struct {
 	C3          c3c.C3Opts      `json:"C3Opts"`
 	Diagnostics DiagnosticsOpts `json:"Diagnostics"`
+	Cache       CacheOpts       `json:"Cache"`
+	Formatting  FormattingOpts  `json:"Formatting"`
+	InlayHints  InlayHintsOpts  `json:"InlayHints"`
 
 	LogFilepath      option.Option[string]
 	SendCrashReports bool
@@ -31,22 +74,94 @@ type ServerOptsJson struct {
 		Path        *string  `json:"path,omitempty"`
 		StdlibPath  *string  `json:"stdlib-path,omitempty"`
 		CompileArgs []string `json:"compile-args"`
+		Target      *string  `json:"target,omitempty"`
 	}
 
 	Diagnostics struct {
-		Enabled bool          `json:"enabled"`
-		Delay   time.Duration `json:"delay"`
+		Enabled  *bool          `json:"enabled,omitempty"`
+		Delay    *time.Duration `json:"delay,omitempty"`
+		Mode     *string        `json:"mode,omitempty"`
+		Disabled []string       `json:"disabled,omitempty"`
+	}
+
+	Cache struct {
+		Enabled *bool   `json:"enabled,omitempty"`
+		Path    *string `json:"path,omitempty"`
+	}
+
+	Formatting struct {
+		IndentWidth  *int  `json:"indent-width,omitempty"`
+		UseTabs      *bool `json:"use-tabs,omitempty"`
+		MaxLineWidth *int  `json:"max-line-width,omitempty"`
 	}
+
+	InlayHints struct {
+		ParameterNames *bool `json:"parameter-names,omitempty"`
+		InferredTypes  *bool `json:"inferred-types,omitempty"`
+		EnumValues     *bool `json:"enum-values,omitempty"`
+	}
+
+	LogPath *string `json:"log-path,omitempty"`
 }
 
-func (s *Server) loadServerConfigurationForWorkspace(path string) {
+// Section of the client settings holding the server configuration.
+const configurationSection = "c3lsp"
+
+// loadServerConfigurationForWorkspace builds the server options from the command line
+// arguments, <path>/c3lsp.json and the settings sent by the client, in that order of
+// precedence. It can be called again whenever any of them changes: only the parts of
+// the configuration that changed are reapplied.
+func (s *Server) loadServerConfigurationForWorkspace(project *Project, path string) {
+	previous := project.options
+	previousConfiguredVersion := project.configuredVersion
+
+	project.options = s.defaultOptions
+	project.configuredVersion = option.None[string]()
+
+	if path != "" {
+		if options, found := s.readConfigurationFile(path); found {
+			s.applyConfiguration(project, options, path)
+		}
+	}
+
+	if project.clientConfiguration.IsSome() {
+		s.applyConfiguration(project, project.clientConfiguration.Get(), path)
+	}
+
+	if !s.diagnosticsSupported {
+		project.options.Diagnostics.Enabled = false
+	}
+
+	if project.options.Diagnostics.Delay != previous.Diagnostics.Delay {
+		project.diagnosticDebounced = debounce.New(project.options.Diagnostics.Delay * time.Millisecond)
+	}
+
+	if project.options.LogFilepath != previous.LogFilepath && project.options.LogFilepath.IsSome() {
+		logPath := project.options.LogFilepath.Get()
+		commonlog.Configure(2, &logPath)
+	}
+
+	stdlibChanged := !project.stdlibLoaded ||
+		project.configuredVersion != previousConfiguredVersion ||
+		project.options.C3.Path != previous.C3.Path ||
+		project.options.C3.StdlibPath != previous.C3.StdlibPath
+	if stdlibChanged {
+		s.applyVersionAndLoadStdlib(project, project.configuredVersion)
+		project.stdlibLoaded = true
+	} else {
+		project.options.C3.Version = previous.C3.Version
+	}
+}
+
+// readConfigurationFile reads <path>/c3lsp.json, if present.
+func (s *Server) readConfigurationFile(path string) (ServerOptsJson, bool) {
+	var options ServerOptsJson
+
 	file, err := os.Open(path + "/c3lsp.json")
 	if err != nil {
 		// No configuration project file found - use defaults
 		s.server.Log.Infof("No configuration " + path + "/c3lsp.json found")
-		// Still need to initialize stdlib with default version
-		s.applyVersionAndLoadStdlib(option.None[string]())
-		return
+		return options, false
 	}
 	defer file.Close()
 
@@ -59,46 +174,169 @@ func (s *Server) loadServerConfigurationForWorkspace(path string) {
 	}
 	s.server.Log.Infof("%s", data)
 
-	var options ServerOptsJson
 	err = json.Unmarshal(data, &options)
 	if err != nil {
 		s.server.Log.Errorf("Error deserializing config json: %v", err)
 	}
 
+	return options, true
+}
+
+// applyConfiguration overrides the project options with the ones set in options.
+// Relative paths are resolved from path.
+func (s *Server) applyConfiguration(project *Project, options ServerOptsJson, path string) {
 	if options.C3.StdlibPath != nil {
-		s.options.C3.StdlibPath = option.Some(*options.C3.StdlibPath)
+		project.options.C3.StdlibPath = option.Some(*options.C3.StdlibPath)
 		s.server.Log.Infof("Stdlib:%s", *options.C3.StdlibPath)
-		s.server.Log.Infof("Setted Stdlib:%s", s.options.C3.StdlibPath.Get())
 	}
 
 	// Store user-configured version (from c3lsp.json)
-	var userConfiguredVersion option.Option[string]
 	if options.C3.Version != nil {
-		userConfiguredVersion = option.Some(*options.C3.Version)
+		project.configuredVersion = option.Some(*options.C3.Version)
 	}
 
 	if options.C3.Path != nil {
-		s.options.C3.Path = option.Some(*options.C3.Path)
+		project.options.C3.Path = option.Some(*options.C3.Path)
 	}
 
 	if len(options.C3.CompileArgs) > 0 {
-		s.options.C3.CompileArgs = options.C3.CompileArgs
+		project.options.C3.CompileArgs = options.C3.CompileArgs
+	}
+
+	if options.C3.Target != nil {
+		if _, known := layout.TargetByName(*options.C3.Target); !known {
+			s.server.Log.Warningf("Unknown target in configuration: %s", *options.C3.Target)
+		}
+		project.options.C3.Target = option.Some(*options.C3.Target)
 	}
 
-	// Apply version and load stdlib
-	s.applyVersionAndLoadStdlib(userConfiguredVersion)
+	if options.Diagnostics.Enabled != nil {
+		project.options.Diagnostics.Enabled = *options.Diagnostics.Enabled
+	}
+
+	if options.Diagnostics.Delay != nil {
+		project.options.Diagnostics.Delay = *options.Diagnostics.Delay
+	}
+
+	if options.Diagnostics.Mode != nil {
+		switch *options.Diagnostics.Mode {
+		case DiagnosticsModeAuto, DiagnosticsModeProject, DiagnosticsModeFile:
+			project.options.Diagnostics.Mode = *options.Diagnostics.Mode
+		default:
+			s.server.Log.Warningf("Unknown diagnostics mode in configuration: %s", *options.Diagnostics.Mode)
+		}
+	}
 
-	// Change log filepath?
-	// Should be able to do that from c3lsp.json?
+	if options.Diagnostics.Disabled != nil {
+		for _, code := range options.Diagnostics.Disabled {
+			if !slices.Contains(diagnostics.Codes, code) {
+				s.server.Log.Warningf("Unknown diagnostic code in configuration: %s", code)
+			}
+		}
+		project.options.Diagnostics.Disabled = options.Diagnostics.Disabled
+	}
+
+	if options.Cache.Enabled != nil {
+		project.options.Cache.Enabled = *options.Cache.Enabled
+	}
+
+	if options.Cache.Path != nil {
+		cachePath := *options.Cache.Path
+		if !filepath.IsAbs(cachePath) {
+			cachePath = filepath.Join(path, cachePath)
+		}
+		project.options.Cache.Path = option.Some(cachePath)
+	}
+
+	if options.Formatting.IndentWidth != nil {
+		project.options.Formatting.IndentWidth = option.Some(*options.Formatting.IndentWidth)
+	}
+
+	if options.Formatting.UseTabs != nil {
+		project.options.Formatting.UseTabs = option.Some(*options.Formatting.UseTabs)
+	}
+
+	if options.Formatting.MaxLineWidth != nil {
+		project.options.Formatting.MaxLineWidth = option.Some(*options.Formatting.MaxLineWidth)
+	}
+
+	if options.InlayHints.ParameterNames != nil {
+		project.options.InlayHints.ParameterNames = *options.InlayHints.ParameterNames
+	}
+
+	if options.InlayHints.InferredTypes != nil {
+		project.options.InlayHints.InferredTypes = *options.InlayHints.InferredTypes
+	}
+
+	if options.InlayHints.EnumValues != nil {
+		project.options.InlayHints.EnumValues = *options.InlayHints.EnumValues
+	}
+
+	if options.LogPath != nil {
+		project.options.LogFilepath = option.Some(*options.LogPath)
+	}
+}
+
+// pullClientConfiguration requests the settings of every project to the client through
+// workspace/configuration and reloads their configuration with them.
+// Like any request to the client, it must not be called from a request handler.
+func (s *Server) pullClientConfiguration(context *glsp.Context) {
+	if !s.configurationRequestSupported {
+		return
+	}
+
+	s.stateLock.Lock()
+	projects := append([]*Project{}, s.projects...)
+	s.stateLock.Unlock()
+
+	items := []protocol.ConfigurationItem{}
+	for _, project := range projects {
+		item := protocol.ConfigurationItem{Section: cast.ToPtr(configurationSection)}
+		if project.root != "" {
+			item.ScopeURI = cast.ToPtr(fs.ConvertPathToURI(project.root, option.None[string]()))
+		}
+		items = append(items, item)
+	}
+
+	var results []any
+	context.Call(protocol.ServerWorkspaceConfiguration, protocol.ConfigurationParams{Items: items}, &results)
+
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+
+	for i, settings := range results {
+		if i < len(projects) {
+			s.setClientConfiguration(projects[i], settings)
+		}
+	}
+}
+
+// setClientConfiguration stores the settings sent by the client for project and reapplies its configuration.
+func (s *Server) setClientConfiguration(project *Project, settings any) {
+	if settings == nil {
+		return
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		s.server.Log.Errorf("Error serializing client configuration: %v", err)
+		return
+	}
+
+	var options ServerOptsJson
+	if err := json.Unmarshal(data, &options); err != nil {
+		s.server.Log.Errorf("Error deserializing client configuration: %v", err)
+		return
+	}
 
-	// Enable/disable sendCrashReports
-	// Should be able to do that from c3lsp.json?
+	project.clientConfiguration = option.Some(options)
+	s.loadServerConfigurationForWorkspace(project, project.root)
 }
 
 // applyVersionAndLoadStdlib determines the C3 version and loads stdlib
-func (s *Server) applyVersionAndLoadStdlib(userConfiguredVersion option.Option[string]) {
+func (s *Server) applyVersionAndLoadStdlib(project *Project, userConfiguredVersion option.Option[string]) {
 	// Detect version from binary (either from configured path or system PATH)
-	detectedBinaryVersion := c3c.GetC3Version(s.options.C3.Path)
+	detectedBinaryVersion := c3c.GetC3Version(project.options.C3.Path)
 
 	// Validate and set the final version to use
 	var finalVersion option.Option[string]
@@ -122,18 +360,18 @@ func (s *Server) applyVersionAndLoadStdlib(userConfiguredVersion option.Option[s
 	}
 	// else: no version at all, will default to latest supported
 
-	s.options.C3.Version = finalVersion
+	project.options.C3.Version = finalVersion
 
-	requestedLanguageVersion := checkRequestedLanguageVersion(s.server.Log, s.options.C3.Version)
+	requestedLanguageVersion := checkRequestedLanguageVersion(s.server.Log, project.options.C3.Version)
 
 	// Determine c3cLibPath to pass to SetLanguageVersion
 	// Priority: explicit stdlib-path > c3c-path/lib > empty string
 	c3cLibPath := ""
-	if s.options.C3.StdlibPath.IsSome() {
-		c3cLibPath = s.options.C3.StdlibPath.Get()
-	} else if s.options.C3.Path.IsSome() {
-		c3cLibPath = s.options.C3.Path.Get() + "/lib"
+	if project.options.C3.StdlibPath.IsSome() {
+		c3cLibPath = project.options.C3.StdlibPath.Get()
+	} else if project.options.C3.Path.IsSome() {
+		c3cLibPath = project.options.C3.Path.Get() + "/lib"
 	}
 
-	s.state.SetLanguageVersion(requestedLanguageVersion, c3cLibPath)
+	project.state.SetLanguageVersion(requestedLanguageVersion, c3cLibPath)
 }
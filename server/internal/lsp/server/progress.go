@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/pherrymason/c3-lsp/pkg/cast"
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var progressTokenCounter atomic.Uint64
+
+// workDoneProgress reports the progress of a long running task to the client
+// through $/progress notifications.
+type workDoneProgress struct {
+	notify     glsp.NotifyFunc
+	token      protocol.ProgressToken
+	percentage protocol.UInteger
+}
+
+// beginWorkDoneProgress asks the client to create a progress indicator and starts it.
+// It must not be called from a request handler: it waits for the client response, which
+// can't be read while a handler is running.
+func (h *Server) beginWorkDoneProgress(context *glsp.Context, title string) option.Option[*workDoneProgress] {
+	if !h.workDoneProgressSupported {
+		return option.None[*workDoneProgress]()
+	}
+
+	token := protocol.ProgressToken{Value: fmt.Sprintf("c3-lsp/%d", progressTokenCounter.Add(1))}
+	context.Call(protocol.ServerWindowWorkDoneProgressCreate, protocol.WorkDoneProgressCreateParams{Token: token}, nil)
+
+	progress := &workDoneProgress{notify: context.Notify, token: token}
+	progress.notify(string(protocol.MethodProgress), protocol.ProgressParams{
+		Token: token,
+		Value: protocol.WorkDoneProgressBegin{
+			Kind:        "begin",
+			Title:       title,
+			Cancellable: cast.ToPtr(false),
+			Percentage:  cast.ToPtr(protocol.UInteger(0)),
+		},
+	})
+
+	return option.Some(progress)
+}
+
+// report updates the progress message. Notifications are only sent when the percentage changes,
+// to avoid flooding the client.
+func (p *workDoneProgress) report(message string, done int, total int) {
+	percentage := protocol.UInteger(100)
+	if total > 0 {
+		percentage = protocol.UInteger(done * 100 / total)
+	}
+	if percentage == p.percentage && done != total {
+		return
+	}
+	p.percentage = percentage
+
+	p.notify(string(protocol.MethodProgress), protocol.ProgressParams{
+		Token: p.token,
+		Value: protocol.WorkDoneProgressReport{
+			Kind:       "report",
+			Message:    &message,
+			Percentage: &percentage,
+		},
+	})
+}
+
+func (p *workDoneProgress) end(message string) {
+	p.notify(string(protocol.MethodProgress), protocol.ProgressParams{
+		Token: p.token,
+		Value: protocol.WorkDoneProgressEnd{
+			Kind:    "end",
+			Message: &message,
+		},
+	})
+}
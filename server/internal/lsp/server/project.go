@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bep/debounce"
+	l "github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/pkg/fs"
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	"github.com/pherrymason/c3-lsp/pkg/utils"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Project is the context of a workspace folder: each one has its own configuration,
+// dependencies, symbols and diagnostics. Stdlib symbols are shared between them.
+type Project struct {
+	root    string // Normalized path of the workspace folder. Empty when there's no workspace.
+	state   *l.ProjectState
+	options ServerOpts
+
+	clientConfiguration option.Option[ServerOptsJson]
+	configuredVersion   option.Option[string]
+	stdlibLoaded        bool
+	indexed             bool // Whether the workspace folder and its dependencies have been indexed
+
+	// Files of the libraries the project depends on, as resolved from project.json.
+	dependencies map[string]bool
+
+	diagnosticDebounced func(func())
+
+	diagnosticsLock   sync.Mutex
+	cancelDiagnostics context.CancelFunc // Stops the c3c check in progress, if any
+}
+
+func (h *Server) newProject(root string) *Project {
+	state := l.NewProjectState(h.server.Log, option.None[string](), h.defaultOptions.Debug)
+	state.SetProjectRootURI(root)
+
+	return &Project{
+		root:                root,
+		state:               &state,
+		options:             h.defaultOptions,
+		dependencies:        map[string]bool{},
+		diagnosticDebounced: debounce.New(h.defaultOptions.Diagnostics.Delay * time.Millisecond),
+	}
+}
+
+// addProject creates the project of a workspace folder and loads its configuration.
+func (h *Server) addProject(folderURI protocol.DocumentUri) *Project {
+	project := h.newProject(utils.NormalizePath(folderURI))
+	h.projects = append(h.projects, project)
+
+	path, _ := fs.UriToPath(folderURI)
+	h.loadServerConfigurationForWorkspace(project, path)
+
+	return project
+}
+
+func (h *Server) removeProject(folderURI protocol.DocumentUri) option.Option[*Project] {
+	root := utils.NormalizePath(folderURI)
+	for i, project := range h.projects {
+		if project.root == root {
+			h.projects = append(h.projects[:i], h.projects[i+1:]...)
+			return option.Some(project)
+		}
+	}
+
+	return option.None[*Project]()
+}
+
+// projectFor returns the project owning docId: the one with the deepest workspace folder
+// containing it. Documents outside every workspace folder belong to the first project.
+func (h *Server) projectFor(docId string) *Project {
+	var owner *Project
+	for _, project := range h.projects {
+		if !isPathInside(docId, project.root) {
+			continue
+		}
+		if owner == nil || len(project.root) > len(owner.root) {
+			owner = project
+		}
+	}
+
+	if owner == nil {
+		return h.projects[0]
+	}
+
+	return owner
+}
+
+func isPathInside(path string, root string) bool {
+	if root == "" {
+		return false
+	}
+
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// startDiagnostics cancels the c3c check in progress, whose results would be outdated,
+// and returns the context of a new one.
+func (p *Project) startDiagnostics() context.Context {
+	p.diagnosticsLock.Lock()
+	defer p.diagnosticsLock.Unlock()
+
+	if p.cancelDiagnostics != nil {
+		p.cancelDiagnostics()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancelDiagnostics = cancel
+
+	return ctx
+}
+
+// cancelRunningDiagnostics stops the c3c check in progress, if any.
+func (p *Project) cancelRunningDiagnostics() {
+	p.diagnosticsLock.Lock()
+	defer p.diagnosticsLock.Unlock()
+
+	if p.cancelDiagnostics != nil {
+		p.cancelDiagnostics()
+		p.cancelDiagnostics = nil
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPathInside(t *testing.T) {
+	root := filepath.Join("/", "workspace", "app")
+
+	cases := []struct {
+		name     string
+		path     string
+		expected bool
+	}{
+		{"file in root", filepath.Join(root, "main.c3"), true},
+		{"file in subfolder", filepath.Join(root, "src", "main.c3"), true},
+		{"root itself", root, true},
+		{"file in parent", filepath.Join("/", "workspace", "main.c3"), false},
+		{"sibling folder sharing prefix", filepath.Join("/", "workspace", "app2", "main.c3"), false},
+		{"file named like parent folder", filepath.Join(root, "..foo", "main.c3"), true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isPathInside(tt.path, root))
+		})
+	}
+
+	assert.False(t, isPathInside(filepath.Join(root, "main.c3"), ""), "Projects without root contain nothing")
+}
+
+func TestProjectFor(t *testing.T) {
+	app := &Project{root: filepath.Join("/", "workspace", "app")}
+	nested := &Project{root: filepath.Join("/", "workspace", "app", "libs", "nested")}
+	other := &Project{root: filepath.Join("/", "workspace", "other")}
+	h := &Server{projects: []*Project{app, nested, other}}
+
+	t.Run("returns project containing document", func(t *testing.T) {
+		assert.Same(t, app, h.projectFor(filepath.Join("/", "workspace", "app", "main.c3")))
+		assert.Same(t, other, h.projectFor(filepath.Join("/", "workspace", "other", "main.c3")))
+	})
+
+	t.Run("returns deepest project for nested folders", func(t *testing.T) {
+		assert.Same(t, nested, h.projectFor(filepath.Join("/", "workspace", "app", "libs", "nested", "lib.c3")))
+		assert.Same(t, app, h.projectFor(filepath.Join("/", "workspace", "app", "libs", "other.c3")))
+	})
+
+	t.Run("returns deepest project regardless of order", func(t *testing.T) {
+		reversed := &Server{projects: []*Project{nested, app}}
+		assert.Same(t, nested, reversed.projectFor(filepath.Join("/", "workspace", "app", "libs", "nested", "lib.c3")))
+	})
+
+	t.Run("returns first project for documents outside every folder", func(t *testing.T) {
+		assert.Same(t, app, h.projectFor(filepath.Join("/", "tmp", "scratch.c3")))
+	})
+}
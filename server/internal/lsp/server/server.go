@@ -1,15 +1,16 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
-	"time"
+	"sync"
+	"sync/atomic"
 
-	"github.com/bep/debounce"
 	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
-	l "github.com/pherrymason/c3-lsp/internal/lsp/project_state"
 	"github.com/pherrymason/c3-lsp/internal/lsp/search"
 	"github.com/pherrymason/c3-lsp/internal/lsp/search_v2"
+	"github.com/pherrymason/c3-lsp/internal/lsp/semantic_tokens"
 	"github.com/pherrymason/c3-lsp/pkg/option"
 	p "github.com/pherrymason/c3-lsp/pkg/parser"
 	"github.com/pherrymason/c3-lsp/pkg/utils"
@@ -18,19 +19,40 @@ import (
 	_ "github.com/tliron/commonlog/simple"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
+	protocol317 "github.com/tliron/glsp/protocol_3_17"
 	glspserv "github.com/tliron/glsp/server"
 )
 
 type Server struct {
 	server  *glspserv.Server
-	options ServerOpts
 	version string
 
-	state  *l.ProjectState
+	// Options given through command line arguments, on top of which each project configuration is applied.
+	defaultOptions ServerOpts
+
+	// One project per workspace folder.
+	projects []*Project
+
 	parser *p.Parser
 	search search.SearchInterface
 
-	diagnosticDebounced func(func())
+	semanticTokens *semantic_tokens.Cache
+
+	// Serializes access to the state between request handlers and background indexing.
+	stateLock sync.Mutex
+
+	diagnosticsSupported               bool
+	pullDiagnosticsSupported           bool
+	diagnosticRefreshSupported         bool
+	workDoneProgressSupported          bool
+	watchedFilesRegistrationSupported  bool
+	configurationRegistrationSupported bool
+	configurationRequestSupported      bool
+
+	// Sends requests to the client. Like context.Call, it must not be called from a request handler.
+	clientCall glsp.CallFunc
+	// Set while a workspace/diagnostic/refresh request is about to be sent.
+	diagnosticRefreshPending atomic.Bool
 }
 
 // ServerOpts holds the options to create a new Server.
@@ -73,11 +95,10 @@ func NewServer(opts ServerOpts, appName string, version string) *Server {
 	}
 
 	handler := protocol.Handler{}
-	glspServer := glspserv.NewServer(&handler, appName, true)
+	handler317 := &protocol317Handler{Handler: &handler}
+	lockingHandler := &stateLockingHandler{handler: handler317}
+	glspServer := glspserv.NewServer(lockingHandler, appName, true)
 
-	requestedLanguageVersion := checkRequestedLanguageVersion(logger, opts.C3.Version)
-
-	state := l.NewProjectState(logger, option.Some(requestedLanguageVersion), opts.Debug)
 	parser := p.NewParser(logger)
 
 	// Instantiate search implementation based on feature flag
@@ -93,34 +114,22 @@ func NewServer(opts ServerOpts, appName string, version string) *Server {
 
 	server := &Server{
 		server:  glspServer,
-		options: opts,
 		version: version,
 
-		state:  &state,
+		defaultOptions:       opts,
+		diagnosticsSupported: true,
+
 		parser: &parser,
 		search: searchImpl,
 
-		diagnosticDebounced: debounce.New(opts.Diagnostics.Delay * time.Millisecond),
+		semanticTokens: semantic_tokens.NewCache(),
 	}
+	lockingHandler.lock = &server.stateLock
 
-	handler.Initialized = func(context *glsp.Context, params *protocol.InitializedParams) error {
-		/*
-			context.Notify(protocol.ServerWorkspaceWorkspaceFolders, protocol.PublishDiagnosticsParams{
-				URI:         doc.URI,
-				Diagnostics: diagnostics,
-			})*/
-		/*sendCrashStatus := "disabled"
-		if opts.SendCrashReports {
-			sendCrashStatus = "enabled"
-		}
+	// Until the client tells which are the workspace folders, documents belong to a project without root.
+	server.projects = []*Project{server.newProject("")}
 
-		context.Notify(protocol.ServerWindowShowMessage, protocol.ShowMessageParams{
-			Type:    protocol.MessageTypeInfo,
-			Message: fmt.Sprintf("SendCrash: %s", sendCrashStatus),
-		})
-		*/
-		return nil
-	}
+	handler.Initialized = server.Initialized
 	handler.Shutdown = shutdown
 	handler.SetTrace = setTrace
 
@@ -142,9 +151,22 @@ func NewServer(opts ServerOpts, appName string, version string) *Server {
 	handler.TextDocumentHover = server.TextDocumentHover
 	handler.TextDocumentDeclaration = server.TextDocumentDeclaration
 	handler.TextDocumentDefinition = server.TextDocumentDefinition
+	handler.TextDocumentReferences = server.TextDocumentReferences
+	handler.TextDocumentPrepareRename = server.TextDocumentPrepareRename
+	handler.TextDocumentRename = server.TextDocumentRename
+	handler.TextDocumentDocumentSymbol = server.TextDocumentDocumentSymbol
 	handler.TextDocumentCompletion = server.TextDocumentCompletion
 	handler.TextDocumentSignatureHelp = server.TextDocumentSignatureHelp
+	handler.TextDocumentCodeAction = server.TextDocumentCodeAction
+	handler.TextDocumentFormatting = server.TextDocumentFormatting
+	handler.TextDocumentRangeFormatting = server.TextDocumentRangeFormatting
+	handler.TextDocumentOnTypeFormatting = server.TextDocumentOnTypeFormatting
+	handler.WorkspaceSymbol = server.WorkspaceSymbol
+	handler.TextDocumentSemanticTokensFull = server.TextDocumentSemanticTokensFull
+	handler.TextDocumentSemanticTokensFullDelta = server.TextDocumentSemanticTokensFullDelta
+	handler.TextDocumentSemanticTokensRange = server.TextDocumentSemanticTokensRange
 	handler.WorkspaceDidChangeWatchedFiles = server.WorkspaceDidChangeWatchedFiles
+	handler.WorkspaceDidChangeConfiguration = server.WorkspaceDidChangeConfiguration
 	handler.WorkspaceDidDeleteFiles = server.WorkspaceDidDeleteFiles
 	handler.WorkspaceDidRenameFiles = server.WorkspaceDidRenameFiles
 
@@ -152,14 +174,140 @@ func NewServer(opts ServerOpts, appName string, version string) *Server {
 		return params, nil
 	}
 
-	handler.WorkspaceDidChangeWorkspaceFolders = func(context *glsp.Context, params *protocol.DidChangeWorkspaceFoldersParams) error {
+	handler.WorkspaceDidChangeWorkspaceFolders = server.WorkspaceDidChangeWorkspaceFolders
 
-		return nil
-	}
+	handler.TextDocumentPrepareCallHierarchy = server.TextDocumentPrepareCallHierarchy
+	handler.CallHierarchyIncomingCalls = server.CallHierarchyIncomingCalls
+	handler.CallHierarchyOutgoingCalls = server.CallHierarchyOutgoingCalls
+	handler.TextDocumentImplementation = server.TextDocumentImplementation
+
+	handler317.TextDocumentDiagnostic = server.TextDocumentDiagnostic
+	handler317.WorkspaceDiagnostic = server.WorkspaceDiagnostic
+	handler317.TextDocumentInlayHint = server.TextDocumentInlayHint
+	handler317.InlayHintResolve = server.InlayHintResolve
+	handler317.TextDocumentPrepareTypeHierarchy = server.TextDocumentPrepareTypeHierarchy
+	handler317.TypeHierarchySupertypes = server.TypeHierarchySupertypes
+	handler317.TypeHierarchySubtypes = server.TypeHierarchySubtypes
 
 	return server
 }
 
+// stateLockingHandler runs every message handler while holding the state lock,
+// so they don't see the state while the background indexing is modifying it.
+type stateLockingHandler struct {
+	handler glsp.Handler
+	lock    *sync.Mutex
+}
+
+func (h *stateLockingHandler) Handle(context *glsp.Context) (r any, validMethod bool, validParams bool, err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.handler.Handle(context)
+}
+
+// protocol317Handler answers the requests added in LSP 3.17, like the ones of the diagnostics
+// pull model, inlay hints or type hierarchy, and passes any other message to the 3.16 handler.
+type protocol317Handler struct {
+	*protocol.Handler
+
+	TextDocumentDiagnostic protocol317.TextDocumentDiagnosticFunc
+	WorkspaceDiagnostic    WorkspaceDiagnosticFunc
+	TextDocumentInlayHint  TextDocumentInlayHintFunc
+	InlayHintResolve       InlayHintResolveFunc
+
+	TextDocumentPrepareTypeHierarchy TextDocumentPrepareTypeHierarchyFunc
+	TypeHierarchySupertypes          TypeHierarchySupertypesFunc
+	TypeHierarchySubtypes            TypeHierarchySubtypesFunc
+}
+
+func (h *protocol317Handler) Handle(context *glsp.Context) (r any, validMethod bool, validParams bool, err error) {
+	if !h.IsInitialized() {
+		return h.Handler.Handle(context)
+	}
+
+	switch context.Method {
+	case protocol317.MethodTextDocumentDiagnostic:
+		if h.TextDocumentDiagnostic != nil {
+			validMethod = true
+			var params protocol317.DocumentDiagnosticParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = h.TextDocumentDiagnostic(context, &params)
+			}
+			return
+		}
+
+	case MethodWorkspaceDiagnostic:
+		if h.WorkspaceDiagnostic != nil {
+			validMethod = true
+			var params WorkspaceDiagnosticParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = h.WorkspaceDiagnostic(context, &params)
+			}
+			return
+		}
+
+	case MethodTextDocumentInlayHint:
+		if h.TextDocumentInlayHint != nil {
+			validMethod = true
+			var params InlayHintParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = h.TextDocumentInlayHint(context, &params)
+			}
+			return
+		}
+
+	case MethodInlayHintResolve:
+		if h.InlayHintResolve != nil {
+			validMethod = true
+			var params InlayHint
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = h.InlayHintResolve(context, &params)
+			}
+			return
+		}
+
+	case MethodTextDocumentPrepareTypeHierarchy:
+		if h.TextDocumentPrepareTypeHierarchy != nil {
+			validMethod = true
+			var params TypeHierarchyPrepareParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = h.TextDocumentPrepareTypeHierarchy(context, &params)
+			}
+			return
+		}
+
+	case MethodTypeHierarchySupertypes:
+		if h.TypeHierarchySupertypes != nil {
+			validMethod = true
+			var params TypeHierarchySupertypesParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = h.TypeHierarchySupertypes(context, &params)
+			}
+			return
+		}
+
+	case MethodTypeHierarchySubtypes:
+		if h.TypeHierarchySubtypes != nil {
+			validMethod = true
+			var params TypeHierarchySubtypesParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = h.TypeHierarchySubtypes(context, &params)
+			}
+			return
+		}
+	}
+
+	return h.Handler.Handle(context)
+}
+
 // Run starts the Language Server in stdio mode.
 func (s *Server) Run() error {
 	return errors.Wrap(s.server.RunStdio(), "lsp")
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/internal/lsp/search"
+	"github.com/pherrymason/c3-lsp/internal/lsp/semantic_tokens"
+	"github.com/pherrymason/c3-lsp/pkg/document"
+	"github.com/pherrymason/c3-lsp/pkg/fs"
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	p "github.com/pherrymason/c3-lsp/pkg/parser"
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const testRoot = "/workspace"
+
+// newTestServer returns a server with a single project rooted at testRoot, holding sources
+// as documents opened in the client. Sources are keyed by their path relative to testRoot.
+func newTestServer(t *testing.T, sources map[string]string) (*Server, *Project) {
+	t.Helper()
+
+	logger := commonlog.MockLogger{}
+	parser := p.NewParser(logger)
+	searcher := search.NewSearch(logger, false)
+
+	state := project_state.NewProjectState(logger, option.Some("dummy"), false)
+	state.SetProjectRootURI(testRoot)
+	project := &Project{
+		root:         testRoot,
+		state:        &state,
+		dependencies: map[string]bool{},
+		indexed:      true,
+	}
+
+	for name, source := range sources {
+		doc := document.NewDocument(testDocId(name), source)
+		state.RefreshDocumentIdentifiers(&doc, &parser)
+		state.MarkDocumentOpened(doc.URI)
+	}
+
+	h := &Server{
+		projects:       []*Project{project},
+		parser:         &parser,
+		search:         &searcher,
+		semanticTokens: semantic_tokens.NewCache(),
+	}
+
+	return h, project
+}
+
+// testDocId returns the id of the document placed at name inside testRoot.
+func testDocId(name string) string {
+	return filepath.Join(testRoot, name)
+}
+
+func testDocURI(name string) protocol.DocumentUri {
+	return fs.ConvertPathToURI(testDocId(name), option.None[string]())
+}
@@ -0,0 +1,175 @@
+package symbol_trie
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+)
+
+const (
+	scoreExact           = 1000
+	scoreExactIgnoreCase = 900
+	scorePrefix          = 800
+	scorePrefixIgnore    = 700
+	scoreSubsequenceMax  = 600
+
+	bonusBoundary    = 8
+	bonusConsecutive = 5
+)
+
+type FuzzyResult struct {
+	Symbol symbols.Indexable
+	Score  int
+}
+
+// FuzzySearch returns every symbol whose name matches query, together with
+// the quality of the match. Results are not sorted.
+// accepted queries
+//   - name         -> fuzzy matches the symbol name: `mLst` matches `MyList`
+//   - Type.method  -> fuzzy matches methods including their type
+//   - mod::name    -> as above, but the symbol module must also match `mod`
+func (t *Trie) FuzzySearch(query string) []FuzzyResult {
+	moduleQuery := ""
+	nameQuery := query
+	if i := strings.LastIndex(query, "::"); i >= 0 {
+		moduleQuery = query[:i]
+		nameQuery = query[i+2:]
+	}
+
+	var results []FuzzyResult
+	for _, symbol := range collectSymbols(t.root, false) {
+		if symbol.GetName() == "" {
+			continue
+		}
+
+		score, ok := matchSymbolName(nameQuery, symbol)
+		if !ok {
+			continue
+		}
+
+		if moduleQuery != "" {
+			moduleScore, ok := FuzzyScore(moduleQuery, symbol.GetModuleString())
+			if !ok {
+				continue
+			}
+			score += moduleScore / 2
+		}
+
+		results = append(results, FuzzyResult{Symbol: symbol, Score: score})
+	}
+
+	return results
+}
+
+func matchSymbolName(query string, symbol symbols.Indexable) (int, bool) {
+	score, ok := FuzzyScore(query, symbol.GetName())
+	if function, isFunction := symbol.(*symbols.Function); isFunction && function.GetTypeIdentifier() != "" {
+		// Methods can be searched without their type prefix.
+		if methodScore, methodOk := FuzzyScore(query, function.GetMethodName()); methodOk && (!ok || methodScore > score) {
+			return methodScore, true
+		}
+	}
+
+	return score, ok
+}
+
+// FuzzyScore tells if query matches candidate and how good the match is.
+// Exact and prefix matches score highest, followed by subsequence matches,
+// which are favoured when they hit word boundaries (camelCase, snake_case).
+func FuzzyScore(query string, candidate string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+
+	lengthDiff := len(candidate) - len(query)
+	lowerQuery := strings.ToLower(query)
+	lowerCandidate := strings.ToLower(candidate)
+	switch {
+	case candidate == query:
+		return scoreExact, true
+	case lowerCandidate == lowerQuery:
+		return scoreExactIgnoreCase, true
+	case strings.HasPrefix(candidate, query):
+		return scorePrefix - min(lengthDiff, 99), true
+	case strings.HasPrefix(lowerCandidate, lowerQuery):
+		return scorePrefixIgnore - min(lengthDiff, 99), true
+	}
+
+	score, ok := subsequenceScore([]rune(lowerQuery), []rune(candidate))
+	if !ok {
+		return 0, false
+	}
+
+	return min(score-lengthDiff/4, scoreSubsequenceMax), true
+}
+
+// subsequenceScore finds the best way of matching each query character, in order,
+// with a candidate character.
+func subsequenceScore(query []rune, candidate []rune) (int, bool) {
+	if len(query) > len(candidate) {
+		return 0, false
+	}
+
+	const noMatch = -1 << 30
+	// best[j]: best score having matched the current query character at candidate[j]
+	best := make([]int, len(candidate))
+	previous := make([]int, len(candidate))
+
+	for i, q := range query {
+		bestBefore := noMatch // best score of previous query character before j-1
+		for j, c := range candidate {
+			best[j] = noMatch
+
+			if i > 0 && j >= 2 && previous[j-2] > bestBefore {
+				bestBefore = previous[j-2]
+			}
+
+			if unicode.ToLower(c) != q {
+				continue
+			}
+
+			charScore := 1
+			if isWordBoundary(candidate, j) {
+				charScore += bonusBoundary
+			}
+
+			if i == 0 {
+				best[j] = charScore
+				continue
+			}
+
+			if j > 0 && previous[j-1] != noMatch {
+				best[j] = previous[j-1] + charScore + bonusConsecutive
+			}
+			if bestBefore != noMatch && bestBefore+charScore > best[j] {
+				best[j] = bestBefore + charScore
+			}
+		}
+
+		copy(previous, best)
+	}
+
+	result := noMatch
+	for _, score := range previous {
+		result = max(result, score)
+	}
+
+	return result, result != noMatch
+}
+
+func isWordBoundary(candidate []rune, j int) bool {
+	if j == 0 {
+		return true
+	}
+
+	prev, current := candidate[j-1], candidate[j]
+	switch {
+	case prev == '_' || prev == '.' || prev == ':' || prev == '@' || prev == '$' || prev == '#':
+		return true
+	case unicode.IsLower(prev) && unicode.IsUpper(current):
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,80 @@
+package symbol_trie
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	cases := []struct {
+		query     string
+		candidate string
+		matches   bool
+	}{
+		{"MyList", "MyList", true},
+		{"mylist", "MyList", true},
+		{"My", "MyList", true},
+		{"ML", "MyList", true},
+		{"mlst", "MyList", true},
+		{"nb", "new_buffer", true},
+		{"lm", "MyList", false},
+		{"MyListX", "MyList", false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.query+" in "+tt.candidate, func(t *testing.T) {
+			_, ok := FuzzyScore(tt.query, tt.candidate)
+			assert.Equal(t, tt.matches, ok)
+		})
+	}
+
+	t.Run("Ranks exact over prefix over subsequence matches", func(t *testing.T) {
+		exact, _ := FuzzyScore("list", "list")
+		prefix, _ := FuzzyScore("list", "list_len")
+		subsequence, _ := FuzzyScore("list", "long_items_set")
+
+		assert.Greater(t, exact, prefix)
+		assert.Greater(t, prefix, subsequence)
+	})
+
+	t.Run("Ranks word boundary matches over scattered matches", func(t *testing.T) {
+		boundary, _ := FuzzyScore("nb", "new_buffer")
+		scattered, _ := FuzzyScore("nb", "unable")
+
+		assert.Greater(t, boundary, scattered)
+	})
+}
+
+func TestTrie_FuzzySearch(t *testing.T) {
+	trie := NewTrie()
+	docId := "doc"
+	trie.Insert(symbols.NewStructBuilder("MyList", "app::collections", docId).Build())
+	trie.Insert(symbols.NewStructBuilder("Mutex", "app::thread", docId).Build())
+	trie.Insert(symbols.NewFunctionBuilder("push_back", symbols.NewTypeFromString("void", "app::collections"), "app::collections", docId).WithTypeIdentifier("MyList").Build())
+
+	names := func(results []FuzzyResult) []string {
+		found := []string{}
+		for _, result := range results {
+			found = append(found, result.Symbol.GetName())
+		}
+		return found
+	}
+
+	t.Run("Matches short names", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"Mutex"}, names(trie.FuzzySearch("mtx")))
+	})
+
+	t.Run("Matches methods without their type", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"MyList.push_back"}, names(trie.FuzzySearch("pb")))
+	})
+
+	t.Run("Matches methods with their type", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"MyList.push_back"}, names(trie.FuzzySearch("MyList.push")))
+	})
+
+	t.Run("Filters by module", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"Mutex"}, names(trie.FuzzySearch("thread::mt")))
+	})
+}
@@ -17,23 +17,33 @@ func NewTrie() *Trie {
 }
 
 func (t *Trie) ClearByTag(tag string) {
-	clearByTagHelper(t.root, tag)
+	clearByTagHelper(t.root, map[string]bool{tag: true})
 }
 
-func clearByTagHelper(node *TrieNode, docId string) bool {
+// ClearByTags removes the symbols of all the given documents in a single pass.
+func (t *Trie) ClearByTags(tags []string) {
+	docIds := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		docIds[tag] = true
+	}
+
+	clearByTagHelper(t.root, docIds)
+}
+
+func clearByTagHelper(node *TrieNode, docIds map[string]bool) bool {
 	if node == nil {
 		return false
 	}
 
 	// Recursively clear children
 	for key, child := range node.children {
-		if clearByTagHelper(child, docId) {
+		if clearByTagHelper(child, docIds) {
 			delete(node.children, key)
 		}
 	}
 
 	// Clear this node if it matches the tag
-	if node.symbol != nil && node.symbol.GetDocumentURI() == docId {
+	if node.symbol != nil && docIds[node.symbol.GetDocumentURI()] {
 		node.symbol = nil
 	}
 
@@ -126,3 +126,17 @@ func TestTrie_clearing_by_tag_should_keep_nodes_with_children(t *testing.T) {
 	assert.Equal(t, 1, len(trie.Search("app::structName::method1")))
 	assert.Equal(t, 0, len(trie.Search("app::structName::method2")))
 }
+
+func TestTrie_clearing_by_several_tags(t *testing.T) {
+	trie := NewTrie()
+	for _, docId := range []string{"doc-a", "doc-b", "doc-c"} {
+		fun := symbols.NewFunctionBuilder("method_"+docId, symbols.NewTypeFromString("void", "app"), "app", docId).Build()
+		trie.Insert(fun)
+	}
+
+	trie.ClearByTags([]string{"doc-a", "doc-c"})
+
+	assert.Equal(t, 0, len(trie.Search("app::method_doc-a")))
+	assert.Equal(t, 1, len(trie.Search("app::method_doc-b")))
+	assert.Equal(t, 0, len(trie.Search("app::method_doc-c")))
+}
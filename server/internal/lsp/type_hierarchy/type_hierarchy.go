@@ -0,0 +1,119 @@
+package type_hierarchy
+
+import (
+	"sort"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+)
+
+// IsHierarchyType tells if symbol is a type that can have supertypes or subtypes: structs,
+// which implement interfaces and inline other types, interfaces and typedefs.
+func IsHierarchyType(symbol symbols.Indexable) bool {
+	switch symbol.(type) {
+	case *symbols.Struct, *symbols.Interface, *symbols.Distinct:
+		return true
+	}
+
+	return false
+}
+
+// TypeDeclaredAt returns the type whose name is declared at position of docId.
+func TypeDeclaredAt(state *project_state.ProjectState, docId string, position symbols.Position) symbols.Indexable {
+	for _, symbol := range declaredTypes(state, docId) {
+		if symbol.GetIdRange().Start == position {
+			return symbol
+		}
+	}
+
+	return nil
+}
+
+// Supertypes returns the interfaces a struct implements and the types it inlines, or the type an
+// inline typedef is based on. Types that can't be found are skipped.
+func Supertypes(symbol symbols.Indexable, state *project_state.ProjectState) []symbols.Indexable {
+	supertypes := []symbols.Indexable{}
+	add := func(name string) {
+		if found := state.ResolveName(name, symbol.GetModuleString(), IsHierarchyType); found != nil {
+			supertypes = append(supertypes, found)
+		}
+	}
+
+	switch s := symbol.(type) {
+	case *symbols.Struct:
+		for _, name := range s.GetInterfaces() {
+			add(name)
+		}
+		for _, member := range s.GetMembers() {
+			if !s.GetDocumentRange().HasPosition(member.GetIdRange().Start) || member.GetDocumentURI() != s.GetDocumentURI() {
+				// Members of inlined types are appended after the ones declared.
+				break
+			}
+			if member.IsInlinePendingToResolve() || member.IsExpandedInline() {
+				add(member.GetType().GetName())
+			}
+		}
+
+	case *symbols.Distinct:
+		if s.IsInline() && !s.GetBaseType().IsBaseTypeLanguage() {
+			add(s.GetBaseType().GetName())
+		}
+	}
+
+	return supertypes
+}
+
+// Subtypes returns the types that have symbol as one of their supertypes, ordered by document
+// and position.
+func Subtypes(symbol symbols.Indexable, state *project_state.ProjectState) []symbols.Indexable {
+	subtypes := []symbols.Indexable{}
+	for docId := range state.GetAllUnitModules() {
+		for _, candidate := range declaredTypes(state, docId) {
+			for _, supertype := range Supertypes(candidate, state) {
+				if isSameSymbol(supertype, symbol) {
+					subtypes = append(subtypes, candidate)
+					break
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(subtypes, func(i, j int) bool {
+		if subtypes[i].GetDocumentURI() != subtypes[j].GetDocumentURI() {
+			return subtypes[i].GetDocumentURI() < subtypes[j].GetDocumentURI()
+		}
+
+		return subtypes[j].GetIdRange().IsBeforePosition(subtypes[i].GetIdRange().Start)
+	})
+
+	return subtypes
+}
+
+// declaredTypes returns the structs, interfaces and typedefs declared in docId.
+func declaredTypes(state *project_state.ProjectState, docId string) []symbols.Indexable {
+	unitModules := state.GetUnitModulesByDoc(docId)
+	if unitModules == nil {
+		return nil
+	}
+
+	found := []symbols.Indexable{}
+	for _, module := range unitModules.Modules() {
+		for _, strukt := range module.Structs {
+			found = append(found, strukt)
+		}
+		for _, _interface := range module.Interfaces {
+			found = append(found, _interface)
+		}
+		for _, distinct := range module.Distincts {
+			found = append(found, distinct)
+		}
+	}
+
+	return found
+}
+
+func isSameSymbol(a symbols.Indexable, b symbols.Indexable) bool {
+	return a.GetName() == b.GetName() &&
+		a.GetDocumentURI() == b.GetDocumentURI() &&
+		a.GetIdRange() == b.GetIdRange()
+}
@@ -0,0 +1,82 @@
+package type_hierarchy
+
+import (
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/internal/lsp/project_state"
+	"github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/stretchr/testify/assert"
+)
+
+func symbol(t *testing.T, state *project_state.ProjectState, fqn string) symbols.Indexable {
+	found := state.SearchByFQN(fqn)
+	assert.Equal(t, 1, len(found), "Type %s not found", fqn)
+
+	return found[0]
+}
+
+func names(types []symbols.Indexable) []string {
+	found := []string{}
+	for _, symbol := range types {
+		found = append(found, symbol.GetName())
+	}
+
+	return found
+}
+
+const shapes = `module shapes;
+interface Shape { fn float area(); }
+interface Named { fn String name(); }
+struct Point { int x; int y; }
+struct Circle (Shape, Named) {
+	inline Point center;
+	float radius;
+}
+struct Square (Shape) { float side; }
+typedef Meters = inline float;
+typedef Position = inline Point;`
+
+func TestSupertypes(t *testing.T) {
+	state := project_state.NewTestProjectState(map[string]string{"shapes.c3": shapes})
+
+	t.Run("Interfaces and inline members of structs", func(t *testing.T) {
+		assert.Equal(t, []string{"Shape", "Named", "Point"}, names(Supertypes(symbol(t, state, "shapes::Circle"), state)))
+	})
+
+	t.Run("Base type of inline typedefs", func(t *testing.T) {
+		assert.Equal(t, []string{"Point"}, names(Supertypes(symbol(t, state, "shapes::Position"), state)))
+	})
+
+	t.Run("Builtin types are not supertypes", func(t *testing.T) {
+		assert.Empty(t, Supertypes(symbol(t, state, "shapes::Meters"), state))
+	})
+}
+
+func TestSubtypes(t *testing.T) {
+	state := project_state.NewTestProjectState(map[string]string{
+		"shapes.c3": shapes,
+		"labels.c3": `module labels;
+import shapes;
+struct Label (shapes::Named) { String text; }`,
+	})
+
+	t.Run("Structs implementing an interface", func(t *testing.T) {
+		assert.Equal(t, []string{"Circle", "Square"}, names(Subtypes(symbol(t, state, "shapes::Shape"), state)))
+	})
+
+	t.Run("Structs implementing an interface of another module", func(t *testing.T) {
+		assert.Equal(t, []string{"Label", "Circle"}, names(Subtypes(symbol(t, state, "shapes::Named"), state)))
+	})
+
+	t.Run("Types inlining a struct", func(t *testing.T) {
+		assert.Equal(t, []string{"Circle", "Position"}, names(Subtypes(symbol(t, state, "shapes::Point"), state)))
+	})
+}
+
+func TestTypeDeclaredAt(t *testing.T) {
+	state := project_state.NewTestProjectState(map[string]string{"shapes.c3": shapes})
+
+	found := TypeDeclaredAt(state, "shapes.c3", symbols.NewPosition(4, 7))
+
+	assert.Equal(t, "Circle", found.GetName())
+}
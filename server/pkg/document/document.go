@@ -1,6 +1,8 @@
 package document
 
 import (
+	"strings"
+
 	"github.com/pherrymason/c3-lsp/internal/lsp/cst"
 	code "github.com/pherrymason/c3-lsp/pkg/document/sourcecode"
 	"github.com/pherrymason/c3-lsp/pkg/symbols"
@@ -31,6 +33,25 @@ func NewDocumentFromString(docId string, sourceCode string) Document {
 	return NewDocument(docId, sourceCode)
 }
 
+// NewDocumentWithoutTree creates a Document whose syntax tree is not parsed
+// until SyntaxTree is called. Used when symbols are already known, like when
+// they are loaded from the index cache.
+func NewDocumentWithoutTree(docId string, sourceCode string) Document {
+	return Document{
+		URI:        docId,
+		SourceCode: code.NewSourceCode(sourceCode),
+	}
+}
+
+// SyntaxTree returns the syntax tree of the document, parsing it if it was not yet.
+func (d *Document) SyntaxTree() *sitter.Tree {
+	if d.ContextSyntaxTree == nil {
+		d.ContextSyntaxTree = cst.GetParsedTreeFromString(d.SourceCode.Text)
+	}
+
+	return d.ContextSyntaxTree
+}
+
 func NewDocumentFromDocURI(docURI string, sourceCode string, docVersion int32) *Document {
 	normalizedPath := utils.NormalizePath(docURI)
 	doc := NewDocumentFromString(normalizedPath, sourceCode)
@@ -39,44 +60,53 @@ func NewDocumentFromDocURI(docURI string, sourceCode string, docVersion int32) *
 }
 
 // ApplyChanges updates the content of the Document from LSP textDocument/didChange events.
+// The syntax tree is edited along with the text, so it can be reparsed incrementally.
 func (d *Document) ApplyChanges(changes []interface{}) {
+	incremental := d.ContextSyntaxTree != nil
 	for _, change := range changes {
 		switch c := change.(type) {
 		case protocol.TextDocumentContentChangeEvent:
 			startIndex, endIndex := c.Range.IndexesIn(d.SourceCode.Text)
-			d.SourceCode.Text = d.SourceCode.Text[:startIndex] + c.Text + d.SourceCode.Text[endIndex:]
+			newText := d.SourceCode.Text[:startIndex] + c.Text + d.SourceCode.Text[endIndex:]
+			newEndIndex := startIndex + len(c.Text)
+
+			if incremental {
+				d.ContextSyntaxTree.Edit(sitter.EditInput{
+					StartIndex:  uint32(startIndex),
+					OldEndIndex: uint32(endIndex),
+					NewEndIndex: uint32(newEndIndex),
+					StartPoint:  indexToPoint(d.SourceCode.Text, startIndex),
+					OldEndPoint: indexToPoint(d.SourceCode.Text, endIndex),
+					NewEndPoint: indexToPoint(newText, newEndIndex),
+				})
+			}
+			d.SourceCode.Text = newText
 		case protocol.TextDocumentContentChangeEventWhole:
 			d.SourceCode.Text = c.Text
+			incremental = false
 		}
 	}
 
-	//d.lines = nil
-	d.updateParsedTree()
-	d.ContextSyntaxTree = cst.GetParsedTreeFromString(d.SourceCode.Text)
+	d.updateParsedTree(incremental)
+}
+
+func (d *Document) updateParsedTree(incremental bool) {
+	if !incremental {
+		d.ContextSyntaxTree = cst.GetParsedTreeFromString(d.SourceCode.Text)
+		return
+	}
+
+	d.ContextSyntaxTree = cst.ReparseTree(d.ContextSyntaxTree, d.SourceCode.Text)
 }
-func (d *Document) updateParsedTree() {
-	// TODO
-	// should the Document store the parsed CTS?
-	// would allow parsing incrementally and be faster
-	// // change 1 -> true
-	//		newText := []byte("let a = true")
-	//		tree.Edit(sitter.EditInput{
-	//    		StartIndex:  8,
-	//    		OldEndIndex: 9,
-	//    		NewEndIndex: 12,
-	//    		StartPoint: sitter.Point{
-	//    		    Row:    0,
-	//    		    Column: 8,
-	//    		},
-	//    		OldEndPoint: sitter.Point{
-	//    		    Row:    0,
-	//    		    Column: 9,
-	//    		},
-	//    		NewEndPoint: sitter.Point{
-	//    		    Row:    0,
-	//    		    Column: 12,
-	//    		},
-	//		})
+
+// indexToPoint converts a byte index of text to a tree-sitter point, whose column is measured in bytes.
+func indexToPoint(text string, index int) sitter.Point {
+	lineStart := strings.LastIndexByte(text[:index], '\n') + 1
+
+	return sitter.Point{
+		Row:    uint32(strings.Count(text[:index], "\n")),
+		Column: uint32(index - lineStart),
+	}
 }
 
 func (d *Document) HasPointInFrontSymbol(position symbols.Position) bool {
@@ -5,7 +5,9 @@ import (
 	"testing"
 
 	idx "github.com/pherrymason/c3-lsp/pkg/symbols"
+	sitter "github.com/smacker/go-tree-sitter"
 	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 func TestDocument_GetSymbolRangeAtIndex_does_not_find_symbol(t *testing.T) {
@@ -93,3 +95,48 @@ func TestDocument_ParentSymbolInPosition(t *testing.T) {
 		})
 	}
 }
+
+func TestDocument_ApplyChanges_reparses_incrementally(t *testing.T) {
+	source := "module app;\nfn void main() {\n\tint value = 1;\n}\n"
+	doc := NewDocument("x", source)
+
+	doc.ApplyChanges([]interface{}{
+		protocol.TextDocumentContentChangeEvent{
+			Range: &protocol.Range{
+				Start: protocol.Position{Line: 2, Character: 5},
+				End:   protocol.Position{Line: 2, Character: 10},
+			},
+			Text: "counter",
+		},
+		protocol.TextDocumentContentChangeEvent{
+			Range: &protocol.Range{
+				Start: protocol.Position{Line: 3, Character: 1},
+				End:   protocol.Position{Line: 3, Character: 1},
+			},
+			Text: "\nfn void other() {}",
+		},
+	})
+
+	expected := NewDocument("x", "module app;\nfn void main() {\n\tint counter = 1;\n}\nfn void other() {}\n")
+	assert.Equal(t, expected.SourceCode.Text, doc.SourceCode.Text)
+	assert.Equal(t, expected.ContextSyntaxTree.RootNode().String(), doc.ContextSyntaxTree.RootNode().String())
+}
+
+func TestIndexToPoint(t *testing.T) {
+	text := "ab\ncde\nf"
+
+	assert.Equal(t, sitter.Point{Row: 0, Column: 0}, indexToPoint(text, 0))
+	assert.Equal(t, sitter.Point{Row: 1, Column: 1}, indexToPoint(text, 4))
+	assert.Equal(t, sitter.Point{Row: 2, Column: 1}, indexToPoint(text, len(text)))
+}
+
+func TestDocument_without_tree_parses_it_once_needed(t *testing.T) {
+	doc := NewDocumentWithoutTree("x", "fn void main() {}")
+	assert.Nil(t, doc.ContextSyntaxTree)
+
+	tree := doc.SyntaxTree()
+
+	assert.NotNil(t, tree)
+	assert.Same(t, tree, doc.SyntaxTree(), "Tree is parsed only once")
+	assert.Equal(t, NewDocument("x", "fn void main() {}").ContextSyntaxTree.RootNode().String(), tree.RootNode().String())
+}
@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"hash/fnv"
+	"sync"
+
+	idx "github.com/pherrymason/c3-lsp/pkg/symbols"
+	"github.com/pherrymason/c3-lsp/pkg/symbols_table"
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// declarationKey identifies a top level declaration by its content and the
+// module it belongs to. Two declarations with the same key produce the same
+// symbols, once moved to where the declaration is placed.
+type declarationKey struct {
+	moduleHash uint64
+	hash       uint64
+}
+
+// declaration holds the symbols extracted from a top level declaration, so
+// they can be added again to the module of a later parse without extracting them.
+type declaration struct {
+	// register adds the symbols to module and their types to pending.
+	register func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve)
+	symbols  []idx.Indexable // Symbols registered, to move them along with the declaration
+	start    sitter.Point    // Start of the top level node the declaration was extracted from
+	end      idx.Position
+
+	// Types of the symbols pending to resolve, with the module they had
+	// when extracted, before being resolved against other documents.
+	types      []*idx.Type
+	typeModule []string
+	registered bool
+}
+
+func newDeclaration(node *sitter.Node, symbols []idx.Indexable, register func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve)) *declaration {
+	return &declaration{
+		register: register,
+		symbols:  symbols,
+		end:      idx.NewPositionFromTreeSitterPoint(node.EndPoint()),
+	}
+}
+
+// moveTo shifts the ranges of the symbols of the declaration to where topLevelNode,
+// having the same content as the top level node the declaration was extracted from, is placed.
+func (d *declaration) moveTo(topLevelNode *sitter.Node) {
+	start := topLevelNode.StartPoint()
+	if start == d.start {
+		return
+	}
+
+	lineDelta := int(start.Row) - int(d.start.Row)
+	characterDelta := int(start.Column) - int(d.start.Column)
+	for _, symbol := range d.symbols {
+		idx.ShiftRanges(symbol, uint(d.start.Row), lineDelta, characterDelta)
+	}
+
+	if d.end.Line == uint(d.start.Row) {
+		d.end.Character = uint(int(d.end.Character) + characterDelta)
+	}
+	d.end.Line = uint(int(d.end.Line) + lineDelta)
+	d.start = start
+}
+
+// addTo registers the symbols of the declaration in module. When they were
+// already registered by a previous parse, their types are restored to the
+// unresolved state, so they are resolved again against the current state of
+// other documents.
+func (d *declaration) addTo(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve) {
+	declarationPending := symbols_table.NewPendingToResolve()
+	d.register(module, docComment, &declarationPending)
+
+	if !d.registered {
+		d.types = declarationPending.Types()
+		d.typeModule = make([]string, len(d.types))
+		for i, vType := range d.types {
+			d.typeModule[i] = vType.GetModule()
+		}
+		d.registered = true
+	} else {
+		for i, vType := range d.types {
+			vType.SetModule(d.typeModule[i])
+		}
+	}
+
+	pending.Merge(declarationPending)
+}
+
+// declarationsCache keeps the declarations extracted in the last parse of
+// each document, so when a document is edited only the declarations that
+// changed need to be extracted again.
+type declarationsCache struct {
+	mu    sync.Mutex
+	byDoc map[string]map[declarationKey]*declaration
+}
+
+func newDeclarationsCache() *declarationsCache {
+	return &declarationsCache{
+		byDoc: make(map[string]map[declarationKey]*declaration),
+	}
+}
+
+func (c *declarationsCache) get(docId string) map[declarationKey]*declaration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.byDoc[docId]
+}
+
+// replace stores the declarations found in the last parse of docId, discarding the previous ones.
+func (c *declarationsCache) replace(docId string, declarations map[declarationKey]*declaration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.byDoc[docId] = declarations
+}
+
+func (c *declarationsCache) forget(docId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.byDoc, docId)
+}
+
+func newDeclarationKey(moduleHash uint64, node *sitter.Node, sourceCode []byte) declarationKey {
+	return declarationKey{
+		moduleHash: moduleHash,
+		hash:       hashContent(sourceCode[node.StartByte():node.EndByte()]),
+	}
+}
+
+func hashContent(content []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(content)
+
+	return h.Sum64()
+}
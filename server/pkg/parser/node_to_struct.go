@@ -97,6 +97,7 @@ func (p *Parser) parse_struct_body(bodyNode *sitter.Node, currentModule *idx.Mod
 		memberNode := bodyNode.Child(i)
 		isInline := false
 		isSubStruct := false
+		isUnion := false
 
 		//fmt.Println("body child:", memberNode.Type())
 		if memberNode.Type() != "struct_member_declaration" {
@@ -130,8 +131,18 @@ func (p *Parser) parse_struct_body(bodyNode *sitter.Node, currentModule *idx.Mod
 				}
 			case "attributes":
 				// TODO
+			case "union":
+				isUnion = true
 			case "bitstruct_body":
 				bitStructsMembers := p.nodeToBitStructMembers(n, currentModule, docId, sourceCode)
+				if len(bitStructsMembers) > 0 {
+					// A named bitstruct also adds a member holding it, after its bits.
+					blockMembers := len(bitStructsMembers)
+					if len(identifier) > 0 {
+						blockMembers++
+					}
+					bitStructsMembers[0].StartBlock(idx.MemberBlock{Kind: idx.MemberBlockBitstruct, BackingType: fieldType, Members: blockMembers})
+				}
 				structFields = append(structFields, bitStructsMembers...)
 
 			case "struct_body":
@@ -156,11 +167,11 @@ func (p *Parser) parse_struct_body(bodyNode *sitter.Node, currentModule *idx.Mod
 
 		if isSubStruct {
 			if len(identifier) > 0 {
-				structMember := idx.NewSubstructMember(identifier, innerStructBody, currentModule.GetModuleString(), *docId, identifiersRange[0])
+				structMember := idx.NewSubstructMember(identifier, innerStructBody, isUnion, currentModule.GetModuleString(), *docId, identifiersRange[0])
 				structFields = append(structFields, &structMember)
 			} else {
 				// Inline member
-				for _, member := range innerStructBody {
+				for j, member := range innerStructBody {
 					inlineMember := idx.NewInlineSubtype(
 						member.GetName(),
 						*member.GetType(),
@@ -168,6 +179,14 @@ func (p *Parser) parse_struct_body(bodyNode *sitter.Node, currentModule *idx.Mod
 						member.GetDocumentURI(),
 						member.GetIdRange(),
 					)
+					inlineMember.InheritLayout(member)
+					if j == 0 {
+						kind := idx.MemberBlockStruct
+						if isUnion {
+							kind = idx.MemberBlockUnion
+						}
+						inlineMember.StartBlock(idx.MemberBlock{Kind: kind, Members: len(innerStructBody)})
+					}
 					structFields = append(structFields, &inlineMember)
 				}
 			}
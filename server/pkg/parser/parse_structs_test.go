@@ -253,6 +253,17 @@ func TestParse_struct_with_anonymous_bitstructs(t *testing.T) {
 		})
 
 	}
+
+	t.Run("bitstructs are blocks of their members", func(t *testing.T) {
+		for _, i := range []int{0, 3} {
+			blocks := members[i].GetBlocks()
+			assert.Equal(t, 1, len(blocks))
+			assert.Equal(t, idx.MemberBlockBitstruct, blocks[0].Kind)
+			assert.Equal(t, "Register16", blocks[0].BackingType.GetName())
+			assert.Equal(t, 3, blocks[0].Members)
+		}
+		assert.Empty(t, members[6].GetBlocks())
+	})
 }
 
 func TestParse_struct_subtyping_members_should_be_flagged(t *testing.T) {
@@ -12,17 +12,24 @@ import (
 )
 
 type Parser struct {
-	logger commonlog.Logger
+	logger       commonlog.Logger
+	declarations *declarationsCache
 	//pendingToResolve symbols_table.PendingToResolve
 }
 
 func NewParser(logger commonlog.Logger) Parser {
 	return Parser{
-		logger: logger,
+		logger:       logger,
+		declarations: newDeclarationsCache(),
 		//pendingToResolve: symbols_table.NewPendingToResolve(),
 	}
 }
 
+// ForgetDocument discards the declarations remembered from the last parse of docId.
+func (p *Parser) ForgetDocument(docId string) {
+	p.declarations.forget(docId)
+}
+
 func (p *Parser) ClearProject() {
 	// p.pendingToResolve = symbols_table.NewPendingToResolve()
 }
@@ -30,193 +37,121 @@ func (p *Parser) ClearProject() {
 func (p *Parser) ParseSymbols(doc *document.Document) (symbols_table.UnitModules, symbols_table.PendingToResolve) {
 	parsedModules := symbols_table.NewParsedModules(&doc.URI)
 	pendingToResolve := symbols_table.NewPendingToResolve()
-	//fmt.Println(doc.URI, doc.ContextSyntaxTree.RootNode())
-
-	/*
-		q, err := sitter.NewQuery([]byte(query), cst.GetLanguage())
-		if err != nil {
-			panic(err)
-		}
-		qc := sitter.NewQueryCursor()
-		qc.Exec(q, doc.ContextSyntaxTree.RootNode())*/
-	qc := cst.RunQuery(queries.SymbolsQuery, doc.ContextSyntaxTree.RootNode())
+	root := doc.SyntaxTree().RootNode()
 	sourceCode := []byte(doc.SourceCode.Text)
-	//fmt.Println(doc.URI, " ", doc.ContextSyntaxTree.RootNode())
-	//fmt.Println(doc.ContextSyntaxTree.RootNode().Content(sourceCode))
-	//parsed := fmt.Sprint(doc.URI, " ", doc.ContextSyntaxTree.RootNode())
-	//fmt.Println(parsed)
 	var moduleSymbol *idx.Module
 	anonymousModuleName := true
 	lastModuleName := ""
 	var lastDocComment *idx.DocComment = nil
-	//subtyptingToResolve := []StructWithSubtyping{}
+	var moduleHash uint64
+
+	// Top level declarations that did not change since last parse are reused
+	// instead of being extracted again, so the symbols query only runs over
+	// the ones that changed.
+	previousDeclarations := p.declarations.get(doc.URI)
+	declarations := make(map[declarationKey]*declaration)
+
+	initModule := func() {
+		moduleSymbol = parsedModules.GetOrInitModule(
+			lastModuleName,
+			&doc.URI,
+			root,
+			anonymousModuleName,
+		)
+	}
+	addDeclaration := func(key declarationKey, decl *declaration, topLevelNode *sitter.Node) {
+		decl.start = topLevelNode.StartPoint()
+		decl.addTo(moduleSymbol, lastDocComment, &pendingToResolve)
+		declarations[key] = decl
+	}
 
-	for {
-		m, ok := qc.NextMatch()
-		if !ok {
-			break
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		topLevelNode := root.NamedChild(i)
+		key := newDeclarationKey(moduleHash, topLevelNode, sourceCode)
+		// Repeated declarations can't share their symbols: only the first one is reused.
+		if decl, found := previousDeclarations[key]; found && declarations[key] == nil {
+			initModule()
+			decl.moveTo(topLevelNode)
+			addDeclaration(key, decl, topLevelNode)
+			lastDocComment = nil
+			moduleSymbol.SetEndPosition(decl.end)
+			continue
 		}
 
-		for _, c := range m.Captures {
-			nodeType := c.Node.Type()
-			nodeEndPoint := idx.NewPositionFromTreeSitterPoint(c.Node.EndPoint())
-			if nodeType != "module_declaration" && nodeType != "doc_comment" {
-				moduleSymbol = parsedModules.GetOrInitModule(
-					lastModuleName,
-					&doc.URI,
-					doc.ContextSyntaxTree.RootNode(),
-					anonymousModuleName,
-				)
+		qc := cst.RunQueryInRange(queries.SymbolsQuery, root, topLevelNode)
+		for {
+			m, ok := qc.NextMatch()
+			if !ok {
+				break
 			}
 
-			switch nodeType {
-			case "doc_comment":
-				lastDocComment = cast.ToPtr(p.nodeToDocComment(c.Node, sourceCode))
-			case "module_declaration":
-				anonymousModuleName = false
-				module, _, _ := p.nodeToModule(doc, c.Node, sourceCode)
-				lastModuleName = module.GetName()
-				moduleSymbol = parsedModules.UpdateOrInitModule(
-					module,
-					doc.ContextSyntaxTree.RootNode(),
-				)
-
-				start := c.Node.StartPoint()
-				moduleSymbol.
-					SetStartPosition(idx.NewPositionFromTreeSitterPoint(start))
-
-				moduleSymbol.SetStartPosition(idx.NewPositionFromTreeSitterPoint(start))
-				moduleSymbol.ChangeModule(lastModuleName)
-
-				if lastDocComment != nil {
-					moduleSymbol.SetDocComment(lastDocComment)
+			for _, c := range m.Captures {
+				if !cst.IsNodeInside(c.Node, topLevelNode) {
+					continue
 				}
 
-			case "import_declaration":
-				imports := p.nodeToImport(doc, c.Node, sourceCode)
-				moduleSymbol.AddImports(imports)
-
-			case "declaration":
-				variables := p.variableDeclarationNodeToVariable(c.Node, moduleSymbol, &doc.URI, sourceCode)
-				moduleSymbol.AddVariables(variables)
-				pendingToResolve.AddVariableType(variables, moduleSymbol)
-
-				if lastDocComment != nil {
-					for _, v := range variables {
-						v.SetDocComment(lastDocComment)
-					}
+				nodeType := c.Node.Type()
+				nodeEndPoint := idx.NewPositionFromTreeSitterPoint(c.Node.EndPoint())
+				if nodeType != "module_declaration" && nodeType != "doc_comment" {
+					initModule()
 				}
 
-			case "func_definition", "func_declaration":
-				function, err := p.nodeToFunction(c.Node, moduleSymbol, &doc.URI, sourceCode)
-				if err == nil {
-					moduleSymbol.AddFunction(&function)
-					pendingToResolve.AddFunctionTypes(&function, moduleSymbol)
+				switch nodeType {
+				case "doc_comment":
+					lastDocComment = cast.ToPtr(p.nodeToDocComment(c.Node, sourceCode))
+				case "module_declaration":
+					anonymousModuleName = false
+					moduleHash = hashContent(sourceCode[c.Node.StartByte():c.Node.EndByte()])
+					module, _, _ := p.nodeToModule(doc, c.Node, sourceCode)
+					lastModuleName = module.GetName()
+					moduleSymbol = parsedModules.UpdateOrInitModule(
+						module,
+						root,
+					)
+
+					start := c.Node.StartPoint()
+					moduleSymbol.
+						SetStartPosition(idx.NewPositionFromTreeSitterPoint(start))
+
+					moduleSymbol.SetStartPosition(idx.NewPositionFromTreeSitterPoint(start))
+					moduleSymbol.ChangeModule(lastModuleName)
 
 					if lastDocComment != nil {
-						function.SetDocComment(lastDocComment)
+						moduleSymbol.SetDocComment(lastDocComment)
 					}
-				}
-
-			case "enum_declaration":
-				enum := p.nodeToEnum(c.Node, moduleSymbol, &doc.URI, sourceCode)
-				moduleSymbol.AddEnum(&enum)
-
-				if lastDocComment != nil {
-					enum.SetDocComment(lastDocComment)
-				}
-
-			case "struct_declaration":
-				strukt, membersNeedingSubtypingResolve := p.nodeToStruct(c.Node, moduleSymbol, &doc.URI, sourceCode)
-				moduleSymbol.AddStruct(&strukt)
-				if len(membersNeedingSubtypingResolve) > 0 {
-					pendingToResolve.AddStructSubtype(&strukt, membersNeedingSubtypingResolve)
-				}
-
-				pendingToResolve.AddStructMemberTypes(&strukt, moduleSymbol)
-
-				if lastDocComment != nil {
-					strukt.SetDocComment(lastDocComment)
-				}
-
-			case "bitstruct_declaration":
-				bitstruct := p.nodeToBitStruct(c.Node, moduleSymbol, &doc.URI, sourceCode)
-				moduleSymbol.AddBitstruct(&bitstruct)
-
-				if lastDocComment != nil {
-					bitstruct.SetDocComment(lastDocComment)
-				}
-
-			// TODO: @0.7.7 rename internal methods/structs from Def -> Alias
-			case "alias_declaration":
-				def := p.nodeToDef(c.Node, moduleSymbol, &doc.URI, sourceCode)
-				moduleSymbol.AddDef(&def)
-				pendingToResolve.AddDefType(&def, moduleSymbol)
-
-				if lastDocComment != nil {
-					def.SetDocComment(lastDocComment)
-				}
-
-			// TODO: @0.7.7 rename internal methods/structs from Distinct  -> TypeDef
-			case "typedef_declaration":
-				distinct := p.nodeToDistinct(c.Node, moduleSymbol, &doc.URI, sourceCode)
-				moduleSymbol.AddDistinct(&distinct)
-				pendingToResolve.AddDistinctType(&distinct, moduleSymbol)
 
-				if lastDocComment != nil {
-					distinct.SetDocComment(lastDocComment)
-				}
-
-			case "const_declaration":
-				_const := p.nodeToConstant(c.Node, moduleSymbol, &doc.URI, sourceCode)
-				moduleSymbol.AddVariable(&_const)
-
-				if lastDocComment != nil {
-					_const.SetDocComment(lastDocComment)
-				}
-
-			// TODO: @0.7.7 rename internal methods/structs from Fault -> FaultDef
-			case "faultdef_declaration":
-				fault := p.nodeToFault(c.Node, moduleSymbol, &doc.URI, sourceCode)
-				moduleSymbol.AddFault(&fault)
-
-				if lastDocComment != nil {
-					fault.SetDocComment(lastDocComment)
-				}
-
-			case "interface_declaration":
-				interf := p.nodeToInterface(c.Node, moduleSymbol, &doc.URI, sourceCode)
-				moduleSymbol.AddInterface(&interf)
-
-				if lastDocComment != nil {
-					interf.SetDocComment(lastDocComment)
-				}
-
-			case "macro_declaration":
-				macro, err := p.nodeToMacro(c.Node, moduleSymbol, &doc.URI, sourceCode)
-				if err == nil {
-					moduleSymbol.AddFunction(&macro)
+				case "import_declaration":
+					imports := p.nodeToImport(doc, c.Node, sourceCode)
+					moduleSymbol.AddImports(imports)
+
+				default:
+					decl, isDeclaration, reusable := p.nodeToDeclaration(c.Node, moduleSymbol, &doc.URI, sourceCode)
+					if !isDeclaration {
+						// TODO test that module ends up with wrong endPosition
+						// when this source code:
+						// int variable = 3;
+						// fn void main() {
+						// int value = 4;
+						// v
+						// }
+						lastDocComment = nil
+						continue
+					}
 
-					if lastDocComment != nil {
-						macro.SetDocComment(lastDocComment)
+					if decl != nil {
+						if reusable && declarations[key] == nil {
+							addDeclaration(key, decl, topLevelNode)
+						} else {
+							decl.addTo(moduleSymbol, lastDocComment, &pendingToResolve)
+						}
 					}
 				}
-			default:
-				// TODO test that module ends up with wrong endPosition
-				// when this source code:
-				// int variable = 3;
-				// fn void main() {
-				// int value = 4;
-				// v
-				// }
-				lastDocComment = nil
-				continue
-			}
 
-			if nodeType != "doc_comment" {
-				// Ensure the next node won't receive the same doc comment
-				lastDocComment = nil
-				moduleSymbol.SetEndPosition(nodeEndPoint)
+				if nodeType != "doc_comment" {
+					// Ensure the next node won't receive the same doc comment
+					lastDocComment = nil
+					moduleSymbol.SetEndPosition(nodeEndPoint)
+				}
 			}
 		}
 	}
@@ -224,17 +159,127 @@ func (p *Parser) ParseSymbols(doc *document.Document) (symbols_table.UnitModules
 	if moduleSymbol != nil {
 		moduleSymbol.SetEndPosition(
 			idx.NewPositionFromTreeSitterPoint(
-				doc.ContextSyntaxTree.RootNode().EndPoint(),
+				root.EndPoint(),
 			),
 		)
 	}
 
+	p.declarations.replace(doc.URI, declarations)
+
 	// Try to resolve as many types as possible
 	//p.resolveTypes(&parsedModules)
 
 	return parsedModules, pendingToResolve
 }
 
+// nodeToDeclaration extracts the symbols of a top level declaration node.
+// isDeclaration is false when node does not declare symbols. The returned
+// declaration is nil when node could not be extracted, and is not reusable when
+// its symbols will be modified by other documents once registered.
+func (p *Parser) nodeToDeclaration(node *sitter.Node, moduleSymbol *idx.Module, docId *string, sourceCode []byte) (decl *declaration, isDeclaration bool, reusable bool) {
+	switch node.Type() {
+	case "declaration":
+		variables := p.variableDeclarationNodeToVariable(node, moduleSymbol, docId, sourceCode)
+		return newDeclaration(node, variablesIndexables(variables), func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve) {
+			module.AddVariables(variables)
+			pending.AddVariableType(variables, module)
+			for _, v := range variables {
+				v.SetDocComment(docComment)
+			}
+		}), true, true
+
+	case "func_definition", "func_declaration":
+		function, err := p.nodeToFunction(node, moduleSymbol, docId, sourceCode)
+		if err != nil {
+			return nil, true, false
+		}
+		return newDeclaration(node, []idx.Indexable{&function}, func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve) {
+			module.AddFunction(&function)
+			pending.AddFunctionTypes(&function, module)
+			function.SetDocComment(docComment)
+		}), true, true
+
+	case "enum_declaration":
+		enum := p.nodeToEnum(node, moduleSymbol, docId, sourceCode)
+		return newDeclaration(node, []idx.Indexable{&enum}, func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve) {
+			module.AddEnum(&enum)
+			enum.SetDocComment(docComment)
+		}), true, true
+
+	case "struct_declaration":
+		strukt, membersNeedingSubtypingResolve := p.nodeToStruct(node, moduleSymbol, docId, sourceCode)
+		// Inline members are expanded appending the members of other structs,
+		// so structs having them are extracted again on each parse.
+		return newDeclaration(node, []idx.Indexable{&strukt}, func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve) {
+			module.AddStruct(&strukt)
+			if len(membersNeedingSubtypingResolve) > 0 {
+				pending.AddStructSubtype(&strukt, membersNeedingSubtypingResolve)
+			}
+			pending.AddStructMemberTypes(&strukt, module)
+			strukt.SetDocComment(docComment)
+		}), true, len(membersNeedingSubtypingResolve) == 0
+
+	case "bitstruct_declaration":
+		bitstruct := p.nodeToBitStruct(node, moduleSymbol, docId, sourceCode)
+		return newDeclaration(node, []idx.Indexable{&bitstruct}, func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve) {
+			module.AddBitstruct(&bitstruct)
+			bitstruct.SetDocComment(docComment)
+		}), true, true
+
+	// TODO: @0.7.7 rename internal methods/structs from Def -> Alias
+	case "alias_declaration":
+		def := p.nodeToDef(node, moduleSymbol, docId, sourceCode)
+		return newDeclaration(node, []idx.Indexable{&def}, func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve) {
+			module.AddDef(&def)
+			pending.AddDefType(&def, module)
+			def.SetDocComment(docComment)
+		}), true, true
+
+	// TODO: @0.7.7 rename internal methods/structs from Distinct  -> TypeDef
+	case "typedef_declaration":
+		distinct := p.nodeToDistinct(node, moduleSymbol, docId, sourceCode)
+		return newDeclaration(node, []idx.Indexable{&distinct}, func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve) {
+			module.AddDistinct(&distinct)
+			pending.AddDistinctType(&distinct, module)
+			distinct.SetDocComment(docComment)
+		}), true, true
+
+	case "const_declaration":
+		_const := p.nodeToConstant(node, moduleSymbol, docId, sourceCode)
+		return newDeclaration(node, []idx.Indexable{&_const}, func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve) {
+			module.AddVariable(&_const)
+			_const.SetDocComment(docComment)
+		}), true, true
+
+	// TODO: @0.7.7 rename internal methods/structs from Fault -> FaultDef
+	case "faultdef_declaration":
+		fault := p.nodeToFault(node, moduleSymbol, docId, sourceCode)
+		return newDeclaration(node, []idx.Indexable{&fault}, func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve) {
+			module.AddFault(&fault)
+			fault.SetDocComment(docComment)
+		}), true, true
+
+	case "interface_declaration":
+		interf := p.nodeToInterface(node, moduleSymbol, docId, sourceCode)
+		return newDeclaration(node, []idx.Indexable{&interf}, func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve) {
+			module.AddInterface(&interf)
+			interf.SetDocComment(docComment)
+		}), true, true
+
+	case "macro_declaration":
+		macro, err := p.nodeToMacro(node, moduleSymbol, docId, sourceCode)
+		if err != nil {
+			return nil, true, false
+		}
+		return newDeclaration(node, []idx.Indexable{&macro}, func(module *idx.Module, docComment *idx.DocComment, pending *symbols_table.PendingToResolve) {
+			module.AddFunction(&macro)
+			macro.SetDocComment(docComment)
+		}), true, true
+	}
+
+	return nil, false, false
+}
+
 func (p *Parser) FindVariableDeclarations(node *sitter.Node, moduleName string, currentModule *idx.Module, docId *string, sourceCode []byte) []*idx.Variable {
 	qc := cst.RunQuery(queries.LocalVarDeclQuery, node)
 
@@ -265,3 +310,12 @@ func (p *Parser) FindVariableDeclarations(node *sitter.Node, moduleName string,
 
 	return variables
 }
+
+func variablesIndexables(variables []*idx.Variable) []idx.Indexable {
+	indexables := make([]idx.Indexable, 0, len(variables))
+	for _, variable := range variables {
+		indexables = append(indexables, variable)
+	}
+
+	return indexables
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/pherrymason/c3-lsp/pkg/document"
@@ -832,3 +833,117 @@ func TestExtractSymbols_flags_types_as_pending_to_be_resolved(t *testing.T) {
 		assert.Equal(t, 0, len(pendingToResolve.GetTypesByModule(docId)), "Basic types should not be registered as pending to resolve.")
 	})
 }
+
+func TestExtractSymbols_reuses_unchanged_declarations(t *testing.T) {
+	source := `module app;
+	fn void first() {
+		int a = 1;
+	}
+	fn void second() {
+		int b = 2;
+	}`
+	doc := document.NewDocument("docId", source)
+	parser := createParser()
+	symbols, _ := parser.ParseSymbols(&doc)
+	first := symbols.Get("app").GetChildrenFunctionByName("first").Get()
+	second := symbols.Get("app").GetChildrenFunctionByName("second").Get()
+
+	changed := document.NewDocument("docId", strings.Replace(source, "int b = 2;", "int c = 3;", 1))
+	symbols, _ = parser.ParseSymbols(&changed)
+
+	assert.Same(t, first, symbols.Get("app").GetChildrenFunctionByName("first").Get(), "Unchanged function should be reused")
+	assert.NotSame(t, second, symbols.Get("app").GetChildrenFunctionByName("second").Get(), "Changed function should be extracted again")
+	assert.NotNil(t, symbols.Get("app").GetChildrenFunctionByName("second").Get().Variables["c"])
+}
+
+func TestExtractSymbols_reuses_unchanged_declarations_of_every_kind(t *testing.T) {
+	source := `module app;
+	struct Point { int x; int y; }
+	enum Color { RED, GREEN }
+	const int MAX = 3;
+	fn void main() {
+		int a = 1;
+	}`
+	doc := document.NewDocument("docId", source)
+	parser := createParser()
+	symbols, _ := parser.ParseSymbols(&doc)
+	point := symbols.Get("app").Structs["Point"]
+	color := symbols.Get("app").Enums["Color"]
+	max := symbols.Get("app").Variables["MAX"]
+
+	changed := document.NewDocument("docId", strings.Replace(source, "int a = 1;", "int a = 2;", 1))
+	symbols, _ = parser.ParseSymbols(&changed)
+
+	assert.Same(t, point, symbols.Get("app").Structs["Point"])
+	assert.Same(t, color, symbols.Get("app").Enums["Color"])
+	assert.Same(t, max, symbols.Get("app").Variables["MAX"])
+}
+
+func TestExtractSymbols_moves_reused_declarations(t *testing.T) {
+	source := `module app;
+fn void first() {}
+fn void second(int value) {
+	int a = value;
+}`
+	doc := document.NewDocument("docId", source)
+	parser := createParser()
+	symbols, _ := parser.ParseSymbols(&doc)
+	second := symbols.Get("app").GetChildrenFunctionByName("second").Get()
+
+	changed := document.NewDocument("docId", strings.Replace(source, "fn void first() {}", "import std::io;\nfn void first() {}", 1))
+	symbols, _ = parser.ParseSymbols(&changed)
+
+	assert.Same(t, second, symbols.Get("app").GetChildrenFunctionByName("second").Get(), "Moved function should be reused")
+	assert.Equal(t, idx.NewRange(3, 8, 3, 14), second.GetIdRange())
+	assert.Equal(t, idx.NewRange(3, 0, 5, 1), second.GetDocumentRange())
+	assert.Equal(t, idx.NewRange(3, 19, 3, 24), second.Variables["value"].GetIdRange())
+	assert.Equal(t, idx.NewRange(4, 5, 4, 6), second.Variables["a"].GetIdRange())
+}
+
+func TestExtractSymbols_resolves_again_types_of_reused_declarations(t *testing.T) {
+	source := `module app;
+	fn Foo make() {}`
+	doc := document.NewDocument("docId", source)
+	parser := createParser()
+	symbols, _ := parser.ParseSymbols(&doc)
+	function := symbols.Get("app").GetChildrenFunctionByName("make").Get()
+	// Resolved while registering against another document.
+	function.GetReturnType().SetModule("other")
+
+	changed := document.NewDocument("docId", source+"\nfn void main() {}")
+	symbols, pendingToResolve := parser.ParseSymbols(&changed)
+
+	assert.Same(t, function, symbols.Get("app").GetChildrenFunctionByName("make").Get())
+	assert.Equal(t, "app", function.GetReturnType().GetModule(), "Reused type should not keep its previous resolution")
+	assert.Equal(t, 1, len(pendingToResolve.GetTypesByModule("app")), "Reused type should be resolved again")
+}
+
+func TestExtractSymbols_updates_doc_comment_of_reused_declarations(t *testing.T) {
+	source := `module app;
+	<* First version *>
+	fn void main() {}`
+	doc := document.NewDocument("docId", source)
+	parser := createParser()
+	parser.ParseSymbols(&doc)
+
+	changed := document.NewDocument("docId", strings.Replace(source, "First", "Second", 1))
+	symbols, _ := parser.ParseSymbols(&changed)
+
+	function := symbols.Get("app").GetChildrenFunctionByName("main").Get()
+	assert.Equal(t, "Second version", function.GetDocComment().GetBody())
+}
+
+func TestExtractSymbols_extracts_again_structs_with_inline_members(t *testing.T) {
+	source := `module app;
+	struct Base { int x; }
+	struct Child { inline Base base; }`
+	doc := document.NewDocument("docId", source)
+	parser := createParser()
+	symbols, _ := parser.ParseSymbols(&doc)
+	child := symbols.Get("app").Structs["Child"]
+
+	changed := document.NewDocument("docId", source+"\nfn void main() {}")
+	symbols, _ = parser.ParseSymbols(&changed)
+
+	assert.NotSame(t, child, symbols.Get("app").Structs["Child"])
+}
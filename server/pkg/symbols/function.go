@@ -108,6 +108,34 @@ func (f Function) GetTypeIdentifier() string {
 	return f.typeIdentifier
 }
 
+// IsDynamic tells if the method is marked @dynamic, so it can be invoked through interfaces.
+func (f *Function) IsDynamic() bool {
+	for _, attribute := range f.GetAttributes() {
+		if attribute == "@dynamic" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsMethodOf tells if f is a method of the type typeName declared in module. The type of a
+// method can be qualified with its module path (`fn void shapes::Square.draw()`).
+func (f *Function) IsMethodOf(typeName string, module string) bool {
+	methodType := f.typeIdentifier
+	path := ""
+	if i := strings.LastIndex(methodType, "::"); i >= 0 {
+		path = methodType[:i]
+		methodType = methodType[i+2:]
+	}
+
+	if methodType != typeName {
+		return false
+	}
+
+	return path == "" || module == path || strings.HasSuffix(module, "::"+path)
+}
+
 func (f Function) GetKind() protocol.CompletionItemKind {
 	switch f.fType {
 	case Method:
@@ -171,6 +171,35 @@ func (b *BaseIndexable) formatSource(source string) string {
 	return fmt.Sprintf("```c3\n%s```", source)
 }
 
+func (b *BaseIndexable) shiftRanges(line uint, lineDelta int, characterDelta int) {
+	b.IdRange = b.IdRange.shifted(line, lineDelta, characterDelta)
+	b.DocRange = b.DocRange.shifted(line, lineDelta, characterDelta)
+}
+
+// ShiftRanges moves the ranges of symbol and of the symbols it contains, as if the source
+// code declaring them starting in line was moved lineDelta lines and, in that same line,
+// characterDelta characters.
+func ShiftRanges(symbol Indexable, line uint, lineDelta int, characterDelta int) {
+	shifted := map[Indexable]bool{}
+	pending := []Indexable{symbol}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if shifted[current] {
+			continue
+		}
+		shifted[current] = true
+
+		if shifter, ok := current.(interface {
+			shiftRanges(line uint, lineDelta int, characterDelta int)
+		}); ok {
+			shifter.shiftRanges(line, lineDelta, characterDelta)
+		}
+		pending = append(pending, current.Children()...)
+		pending = append(pending, current.NestedScopes()...)
+	}
+}
+
 func NewBaseIndexable(name string, module string, docId protocol.DocumentUri, idRange Range, docRange Range, kind protocol.CompletionItemKind) BaseIndexable {
 	return BaseIndexable{
 		Name:           name,
@@ -0,0 +1,21 @@
+package symbols
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShiftRanges(t *testing.T) {
+	function := NewFunctionBuilder("run", NewTypeFromString("void", "app"), "app", "app.c3").
+		WithArgument(NewVariableBuilder("count", NewTypeFromString("int", "app"), "app", "app.c3").WithIdentifierRange(2, 16, 2, 21).Build()).
+		WithIdentifierRange(2, 8, 2, 11).
+		WithDocumentRange(2, 0, 4, 1).
+		Build()
+
+	ShiftRanges(function, 2, 3, 4)
+
+	assert.Equal(t, NewRange(5, 12, 5, 15), function.GetIdRange())
+	assert.Equal(t, NewRange(5, 4, 7, 1), function.GetDocumentRange(), "Only positions in the first line move sideways")
+	assert.Equal(t, NewRange(5, 20, 5, 25), function.Variables["count"].GetIdRange(), "Contained symbols are moved")
+}
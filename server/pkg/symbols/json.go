@@ -0,0 +1,584 @@
+package symbols
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pherrymason/c3-lsp/pkg/option"
+)
+
+// JSON serialization of symbols.
+// Symbols keep most of their state in unexported fields, so each of them
+// declares a mirror struct with exported fields used to (un)marshal it.
+// Data that can be derived, like children lists or module paths, is not
+// stored but rebuilt after loading.
+
+type typeJSON struct {
+	BaseTypeLanguage  bool               `json:"baseTypeLanguage,omitempty"`
+	Name              string             `json:"name"`
+	Pointer           int                `json:"pointer,omitempty"`
+	Optional          bool               `json:"optional,omitempty"`
+	GenericArguments  []Type             `json:"genericArguments,omitempty"`
+	Module            string             `json:"module,omitempty"`
+	IsGenericArgument bool               `json:"isGenericArgument,omitempty"`
+	IsCollection      bool               `json:"isCollection,omitempty"`
+	CollectionSize    option.Option[int] `json:"collectionSize"`
+}
+
+func (t Type) MarshalJSON() ([]byte, error) {
+	return json.Marshal(typeJSON{
+		BaseTypeLanguage:  t.baseTypeLanguage,
+		Name:              t.name,
+		Pointer:           t.pointer,
+		Optional:          t.optional,
+		GenericArguments:  t.genericArguments,
+		Module:            t.module,
+		IsGenericArgument: t.isGenericArgument,
+		IsCollection:      t.isCollection,
+		CollectionSize:    t.collectionSize,
+	})
+}
+
+func (t *Type) UnmarshalJSON(data []byte) error {
+	var j typeJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*t = Type{
+		baseTypeLanguage:  j.BaseTypeLanguage,
+		name:              j.Name,
+		pointer:           j.Pointer,
+		optional:          j.Optional,
+		genericArguments:  j.GenericArguments,
+		module:            j.Module,
+		isGenericArgument: j.IsGenericArgument,
+		isCollection:      j.IsCollection,
+		collectionSize:    j.CollectionSize,
+	}
+
+	return nil
+}
+
+type docCommentContractJSON struct {
+	Name string `json:"name"`
+	Body string `json:"body"`
+}
+
+type docCommentJSON struct {
+	Body      string                   `json:"body"`
+	Contracts []docCommentContractJSON `json:"contracts,omitempty"`
+}
+
+func (d DocComment) MarshalJSON() ([]byte, error) {
+	j := docCommentJSON{Body: d.body}
+	for _, contract := range d.contracts {
+		j.Contracts = append(j.Contracts, docCommentContractJSON{Name: contract.name, Body: contract.body})
+	}
+
+	return json.Marshal(j)
+}
+
+func (d *DocComment) UnmarshalJSON(data []byte) error {
+	var j docCommentJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*d = NewDocComment(j.Body)
+	for _, contract := range j.Contracts {
+		d.contracts = append(d.contracts, &DocCommentContract{name: contract.Name, body: contract.Body})
+	}
+
+	return nil
+}
+
+// restoreModulePath rebuilds Module, which is not serialized, from ModuleString.
+func (b *BaseIndexable) restoreModulePath() {
+	b.Module = NewModulePathFromString(b.ModuleString)
+}
+
+// insertChildren registers children in the same order they are declared in source code.
+func (b *BaseIndexable) insertChildren(children []Indexable) {
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].GetIdRange().IsAfterPosition(children[j].GetIdRange().Start)
+	})
+
+	for _, child := range children {
+		b.Insert(child)
+	}
+}
+
+type variableJSON struct {
+	Type Type    `json:"type"`
+	Arg  ArgInfo `json:"arg"`
+	BaseIndexable
+}
+
+func (v Variable) MarshalJSON() ([]byte, error) {
+	return json.Marshal(variableJSON{Type: v.Type, Arg: v.Arg, BaseIndexable: v.BaseIndexable})
+}
+
+func (v *Variable) UnmarshalJSON(data []byte) error {
+	var j variableJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*v = Variable{Type: j.Type, Arg: j.Arg, BaseIndexable: j.BaseIndexable}
+	v.restoreModulePath()
+
+	return nil
+}
+
+type functionJSON struct {
+	FunctionType   FunctionType         `json:"functionType"`
+	ReturnType     Type                 `json:"returnType"`
+	ArgumentIds    []string             `json:"argumentIds"`
+	TypeIdentifier string               `json:"typeIdentifier,omitempty"`
+	Variables      map[string]*Variable `json:"variables"`
+	BaseIndexable
+}
+
+func (f Function) MarshalJSON() ([]byte, error) {
+	return json.Marshal(functionJSON{
+		FunctionType:   f.fType,
+		ReturnType:     f.returnType,
+		ArgumentIds:    f.argumentIds,
+		TypeIdentifier: f.typeIdentifier,
+		Variables:      f.Variables,
+		BaseIndexable:  f.BaseIndexable,
+	})
+}
+
+func (f *Function) UnmarshalJSON(data []byte) error {
+	var j functionJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*f = Function{
+		fType:          j.FunctionType,
+		returnType:     j.ReturnType,
+		argumentIds:    j.ArgumentIds,
+		typeIdentifier: j.TypeIdentifier,
+		Variables:      j.Variables,
+		BaseIndexable:  j.BaseIndexable,
+	}
+	if f.Variables == nil {
+		f.Variables = make(map[string]*Variable)
+	}
+	f.restoreModulePath()
+
+	children := []Indexable{}
+	for _, variable := range f.Variables {
+		children = append(children, variable)
+	}
+	f.insertChildren(children)
+
+	return nil
+}
+
+type structMemberJSON struct {
+	BaseType             Type                   `json:"baseType"`
+	BitRange             option.Option[[2]uint] `json:"bitRange"`
+	InlinePendingResolve bool                   `json:"inlinePendingResolve,omitempty"`
+	ExpandedInline       bool                   `json:"expandedInline,omitempty"`
+	IsStruct             bool                   `json:"isStruct,omitempty"`
+	SubStruct            option.Option[*Struct] `json:"subStruct"`
+	Blocks               []MemberBlock          `json:"blocks,omitempty"`
+	BaseIndexable
+}
+
+func (m StructMember) MarshalJSON() ([]byte, error) {
+	return json.Marshal(structMemberJSON{
+		BaseType:             m.baseType,
+		BitRange:             m.bitRange,
+		InlinePendingResolve: m.inlinePendingResolve,
+		ExpandedInline:       m.expandedInline,
+		IsStruct:             m.isStruct,
+		SubStruct:            m.subStruct,
+		Blocks:               m.blocks,
+		BaseIndexable:        m.BaseIndexable,
+	})
+}
+
+func (m *StructMember) UnmarshalJSON(data []byte) error {
+	var j structMemberJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*m = StructMember{
+		baseType:             j.BaseType,
+		bitRange:             j.BitRange,
+		inlinePendingResolve: j.InlinePendingResolve,
+		expandedInline:       j.ExpandedInline,
+		isStruct:             j.IsStruct,
+		subStruct:            j.SubStruct,
+		blocks:               j.Blocks,
+		BaseIndexable:        j.BaseIndexable,
+	}
+	m.restoreModulePath()
+	if m.subStruct.IsSome() {
+		m.Insert(m.subStruct.Get())
+	}
+
+	return nil
+}
+
+type structJSON struct {
+	Members    []*StructMember `json:"members"`
+	IsUnion    bool            `json:"isUnion,omitempty"`
+	Implements []string        `json:"implements,omitempty"`
+	BaseIndexable
+}
+
+func (s Struct) MarshalJSON() ([]byte, error) {
+	return json.Marshal(structJSON{
+		Members:       s.members,
+		IsUnion:       s.isUnion,
+		Implements:    s.implements,
+		BaseIndexable: s.BaseIndexable,
+	})
+}
+
+func (s *Struct) UnmarshalJSON(data []byte) error {
+	var j structJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*s = Struct{
+		members:       j.Members,
+		isUnion:       j.IsUnion,
+		implements:    j.Implements,
+		BaseIndexable: j.BaseIndexable,
+	}
+	s.restoreModulePath()
+	for _, member := range s.members {
+		s.Insert(member)
+	}
+
+	return nil
+}
+
+type bitstructJSON struct {
+	BackingType Type            `json:"backingType"`
+	Members     []*StructMember `json:"members"`
+	Implements  []string        `json:"implements,omitempty"`
+	BaseIndexable
+}
+
+func (b Bitstruct) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bitstructJSON{
+		BackingType:   b.backingType,
+		Members:       b.members,
+		Implements:    b.implements,
+		BaseIndexable: b.BaseIndexable,
+	})
+}
+
+func (b *Bitstruct) UnmarshalJSON(data []byte) error {
+	var j bitstructJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*b = Bitstruct{
+		backingType:   j.BackingType,
+		members:       j.Members,
+		implements:    j.Implements,
+		BaseIndexable: j.BaseIndexable,
+	}
+	b.restoreModulePath()
+	for _, member := range b.members {
+		b.Insert(member)
+	}
+
+	return nil
+}
+
+type enumeratorJSON struct {
+	Value            string     `json:"value,omitempty"`
+	AssociatedValues []Variable `json:"associatedValues,omitempty"`
+	EnumName         string     `json:"enumName"`
+	BaseIndexable
+}
+
+func (e Enumerator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(enumeratorJSON{
+		Value:            e.value,
+		AssociatedValues: e.AssociatedValues,
+		EnumName:         e.EnumName,
+		BaseIndexable:    e.BaseIndexable,
+	})
+}
+
+func (e *Enumerator) UnmarshalJSON(data []byte) error {
+	var j enumeratorJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*e = Enumerator{
+		value:            j.Value,
+		AssociatedValues: j.AssociatedValues,
+		EnumName:         j.EnumName,
+		BaseIndexable:    j.BaseIndexable,
+	}
+	e.restoreModulePath()
+	for i := range e.AssociatedValues {
+		e.InsertNestedScope(&e.AssociatedValues[i])
+	}
+
+	return nil
+}
+
+type enumJSON struct {
+	BaseType    string        `json:"baseType,omitempty"`
+	Enumerators []*Enumerator `json:"enumerators"`
+	BaseIndexable
+}
+
+func (e Enum) MarshalJSON() ([]byte, error) {
+	return json.Marshal(enumJSON{BaseType: e.baseType, Enumerators: e.enumerators, BaseIndexable: e.BaseIndexable})
+}
+
+func (e *Enum) UnmarshalJSON(data []byte) error {
+	var j enumJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*e = Enum{baseType: j.BaseType, BaseIndexable: j.BaseIndexable}
+	e.restoreModulePath()
+	e.AddEnumerators(j.Enumerators)
+
+	return nil
+}
+
+type faultConstantJSON struct {
+	FaultName string `json:"faultName"`
+	BaseIndexable
+}
+
+func (c FaultConstant) MarshalJSON() ([]byte, error) {
+	return json.Marshal(faultConstantJSON{FaultName: c.faultName, BaseIndexable: c.BaseIndexable})
+}
+
+func (c *FaultConstant) UnmarshalJSON(data []byte) error {
+	var j faultConstantJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*c = FaultConstant{faultName: j.FaultName, BaseIndexable: j.BaseIndexable}
+	c.restoreModulePath()
+
+	return nil
+}
+
+type faultJSON struct {
+	BaseType  string           `json:"baseType,omitempty"`
+	Constants []*FaultConstant `json:"constants"`
+	BaseIndexable
+}
+
+func (e Fault) MarshalJSON() ([]byte, error) {
+	return json.Marshal(faultJSON{BaseType: e.baseType, Constants: e.constants, BaseIndexable: e.BaseIndexable})
+}
+
+func (e *Fault) UnmarshalJSON(data []byte) error {
+	var j faultJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*e = Fault{baseType: j.BaseType, BaseIndexable: j.BaseIndexable}
+	e.restoreModulePath()
+	e.AddConstants(j.Constants)
+
+	return nil
+}
+
+type interfaceJSON struct {
+	Methods map[string]*Function `json:"methods"`
+	BaseIndexable
+}
+
+func (i Interface) MarshalJSON() ([]byte, error) {
+	return json.Marshal(interfaceJSON{Methods: i.methods, BaseIndexable: i.BaseIndexable})
+}
+
+func (i *Interface) UnmarshalJSON(data []byte) error {
+	var j interfaceJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*i = Interface{methods: make(map[string]*Function), BaseIndexable: j.BaseIndexable}
+	i.restoreModulePath()
+
+	methods := []*Function{}
+	for _, method := range j.Methods {
+		methods = append(methods, method)
+	}
+	sort.SliceStable(methods, func(a, b int) bool {
+		return methods[a].GetIdRange().IsAfterPosition(methods[b].GetIdRange().Start)
+	})
+	i.AddMethods(methods)
+
+	return nil
+}
+
+type defJSON struct {
+	ResolvesTo     string               `json:"resolvesTo,omitempty"`
+	ResolvesToType option.Option[*Type] `json:"resolvesToType"`
+	BaseIndexable
+}
+
+func (d Def) MarshalJSON() ([]byte, error) {
+	return json.Marshal(defJSON{ResolvesTo: d.resolvesTo, ResolvesToType: d.resolvesToType, BaseIndexable: d.BaseIndexable})
+}
+
+func (d *Def) UnmarshalJSON(data []byte) error {
+	var j defJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*d = Def{resolvesTo: j.ResolvesTo, resolvesToType: j.ResolvesToType, BaseIndexable: j.BaseIndexable}
+	d.restoreModulePath()
+
+	return nil
+}
+
+type distinctJSON struct {
+	BaseType *Type `json:"baseType"`
+	Inline   bool  `json:"inline,omitempty"`
+	BaseIndexable
+}
+
+func (d Distinct) MarshalJSON() ([]byte, error) {
+	return json.Marshal(distinctJSON{BaseType: d.baseType, Inline: d.inline, BaseIndexable: d.BaseIndexable})
+}
+
+func (d *Distinct) UnmarshalJSON(data []byte) error {
+	var j distinctJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*d = Distinct{baseType: j.BaseType, inline: j.Inline, BaseIndexable: j.BaseIndexable}
+	d.restoreModulePath()
+
+	return nil
+}
+
+type genericParameterJSON struct {
+	BaseIndexable
+}
+
+func (g *GenericParameter) UnmarshalJSON(data []byte) error {
+	var j genericParameterJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*g = GenericParameter{BaseIndexable: j.BaseIndexable}
+	g.restoreModulePath()
+
+	return nil
+}
+
+type moduleJSON struct {
+	Variables         map[string]*Variable         `json:"variables"`
+	Enums             map[string]*Enum             `json:"enums"`
+	Faults            []*Fault                     `json:"faults"`
+	Structs           map[string]*Struct           `json:"structs"`
+	Bitstructs        map[string]*Bitstruct        `json:"bitstructs"`
+	Defs              map[string]*Def              `json:"defs"`
+	Distincts         map[string]*Distinct         `json:"distincts"`
+	ChildrenFunctions []*Function                  `json:"functions"`
+	Interfaces        map[string]*Interface        `json:"interfaces"`
+	Imports           []string                     `json:"imports"`
+	GenericParameters map[string]*GenericParameter `json:"genericParameters,omitempty"`
+	BaseIndexable
+}
+
+func (m Module) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moduleJSON{
+		Variables:         m.Variables,
+		Enums:             m.Enums,
+		Faults:            m.Faults,
+		Structs:           m.Structs,
+		Bitstructs:        m.Bitstructs,
+		Defs:              m.Defs,
+		Distincts:         m.Distincts,
+		ChildrenFunctions: m.ChildrenFunctions,
+		Interfaces:        m.Interfaces,
+		Imports:           m.Imports,
+		GenericParameters: m.GenericParameters,
+		BaseIndexable:     m.BaseIndexable,
+	})
+}
+
+func (m *Module) UnmarshalJSON(data []byte) error {
+	var j moduleJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*m = *NewModule(j.Name, j.DocumentURI, j.IdRange, j.DocRange)
+	m.BaseIndexable = j.BaseIndexable
+	m.restoreModulePath()
+	if j.Imports != nil {
+		m.Imports = j.Imports
+	}
+	m.GenericParameters = j.GenericParameters
+
+	children := []Indexable{}
+	for name, variable := range j.Variables {
+		m.Variables[name] = variable
+		children = append(children, variable)
+	}
+	for name, enum := range j.Enums {
+		m.Enums[name] = enum
+		children = append(children, enum)
+	}
+	for _, fault := range j.Faults {
+		m.Faults = append(m.Faults, fault)
+		children = append(children, fault)
+	}
+	for name, strukt := range j.Structs {
+		m.Structs[name] = strukt
+		children = append(children, strukt)
+	}
+	for name, bitstruct := range j.Bitstructs {
+		m.Bitstructs[name] = bitstruct
+		children = append(children, bitstruct)
+	}
+	for name, def := range j.Defs {
+		m.Defs[name] = def
+		children = append(children, def)
+	}
+	for name, distinct := range j.Distincts {
+		m.Distincts[name] = distinct
+		children = append(children, distinct)
+	}
+	for name, _interface := range j.Interfaces {
+		m.Interfaces[name] = _interface
+		children = append(children, _interface)
+	}
+	for _, generic := range j.GenericParameters {
+		children = append(children, generic)
+	}
+	m.insertChildren(children)
+
+	for _, function := range j.ChildrenFunctions {
+		m.AddFunction(function)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package symbols
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pherrymason/c3-lsp/pkg/option"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModule_json_round_trip(t *testing.T) {
+	docId := "app.c3"
+	module := NewModuleBuilder("foo::bar", docId).Build()
+	module.AddImports([]string{"std::io"})
+	module.AddVariable(
+		NewVariableBuilder("count", NewTypeFromString("int", "foo::bar"), "foo::bar", docId).
+			WithIdentifierRange(1, 4, 1, 9).
+			Build(),
+	)
+	module.AddFunction(
+		NewFunctionBuilder("sum", NewTypeFromString("int", "foo::bar"), "foo::bar", docId).
+			WithArgument(NewVariableBuilder("a", NewTypeFromString("int", "foo::bar"), "foo::bar", docId).Build()).
+			WithTypeIdentifier("Point").
+			WithIdentifierRange(3, 4, 3, 7).
+			Build(),
+	)
+	module.AddStruct(
+		NewStructBuilder("Point", "foo::bar", docId).
+			WithStructMember("x", NewType(false, "Coord", 1, false, true, option.Some(2), "foo::bar"), "foo::bar", docId).
+			ImplementsInterface("Shape").
+			Build(),
+	)
+	module.AddEnum(
+		NewEnumBuilder("Color", "int", "foo::bar", docId).
+			WithEnumerator(NewEnumeratorBuilder("RED", docId).WithEnumName("Color").Build()).
+			Build(),
+	)
+
+	data, err := json.Marshal(module)
+	assert.NoError(t, err)
+
+	var loaded Module
+	assert.NoError(t, json.Unmarshal(data, &loaded))
+
+	assert.Equal(t, "foo::bar", loaded.GetName())
+	assert.Equal(t, []string{"foo", "bar"}, loaded.GetModule().tokens)
+	assert.Equal(t, []string{"std::io"}, loaded.Imports)
+
+	assert.Equal(t, "int", loaded.Variables["count"].GetType().GetName())
+	assert.Equal(t, NewRange(1, 4, 1, 9), loaded.Variables["count"].GetIdRange())
+
+	function := loaded.ChildrenFunctions[0]
+	assert.Equal(t, "Point.sum", function.GetFullName())
+	assert.Equal(t, []string{"a"}, function.ArgumentIds())
+	assert.Equal(t, "int", function.Variables["a"].GetType().GetName())
+	assert.Len(t, function.Children(), 1)
+
+	strukt := loaded.Structs["Point"]
+	assert.Equal(t, []string{"Shape"}, strukt.GetInterfaces())
+	memberType := strukt.GetMembers()[0].GetType()
+	assert.Equal(t, "Coord*[2]", memberType.String())
+	assert.Len(t, strukt.Children(), 1)
+
+	enum := loaded.Enums["Color"]
+	assert.Equal(t, "RED", enum.GetEnumerators()[0].GetName())
+	assert.Equal(t, "Color", enum.GetEnumerators()[0].EnumName)
+
+	assert.Len(t, loaded.Children(), 3)
+	assert.Len(t, loaded.NestedScopes(), 1)
+}
+
+func childNames(indexable Indexable) []string {
+	names := []string{}
+	for _, child := range indexable.Children() {
+		names = append(names, child.GetName())
+	}
+
+	return names
+}
+
+func TestModule_json_round_trip_keeps_declaration_order(t *testing.T) {
+	docId := "app.c3"
+	module := NewModuleBuilder("app", docId).Build()
+	module.AddVariable(NewVariableBuilder("first", NewTypeFromString("int", "app"), "app", docId).WithIdentifierRange(1, 4, 1, 9).Build())
+	module.AddStruct(NewStructBuilder("Second", "app", docId).WithIdentifierRange(2, 7, 2, 13).Build())
+	module.AddVariable(NewVariableBuilder("third", NewTypeFromString("int", "app"), "app", docId).WithIdentifierRange(3, 4, 3, 9).Build())
+
+	_interface := NewInterfaceBuilder("Fourth", "app", docId).WithIdentifierRange(4, 10, 4, 16).Build()
+	_interface.AddMethods([]*Function{
+		NewFunctionBuilder("open", NewTypeFromString("void", "app"), "app", docId).WithIdentifierRange(5, 9, 5, 13).Build(),
+		NewFunctionBuilder("read", NewTypeFromString("void", "app"), "app", docId).WithIdentifierRange(6, 9, 6, 13).Build(),
+		NewFunctionBuilder("close", NewTypeFromString("void", "app"), "app", docId).WithIdentifierRange(7, 9, 7, 14).Build(),
+	})
+	module.AddInterface(&_interface)
+
+	data, err := json.Marshal(module)
+	assert.NoError(t, err)
+
+	var loaded Module
+	assert.NoError(t, json.Unmarshal(data, &loaded))
+
+	assert.Equal(t, []string{"first", "Second", "third", "Fourth"}, childNames(&loaded))
+	assert.Equal(t, []string{"open", "read", "close"}, childNames(loaded.Interfaces["Fourth"]))
+}
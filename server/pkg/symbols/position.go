@@ -35,6 +35,15 @@ func (p Position) ToLSPPosition() protocol.Position {
 	}
 }
 
+func (p Position) shifted(line uint, lineDelta int, characterDelta int) Position {
+	if p.Line == line {
+		p.Character = uint(int(p.Character) + characterDelta)
+	}
+	p.Line = uint(int(p.Line) + lineDelta)
+
+	return p
+}
+
 func (self Position) IndexIn(content string) int {
 	// This code is modified from the gopls implementation found:
 	// https://cs.opensource.google/go/x/tools/+/refs/tags/v0.1.5:internal/span/utf16.go;l=70
@@ -83,6 +83,15 @@ func (r Range) IsAfter(crange Range) bool {
 	return false
 }
 
+// shifted moves r lineDelta lines. Positions in line are also moved characterDelta
+// characters, as happens to text starting in line when it is moved.
+func (r Range) shifted(line uint, lineDelta int, characterDelta int) Range {
+	return Range{
+		Start: r.Start.shifted(line, lineDelta, characterDelta),
+		End:   r.End.shifted(line, lineDelta, characterDelta),
+	}
+}
+
 func (r Range) ToLSP() protocol.Range {
 	return protocol.Range{
 		Start: protocol.Position{Line: uint32(r.Start.Line), Character: uint32(r.Start.Character)},
@@ -98,9 +98,40 @@ type StructMember struct {
 	expandedInline       bool
 	isStruct             bool
 	subStruct            option.Option[*Struct]
+	blocks               []MemberBlock // Anonymous blocks starting at this member, outermost first
 	BaseIndexable
 }
 
+const (
+	MemberBlockStruct = iota
+	MemberBlockUnion
+	MemberBlockBitstruct
+)
+
+// MemberBlock is an anonymous struct, union or bitstruct declared inside a struct. Its members
+// are accessed as members of the enclosing struct, which lists them one after the other,
+// starting with the member the block is attached to.
+type MemberBlock struct {
+	Kind        int  `json:"kind"`
+	BackingType Type `json:"backingType"` // Type holding the bits of a bitstruct
+	Members     int  `json:"members"`
+}
+
+func (m StructMember) GetBlocks() []MemberBlock {
+	return m.blocks
+}
+
+// StartBlock makes the member the first one of block, enclosing the blocks it already started.
+func (m *StructMember) StartBlock(block MemberBlock) {
+	m.blocks = append([]MemberBlock{block}, m.blocks...)
+}
+
+// InheritLayout copies the bit range and blocks of other, which is being flattened into this member.
+func (m *StructMember) InheritLayout(other *StructMember) {
+	m.bitRange = other.bitRange
+	m.blocks = append([]MemberBlock{}, other.blocks...)
+}
+
 func (m StructMember) IsInlinePendingToResolve() bool {
 	return m.inlinePendingResolve
 }
@@ -125,6 +156,10 @@ func (m StructMember) GetBitRange() [2]uint {
 	return m.bitRange.Get()
 }
 
+func (m StructMember) HasBitRange() bool {
+	return m.bitRange.IsSome()
+}
+
 func (s StructMember) GetHoverInfo() string {
 	return fmt.Sprintf("%s %s", s.baseType, s.Name)
 }
@@ -169,7 +204,7 @@ func NewInlineSubtype(name string, fieldType Type, module string, docId string,
 	}
 }
 
-func NewSubstructMember(name string, members []*StructMember, module string, docId string, idRange Range) StructMember {
+func NewSubstructMember(name string, members []*StructMember, isUnion bool, module string, docId string, idRange Range) StructMember {
 	substruct := NewStruct(
 		name,
 		[]string{},
@@ -179,6 +214,7 @@ func NewSubstructMember(name string, members []*StructMember, module string, doc
 		idRange,
 		NewRange(0, 0, 0, 0),
 	)
+	substruct.isUnion = isUnion
 
 	sm := StructMember{
 		bitRange:             option.None[[2]uint](),
@@ -37,12 +37,81 @@ func NewPendingToResolve() PendingToResolve {
 	}
 }
 
+// NewPendingToResolveFromModules collects the types of already parsed modules
+// that still need to be resolved, like ParseSymbols does while parsing them.
+// Used with modules that were not parsed, but loaded from a cache.
+func NewPendingToResolveFromModules(unitModules UnitModules) PendingToResolve {
+	pending := NewPendingToResolve()
+	for _, module := range unitModules.Modules() {
+		variables := []*symbols.Variable{}
+		for _, variable := range module.Variables {
+			variables = append(variables, variable)
+		}
+		pending.AddVariableType(variables, module)
+
+		for _, function := range module.ChildrenFunctions {
+			pending.AddFunctionTypes(function, module)
+		}
+		for _, strukt := range module.Structs {
+			if inlineTypes := inlineMemberTypes(strukt); len(inlineTypes) > 0 {
+				pending.AddStructSubtype(strukt, inlineTypes)
+			}
+			pending.AddStructMemberTypes(strukt, module)
+		}
+		for _, def := range module.Defs {
+			pending.AddDefType(def, module)
+		}
+		for _, distinct := range module.Distincts {
+			pending.AddDistinctType(distinct, module)
+		}
+	}
+
+	return pending
+}
+
+// inlineMemberTypes returns the types of inline members pending to be expanded,
+// including the ones from anonymous substructs.
+func inlineMemberTypes(strukt *symbols.Struct) []symbols.Type {
+	types := []symbols.Type{}
+	for _, member := range strukt.GetMembers() {
+		if member.IsInlinePendingToResolve() && !member.IsExpandedInline() {
+			types = append(types, *member.GetType())
+		}
+		if substruct := member.Substruct(); substruct.IsSome() {
+			types = append(types, inlineMemberTypes(substruct.Get())...)
+		}
+	}
+
+	return types
+}
+
 // Getters ----------
 func (p *PendingToResolve) GetTypesByModule(docId string) []PendingTypeContext {
 	return p.typesByModule[docId]
 }
 
+// Types returns the types waiting to be resolved, of every module.
+func (p *PendingToResolve) Types() []*symbols.Type {
+	types := []*symbols.Type{}
+	for _, typesContext := range p.typesByModule {
+		for _, typeContext := range typesContext {
+			types = append(types, typeContext.vType)
+		}
+	}
+
+	return types
+}
+
 // Setters ----------
+// Merge adds the types and inline structs pending to resolve of other.
+func (p *PendingToResolve) Merge(other PendingToResolve) {
+	for moduleName, types := range other.typesByModule {
+		p.typesByModule[moduleName] = append(p.typesByModule[moduleName], types...)
+	}
+
+	p.subtyptingToResolve = append(p.subtyptingToResolve, other.subtyptingToResolve...)
+}
+
 func (p *PendingToResolve) AddStructSubtype(strukt *symbols.Struct, types []symbols.Type) {
 	p.subtyptingToResolve = append(
 		p.subtyptingToResolve,
@@ -21,19 +21,7 @@ func NewSymbolsTable() SymbolsTable {
 func (st *SymbolsTable) Register(unitModules UnitModules, pendingToResolve PendingToResolve) {
 	st.parsedModulesByDocument[unitModules.DocId()] = unitModules
 
-	// Merge pendingToResolve types
-	for moduleName, types := range pendingToResolve.typesByModule {
-		st.pendingToResolve.typesByModule[moduleName] = append(
-			st.pendingToResolve.typesByModule[moduleName],
-			types...,
-		)
-	}
-
-	st.pendingToResolve.subtyptingToResolve = append(
-		st.pendingToResolve.subtyptingToResolve,
-		pendingToResolve.subtyptingToResolve...,
-	)
-
+	st.pendingToResolve.Merge(pendingToResolve)
 	st.resolveTypes()
 }
 
@@ -13,11 +13,6 @@ func TestIsFeatureEnabled(t *testing.T) {
 			feature:  "USE_SEARCH_V2",
 			expected: false,
 		},
-		{
-			name:     "SIZE_ON_HOVER is disabled by default",
-			feature:  "SIZE_ON_HOVER",
-			expected: false,
-		},
 		{
 			name:     "Unknown feature returns false",
 			feature:  "UNKNOWN_FEATURE",